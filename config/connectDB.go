@@ -11,7 +11,8 @@ import (
 	"gorm.io/gorm"
 )
 
-func ConnectDB() *gorm.DB {
+// DSN returns the Postgres connection string from the environment.
+func DSN() string {
 
 	host := os.Getenv("HOST")
 	user := os.Getenv("USER")
@@ -36,17 +37,35 @@ func ConnectDB() *gorm.DB {
 	if os.Getenv("POSTGRES_URI") != "" {
 		dsn = os.Getenv("POSTGRES_URI")
 	}
+	return dsn
+}
+
+func ConnectDB() *gorm.DB {
+
+	dsn := DSN()
 
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
 	if err != nil {
 		panic("failed to connect to database: " + err.Error())
 	}
 
-	err = db.AutoMigrate(&model.User{}, &model.Task{}, &model.EmailVerification{})
+	err = db.AutoMigrate(&model.User{}, &model.Task{}, &model.EmailVerification{}, &model.TaskEvent{}, &model.Comment{}, &model.Attachment{}, &model.TaskDependency{}, &model.ImportJob{}, &model.TimeEntry{}, &model.TaskReminder{}, &model.Notification{}, &model.NotificationPreference{}, &model.Webhook{}, &model.WebhookDelivery{}, &model.SavedView{}, &model.TaskTemplate{}, &model.CustomField{})
 	if err != nil {
 		panic("failed to auto-migrate: " + err.Error())
 	}
 
+	// = and IN filters on custom fields compile to JSONB containment (@>),
+	// which this index serves
+	err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_tasks_custom_fields ON tasks USING GIN (custom_fields)`).Error
+	if err != nil {
+		panic("failed to index custom fields: " + err.Error())
+	}
+
+	err = SetupTaskSearch(db)
+	if err != nil {
+		panic("failed to set up task search: " + err.Error())
+	}
+
 	log.Info().Msg("Database connected and models migrated successfully")
 	return db
 }
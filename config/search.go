@@ -0,0 +1,150 @@
+package config
+
+import (
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// SQLiteDriver is the database/sql driver to open SQLite with, e.g.
+// sqlite.New(sqlite.Config{DriverName: config.SQLiteDriver, DSN: dsn}). It
+// is go-sqlite3 plus fts4_rank, which ranks task search in SQL when the
+// driver is built without FTS5.
+const SQLiteDriver = "sqlite3_tasks"
+
+func init() {
+	sql.Register(SQLiteDriver, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			return conn.RegisterFunc("fts4_rank", fts4Rank, true)
+		},
+	})
+}
+
+// SetupTaskSearch creates the full-text index used by task search and keeps
+// it in sync with the tasks table:
+//   - Postgres: a generated tsvector column with a GIN index
+//   - SQLite: an external-content FTS5 table maintained by triggers. Drivers
+//     built without the sqlite_fts5 tag fall back to FTS4, ranked by
+//     fts4_rank, so the database must be opened with SQLiteDriver.
+//
+// It must run after the tasks table has been migrated and is safe to run on
+// every start.
+func SetupTaskSearch(db *gorm.DB) error {
+	switch db.Dialector.Name() {
+	case "postgres":
+		return setupPostgresSearch(db)
+	case "sqlite":
+		return setupSQLiteSearch(db)
+	default:
+		log.Warn().Str("dialect", db.Dialector.Name()).Msg("Full-text task search is not supported on this database")
+		return nil
+	}
+}
+
+func setupPostgresSearch(db *gorm.DB) error {
+	statements := []string{
+		`ALTER TABLE tasks ADD COLUMN IF NOT EXISTS search_vector tsvector
+			GENERATED ALWAYS AS (
+				setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+				setweight(to_tsvector('english', coalesce(description, '')), 'B')
+			) STORED`,
+		`CREATE INDEX IF NOT EXISTS idx_tasks_search_vector ON tasks USING GIN (search_vector)`,
+	}
+	for _, stmt := range statements {
+		err := db.Exec(stmt).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setupSQLiteSearch(db *gorm.DB) error {
+
+	var existing string
+	db.Raw(`SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'tasks_fts'`).Scan(&existing)
+	if existing != "" {
+		return nil
+	}
+
+	err := db.Exec(`CREATE VIRTUAL TABLE tasks_fts USING fts5(title, description, content='tasks', content_rowid='id')`).Error
+	if err == nil {
+		return createSQLiteSearchTriggers(db,
+			`INSERT INTO tasks_fts(tasks_fts, rowid, title, description) VALUES ('delete', old.id, old.title, old.description);`)
+	}
+	if !strings.Contains(err.Error(), "no such module") {
+		return err
+	}
+
+	err = db.Exec(`SELECT fts4_rank(X'')`).Error
+	if err != nil {
+		return fmt.Errorf("SQLite without FTS5 needs the %s driver to rank task search: %w", SQLiteDriver, err)
+	}
+	log.Warn().Msg("SQLite driver built without FTS5 (build tag sqlite_fts5) - using FTS4 for task search")
+
+	err = db.Exec(`CREATE VIRTUAL TABLE tasks_fts USING fts4(title, description, content='tasks')`).Error
+	if err != nil {
+		return err
+	}
+	return createSQLiteSearchTriggers(db, `DELETE FROM tasks_fts WHERE docid = old.id;`)
+}
+
+// createSQLiteSearchTriggers mirrors every change of tasks into tasks_fts.
+// removeOld is the statement that drops the old row from the index.
+func createSQLiteSearchTriggers(db *gorm.DB, removeOld string) error {
+	statements := []string{
+		`CREATE TRIGGER tasks_fts_insert AFTER INSERT ON tasks BEGIN
+			INSERT INTO tasks_fts(rowid, title, description) VALUES (new.id, new.title, new.description);
+		END`,
+		`CREATE TRIGGER tasks_fts_delete BEFORE DELETE ON tasks BEGIN ` + removeOld + ` END`,
+		`CREATE TRIGGER tasks_fts_update_before BEFORE UPDATE OF title, description ON tasks BEGIN ` + removeOld + ` END`,
+		`CREATE TRIGGER tasks_fts_update_after AFTER UPDATE OF title, description ON tasks BEGIN
+			INSERT INTO tasks_fts(rowid, title, description) VALUES (new.id, new.title, new.description);
+		END`,
+		// index rows that existed before the table was created
+		`INSERT INTO tasks_fts(tasks_fts) VALUES ('rebuild')`,
+	}
+	for _, stmt := range statements {
+		err := db.Exec(stmt).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fts4Rank computes a relevance score from an FTS4 matchinfo('pcx') blob:
+// for every phrase and column, the hits in this row divided by the hits in
+// all rows, weighted per column.
+func fts4Rank(info []byte, weights ...float64) float64 {
+
+	if len(info) < 8 {
+		return 0
+	}
+
+	value := func(i int) float64 {
+		return float64(binary.NativeEndian.Uint32(info[i*4:]))
+	}
+
+	phrases, columns := int(value(0)), int(value(1))
+	if len(info) < (2+phrases*columns*3)*4 {
+		return 0
+	}
+
+	score := 0.0
+	for p := 0; p < phrases; p++ {
+		for c := 0; c < columns && c < len(weights); c++ {
+			base := 2 + (p*columns+c)*3
+			hitsThisRow, hitsAllRows := value(base), value(base+1)
+			if hitsAllRows > 0 {
+				score += weights[c] * hitsThisRow / hitsAllRows
+			}
+		}
+	}
+	return score
+}
@@ -0,0 +1,52 @@
+// Package events fans out changes to the clients that stream them. A Broker
+// keeps the latest events in a bounded buffer so a client that reconnects
+// with the ID of the last event it saw gets the ones it missed.
+package events
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// DefaultReplaySize is how many events a reconnecting client can catch up on.
+const DefaultReplaySize = 1000
+
+// Event is one change, delivered to the users in UserIDs.
+type Event struct {
+	// ID is assigned by the broker when the event is published.
+	ID      string          `json:"id"`
+	Type    string          `json:"type"`
+	UserIDs []uint          `json:"user_ids"`
+	Data    json.RawMessage `json:"data"`
+	// Volatile events are only delivered live, never replayed.
+	Volatile bool `json:"volatile,omitempty"`
+}
+
+// Broker publishes events and hands them to subscribers. Implementations
+// must be safe for concurrent use.
+type Broker interface {
+	// Publish sends the event to the subscribers of its users.
+	Publish(ctx context.Context, event Event) error
+	// Subscribe starts receiving the user's events. With a lastEventID the
+	// buffered events after it are replayed first.
+	Subscribe(userID uint, lastEventID string) *Subscription
+}
+
+// Subscription receives one user's events.
+type Subscription struct {
+	// Replay holds the buffered events after the requested last event ID.
+	Replay []Event
+	// Missed is set when the requested last event ID is no longer buffered,
+	// so events may have been lost and the client should reload.
+	Missed bool
+	// Events delivers new events. It is closed when the subscriber falls
+	// too far behind, after which it should resubscribe.
+	Events <-chan Event
+
+	close func()
+}
+
+// Close stops the subscription.
+func (s *Subscription) Close() {
+	s.close()
+}
@@ -0,0 +1,116 @@
+package events
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// subscriberBuffer is how many events a subscriber may fall behind before it
+// is dropped.
+const subscriberBuffer = 64
+
+// Hub is an in-process Broker. On its own it only reaches the subscribers of
+// this instance; Postgres uses one to fan out what other instances publish.
+type Hub struct {
+	mu          sync.Mutex
+	next        uint64
+	size        int
+	buffer      []Event
+	subscribers map[*subscriber]struct{}
+}
+
+type subscriber struct {
+	userID uint
+	ch     chan Event
+}
+
+// NewHub returns a hub that keeps the last size events for replay.
+func NewHub(size int) *Hub {
+	return &Hub{size: size, subscribers: map[*subscriber]struct{}{}}
+}
+
+func (h *Hub) Publish(ctx context.Context, event Event) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.next++
+	event.ID = strconv.FormatUint(h.next, 10)
+	h.deliverLocked(event)
+	return nil
+}
+
+// deliver buffers an event that already has an ID and hands it to the
+// subscribers.
+func (h *Hub) deliver(event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.deliverLocked(event)
+}
+
+func (h *Hub) deliverLocked(event Event) {
+
+	if !event.Volatile {
+		h.buffer = append(h.buffer, event)
+		if len(h.buffer) > h.size {
+			h.buffer = slices.Delete(h.buffer, 0, len(h.buffer)-h.size)
+		}
+	}
+
+	for sub := range h.subscribers {
+		if !slices.Contains(event.UserIDs, sub.userID) {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			// too slow: drop it, it resumes from the buffer
+			h.removeLocked(sub)
+		}
+	}
+}
+
+func (h *Hub) Subscribe(userID uint, lastEventID string) *Subscription {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := &Subscription{}
+	if lastEventID != "" {
+		from := slices.IndexFunc(h.buffer, func(e Event) bool { return e.ID == lastEventID })
+		if from < 0 {
+			s.Missed = true
+		} else {
+			for _, event := range h.buffer[from+1:] {
+				if slices.Contains(event.UserIDs, userID) {
+					s.Replay = append(s.Replay, event)
+				}
+			}
+		}
+	}
+
+	// registering under the same lock leaves no gap between replay and live events
+	sub := &subscriber{userID: userID, ch: make(chan Event, subscriberBuffer)}
+	h.subscribers[sub] = struct{}{}
+	s.Events = sub.ch
+	s.close = func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.removeLocked(sub)
+	}
+	return s
+}
+
+func (h *Hub) removeLocked(sub *subscriber) {
+	if _, ok := h.subscribers[sub]; ok {
+		delete(h.subscribers, sub)
+		close(sub.ch)
+	}
+}
+
+// clear empties the replay buffer.
+func (h *Hub) clear() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.buffer = nil
+}
@@ -0,0 +1,97 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func publish(t *testing.T, h *Hub, eventType string, users ...uint) {
+	t.Helper()
+	require.NoError(t, h.Publish(context.Background(), Event{Type: eventType, UserIDs: users, Data: json.RawMessage(`{}`)}))
+}
+
+func types(events []Event) []string {
+	var names []string
+	for _, event := range events {
+		names = append(names, event.Type)
+	}
+	return names
+}
+
+func TestHub_DeliversToTheEventsUsers(t *testing.T) {
+	h := NewHub(10)
+	one := h.Subscribe(1, "")
+	defer one.Close()
+	two := h.Subscribe(2, "")
+	defer two.Close()
+
+	publish(t, h, "a", 1)
+	publish(t, h, "b", 1, 2)
+
+	assert.Equal(t, "a", (<-one.Events).Type)
+	assert.Equal(t, "b", (<-one.Events).Type)
+	event := <-two.Events
+	assert.Equal(t, "b", event.Type)
+	assert.Equal(t, "2", event.ID)
+	assert.Empty(t, two.Events)
+}
+
+func TestHub_ReplaysAfterLastEventID(t *testing.T) {
+	h := NewHub(3)
+	publish(t, h, "a", 1)
+	publish(t, h, "b", 2)
+	publish(t, h, "c", 1)
+	publish(t, h, "d", 1)
+
+	sub := h.Subscribe(1, "2")
+	assert.False(t, sub.Missed)
+	assert.Equal(t, []string{"c", "d"}, types(sub.Replay), "only the user's events")
+	sub.Close()
+
+	sub = h.Subscribe(1, "1")
+	assert.True(t, sub.Missed, "event 1 fell out of the buffer")
+	assert.Empty(t, sub.Replay)
+	sub.Close()
+
+	sub = h.Subscribe(1, "4")
+	assert.False(t, sub.Missed)
+	assert.Empty(t, sub.Replay, "nothing new")
+	sub.Close()
+}
+
+func TestHub_DoesNotReplayVolatileEvents(t *testing.T) {
+	h := NewHub(10)
+	live := h.Subscribe(1, "")
+	defer live.Close()
+
+	publish(t, h, "a", 1)
+	require.NoError(t, h.Publish(context.Background(), Event{Type: "presence", UserIDs: []uint{1}, Volatile: true}))
+	publish(t, h, "b", 1)
+
+	assert.Equal(t, "a", (<-live.Events).Type)
+	assert.Equal(t, "presence", (<-live.Events).Type, "delivered live")
+
+	sub := h.Subscribe(1, "1")
+	assert.Equal(t, []string{"b"}, types(sub.Replay))
+	sub.Close()
+}
+
+func TestHub_DropsSlowSubscribers(t *testing.T) {
+	h := NewHub(10)
+	sub := h.Subscribe(1, "")
+
+	for i := 0; i < subscriberBuffer+1; i++ {
+		publish(t, h, "x", 1)
+	}
+
+	received := 0
+	for range sub.Events {
+		received++
+	}
+	assert.Equal(t, subscriberBuffer, received, "the channel is closed once it is full")
+	sub.Close() // closing again is harmless
+}
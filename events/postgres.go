@@ -0,0 +1,127 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// notifyChannel is the LISTEN/NOTIFY channel events travel on.
+	notifyChannel = "task_events"
+	// maxNotifyPayload stays below Postgres' limit of 8000 bytes.
+	maxNotifyPayload = 7900
+	reconnectDelay   = 5 * time.Second
+)
+
+// Postgres is a Broker for several instances sharing one database. Events
+// are sent with NOTIFY and every instance, the publishing one included,
+// receives them through LISTEN and fans them out with a Hub. Event IDs come
+// from a sequence, so a client can resume on any instance.
+type Postgres struct {
+	hub *Hub
+	db  *sql.DB
+	dsn string
+}
+
+// NewPostgres creates the ID sequence and starts listening in the
+// background until ctx is done. dsn is used for the dedicated LISTEN
+// connection; db publishes.
+func NewPostgres(ctx context.Context, db *sql.DB, dsn string, size int) (*Postgres, error) {
+
+	_, err := db.ExecContext(ctx, "CREATE SEQUENCE IF NOT EXISTS task_event_ids")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the event sequence: %w", err)
+	}
+
+	p := &Postgres{hub: NewHub(size), db: db, dsn: dsn}
+	go p.listen(ctx)
+	return p, nil
+}
+
+// Publish sends the event to all instances. Payloads over the NOTIFY limit
+// only keep the "id" of their data, marked "truncated"; clients fetch the
+// rest.
+func (p *Postgres) Publish(ctx context.Context, event Event) error {
+
+	var id int64
+	err := p.db.QueryRowContext(ctx, "SELECT nextval('task_event_ids')").Scan(&id)
+	if err != nil {
+		return err
+	}
+	event.ID = strconv.FormatInt(id, 10)
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if len(payload) > maxNotifyPayload {
+		var data struct {
+			ID json.RawMessage `json:"id"`
+		}
+		json.Unmarshal(event.Data, &data)
+		event.Data, _ = json.Marshal(map[string]any{"id": data.ID, "truncated": true})
+		if payload, err = json.Marshal(event); err != nil {
+			return err
+		}
+	}
+
+	_, err = p.db.ExecContext(ctx, "SELECT pg_notify($1, $2)", notifyChannel, string(payload))
+	return err
+}
+
+func (p *Postgres) Subscribe(userID uint, lastEventID string) *Subscription {
+	return p.hub.Subscribe(userID, lastEventID)
+}
+
+// listen receives the notifications, reconnecting when the connection drops.
+func (p *Postgres) listen(ctx context.Context) {
+	for {
+		err := p.receive(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Error().Err(err).Msg("Event listener lost its database connection, reconnecting")
+
+		// events sent meanwhile are lost, so nobody may resume across the gap
+		p.hub.clear()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+func (p *Postgres) receive(ctx context.Context) error {
+
+	conn, err := pgx.Connect(ctx, p.dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+notifyChannel); err != nil {
+		return err
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		var event Event
+		if err := json.Unmarshal([]byte(notification.Payload), &event); err != nil {
+			log.Warn().Err(err).Msg("Ignoring malformed event notification")
+			continue
+		}
+		p.hub.deliver(event)
+	}
+}
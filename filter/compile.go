@@ -0,0 +1,271 @@
+package filter
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind is the type of a filterable field. It decides which operators are
+// allowed and how values are parsed.
+type Kind int
+
+const (
+	String Kind = iota
+	Int
+	Time
+	Number // any number, e.g. 2.5
+	Date   // a calendar date, compared as YYYY-MM-DD text
+	List   // a set of strings; = and IN test membership
+)
+
+// Field describes a field that may appear in an expression or a sort.
+type Field struct {
+	Column   string // SQL column, never taken from user input
+	Kind     Kind
+	Nullable bool     // allows IS NULL / IS NOT NULL
+	Values   []string // if set, the only accepted values
+	NoSort   bool
+	// Contains is required for List fields: a SQL condition with one ?
+	// for a list of values, true when the field holds any of them.
+	Contains string
+	// Equals optionally compiles = and IN (for List fields: holds the
+	// value) instead of Column or Contains. It returns a SQL condition with
+	// one ? and its argument, so a field can use a test an index serves,
+	// such as JSONB containment.
+	Equals func(value any) (string, any)
+}
+
+// Fields whitelists the fields of a resource by their public name.
+type Fields map[string]Field
+
+var operators = map[Kind][]string{
+	String: {"=", "!=", "~", "IN", "NOT IN"},
+	Int:    {"=", "!=", ">", ">=", "<", "<=", "IN", "NOT IN"},
+	Time:   {"=", "!=", ">", ">=", "<", "<="},
+	Number: {"=", "!=", ">", ">=", "<", "<="},
+	Date:   {"=", "!=", ">", ">=", "<", "<="},
+	List:   {"=", "!=", "IN", "NOT IN"},
+}
+
+// Compile turns a parsed expression into a SQL condition with ? placeholders
+// for every value, ready for gorm's Where. Relative dates are resolved in
+// UTC.
+func Compile(expr Node, fields Fields) (string, []any, error) {
+	return CompileAt(expr, fields, time.Now().UTC())
+}
+
+// CompileAt is Compile with relative dates such as today or today+7d
+// resolved against now, in now's location.
+func CompileAt(expr Node, fields Fields, now time.Time) (string, []any, error) {
+
+	c := compiler{fields: fields, now: now}
+	sql, err := c.compile(expr)
+	if err != nil {
+		return "", nil, err
+	}
+	return sql, c.args, nil
+}
+
+// ParseAndCompile parses and compiles an expression in one step.
+func ParseAndCompile(input string, fields Fields) (string, []any, error) {
+	return ParseAndCompileAt(input, fields, time.Now().UTC())
+}
+
+// ParseAndCompileAt parses and compiles an expression with relative dates
+// resolved against now.
+func ParseAndCompileAt(input string, fields Fields, now time.Time) (string, []any, error) {
+
+	expr, err := Parse(input)
+	if err != nil {
+		return "", nil, err
+	}
+	return CompileAt(expr, fields, now)
+}
+
+type compiler struct {
+	fields Fields
+	now    time.Time
+	args   []any
+}
+
+func (c *compiler) compile(expr Node) (string, error) {
+
+	switch expr := expr.(type) {
+	case Logical:
+		parts := make([]string, 0, len(expr.Exprs))
+		for _, sub := range expr.Exprs {
+			part, err := c.compile(sub)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, part)
+		}
+		return "(" + strings.Join(parts, " "+expr.Op+" ") + ")", nil
+
+	case Not:
+		inner, err := c.compile(expr.Expr)
+		if err != nil {
+			return "", err
+		}
+		return "NOT (" + inner + ")", nil
+
+	case Comparison:
+		return c.compileComparison(expr)
+	}
+	return "", fmt.Errorf("filter: unknown node %T", expr)
+}
+
+func (c *compiler) compileComparison(cmp Comparison) (string, error) {
+
+	field, ok := c.fields[cmp.Field]
+	if !ok {
+		return "", errorAt(cmp.Pos, "unknown field %q, expected one of %s", cmp.Field, strings.Join(c.fields.names(false), ", "))
+	}
+
+	if cmp.Op == "IS NULL" || cmp.Op == "IS NOT NULL" {
+		if !field.Nullable {
+			return "", errorAt(cmp.ValuePos, "%q is never null", cmp.Field)
+		}
+		return field.Column + " " + cmp.Op, nil
+	}
+
+	if !slices.Contains(operators[field.Kind], cmp.Op) {
+		return "", errorAt(cmp.Pos, "operator %s is not supported for %q", cmp.Op, cmp.Field)
+	}
+
+	values := make([]any, 0, len(cmp.Values))
+	for _, raw := range cmp.Values {
+		value, err := field.parse(raw, c.now)
+		if err != nil {
+			return "", errorAt(cmp.ValuePos, "invalid value for %q: %s", cmp.Field, err)
+		}
+		values = append(values, value)
+	}
+
+	if field.Equals != nil && (cmp.Op == "=" || cmp.Op == "IN") {
+		parts := make([]string, 0, len(values))
+		for _, value := range values {
+			sql, arg := field.Equals(value)
+			parts = append(parts, sql)
+			c.args = append(c.args, arg)
+		}
+		return "(" + strings.Join(parts, " OR ") + ")", nil
+	}
+
+	if field.Kind == List {
+		c.args = append(c.args, values)
+		if cmp.Op == "!=" || cmp.Op == "NOT IN" {
+			return "NOT (" + field.Contains + ")", nil
+		}
+		return "(" + field.Contains + ")", nil
+	}
+
+	switch cmp.Op {
+	case "IN", "NOT IN":
+		c.args = append(c.args, values)
+		return field.Column + " " + cmp.Op + " ?", nil
+	case "~":
+		c.args = append(c.args, "%"+escapeLike(strings.ToLower(cmp.Values[0]))+"%")
+		return "LOWER(" + field.Column + `) LIKE ? ESCAPE '\'`, nil
+	default:
+		c.args = append(c.args, values[0])
+		return field.Column + " " + cmp.Op + " ?", nil
+	}
+}
+
+func (f Field) parse(raw string, now time.Time) (any, error) {
+
+	if len(f.Values) > 0 && !slices.Contains(f.Values, raw) {
+		return nil, fmt.Errorf("expected one of %s", strings.Join(f.Values, ", "))
+	}
+
+	switch f.Kind {
+	case Int:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a whole number", raw)
+		}
+		return n, nil
+	case Number:
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil || math.IsInf(n, 0) || math.IsNaN(n) {
+			return nil, fmt.Errorf("%q is not a number", raw)
+		}
+		return n, nil
+	case Date:
+		if t, err := time.Parse(time.DateOnly, raw); err == nil {
+			return t.Format(time.DateOnly), nil
+		}
+		if t, ok := relativeTime(raw, now); ok {
+			return t.Format(time.DateOnly), nil
+		}
+		return nil, fmt.Errorf("%q is not a date (2006-01-02), today or today[+-Nd]", raw)
+	case Time:
+		for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+			if t, err := time.Parse(layout, raw); err == nil {
+				return t, nil
+			}
+		}
+		if t, ok := relativeTime(raw, now); ok {
+			return t.UTC(), nil
+		}
+		return nil, fmt.Errorf("%q is not a date (2006-01-02), RFC 3339 time, now or today[+-Nd]", raw)
+	default:
+		return raw, nil
+	}
+}
+
+// relativeTime resolves now, today, yesterday, tomorrow and today+Nd or
+// today-Nd. Days start at midnight in now's location.
+func relativeTime(raw string, now time.Time) (time.Time, bool) {
+
+	raw = strings.ToLower(raw)
+	if raw == "now" {
+		return now, true
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	switch raw {
+	case "today":
+		return today, true
+	case "yesterday":
+		return today.AddDate(0, 0, -1), true
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), true
+	}
+
+	rest, ok := strings.CutPrefix(raw, "today")
+	if !ok || len(rest) < 3 || (rest[0] != '+' && rest[0] != '-') || !strings.HasSuffix(rest, "d") {
+		return time.Time{}, false
+	}
+	days, err := strconv.Atoi(rest[1 : len(rest)-1])
+	if err != nil || days < 0 || days > 3660 {
+		return time.Time{}, false
+	}
+	if rest[0] == '-' {
+		days = -days
+	}
+	return today.AddDate(0, 0, days), true
+}
+
+// names lists the field names in a stable order.
+func (fields Fields) names(sortable bool) []string {
+
+	names := make([]string, 0, len(fields))
+	for name, field := range fields {
+		if sortable && field.NoSort {
+			continue
+		}
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
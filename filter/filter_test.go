@@ -0,0 +1,140 @@
+package filter
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testFields = Fields{
+	"title":        {Column: "title", Kind: String},
+	"priority":     {Column: "priority", Kind: Int},
+	"status":       {Column: "status", Kind: String, Values: []string{"pending", "in_progress", "completed"}},
+	"completed_at": {Column: "completed_at", Kind: Time, Nullable: true},
+}
+
+func TestParseAndCompile(t *testing.T) {
+	sql, args, err := ParseAndCompile(`priority>=5 AND status IN (pending,in_progress) AND title~"50%_report"`, testFields)
+	require.NoError(t, err)
+	assert.Equal(t, `(priority >= ? AND status IN ? AND LOWER(title) LIKE ? ESCAPE '\')`, sql)
+	assert.Equal(t, []any{int64(5), []any{"pending", "in_progress"}, `%50\%\_report%`}, args)
+
+	sql, args, err = ParseAndCompile(`not (priority < 3 or completed_at is not null) and completed_at > 2024-05-01`, testFields)
+	require.NoError(t, err)
+	assert.Equal(t, "(NOT ((priority < ? OR completed_at IS NOT NULL)) AND completed_at > ?)", sql)
+	assert.Equal(t, []any{int64(3), time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)}, args)
+}
+
+func TestParseAndCompileAt_RelativeDates(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	now := time.Date(2026, 3, 10, 15, 30, 0, 0, berlin)
+	// midnight in Berlin, days from now; the clocks change on March 29
+	midnight := func(days int) time.Time { return time.Date(2026, 3, 10+days, 0, 0, 0, 0, berlin).UTC() }
+
+	_, args, err := ParseAndCompileAt(`completed_at >= today AND completed_at < Tomorrow OR completed_at > today-7d AND completed_at <= now`, testFields, now)
+	require.NoError(t, err)
+	assert.Equal(t, []any{midnight(0), midnight(1), midnight(-7), now.UTC()}, args)
+
+	_, args, err = ParseAndCompileAt(`completed_at < today+30d`, testFields, now)
+	require.NoError(t, err)
+	assert.Equal(t, []any{midnight(30)}, args)
+
+	for _, input := range []string{`completed_at < today+d`, `completed_at < today+-3d`, `completed_at < later`} {
+		_, _, err = ParseAndCompileAt(input, testFields, now)
+		assert.ErrorContains(t, err, "invalid value", input)
+	}
+}
+
+func TestParseAndCompileAt_NumbersDatesAndLists(t *testing.T) {
+	fields := Fields{
+		"points": {Column: "points", Kind: Number},
+		"launch": {Column: "launch", Kind: Date},
+		"env":    {Column: "env", Kind: List, Values: []string{"dev", "prod"}, Contains: "env_has(?)"},
+	}
+	now := time.Date(2026, 3, 10, 23, 30, 0, 0, time.FixedZone("UTC-5", -5*3600))
+
+	sql, args, err := ParseAndCompileAt(`points > 2.5 AND launch <= today+3d AND env = prod AND env NOT IN (dev)`, fields, now)
+	require.NoError(t, err)
+	assert.Equal(t, "(points > ? AND launch <= ? AND (env_has(?)) AND NOT (env_has(?)))", sql)
+	assert.Equal(t, []any{2.5, "2026-03-13", []any{"prod"}, []any{"dev"}}, args)
+
+	for _, input := range []string{`points = many`, `launch = 2026-02-30`, `env = staging`, `env ~ "pro"`} {
+		_, _, err = ParseAndCompileAt(input, fields, now)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestParseAndCompile_Equals(t *testing.T) {
+	contains := func(value any) (string, any) { return "doc @> ?", fmt.Sprintf(`{"env": [%q]}`, value) }
+	fields := Fields{
+		"env": {Column: "env", Kind: List, Contains: "env_has(?)", Equals: contains},
+	}
+
+	sql, args, err := ParseAndCompile(`env = prod OR env IN (dev, qa) OR env != old`, fields)
+	require.NoError(t, err)
+	assert.Equal(t, "((doc @> ?) OR (doc @> ? OR doc @> ?) OR NOT (env_has(?)))", sql)
+	assert.Equal(t, []any{`{"env": ["prod"]}`, `{"env": ["dev"]}`, `{"env": ["qa"]}`, []any{"old"}}, args)
+}
+
+func TestParseAndCompile_ErrorsHavePositions(t *testing.T) {
+	cases := []struct {
+		input string
+		pos   int
+	}{
+		{`priority >= `, 12},                        // missing value
+		{`priority >= 5 AND (status = pending`, 35}, // unclosed paren
+		{`title = "open`, 8},                        // unterminated string
+		{`priority ! 5`, 9},                         // lone !
+		{`owner = 3`, 0},                            // unknown field
+		{`title > "a"`, 0},                          // operator not allowed for strings
+		{`priority = high`, 11},                     // not a number
+		{`status = done`, 9},                        // not an allowed value
+		{`priority IS NULL`, 9},                     // not nullable
+		{`priority = 1 priority = 2`, 13},           // missing AND/OR
+		{`status IN (pending completed)`, 19},       // missing comma
+	}
+
+	for _, c := range cases {
+		_, _, err := ParseAndCompile(c.input, testFields)
+		var filterErr *Error
+		if assert.ErrorAs(t, err, &filterErr, c.input) {
+			assert.Equal(t, c.pos, filterErr.Pos, "%s: %s", c.input, filterErr.Message)
+		}
+	}
+}
+
+func TestParse_RejectsDeepNesting(t *testing.T) {
+	input := ""
+	for range 30 {
+		input += "("
+	}
+	_, err := Parse(input + "priority = 1")
+	assert.ErrorContains(t, err, "nested too deeply")
+}
+
+func TestParseSort(t *testing.T) {
+	sort, err := ParseSort("priority:desc,created_at", Fields{
+		"priority":   {Column: "tasks.priority"},
+		"created_at": {Column: "tasks.created_at"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []SortField{
+		{Name: "priority", Field: Field{Column: "tasks.priority"}, Desc: true},
+		{Name: "created_at", Field: Field{Column: "tasks.created_at"}},
+	}, sort)
+
+	_, err = ParseSort("priority:desc,secret:asc", testFields)
+	var filterErr *Error
+	require.ErrorAs(t, err, &filterErr)
+	assert.Equal(t, 14, filterErr.Pos)
+
+	_, err = ParseSort("priority:sideways", testFields)
+	assert.ErrorContains(t, err, "asc or desc")
+
+	_, err = ParseSort("priority,priority:desc", testFields)
+	assert.ErrorContains(t, err, "more than once")
+}
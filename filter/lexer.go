@@ -0,0 +1,139 @@
+package filter
+
+import (
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokString
+	tokOperator
+	tokLParen
+	tokRParen
+	tokComma
+)
+
+func (k tokenKind) String() string {
+	switch k {
+	case tokEOF:
+		return "end of input"
+	case tokWord:
+		return "word"
+	case tokString:
+		return "string"
+	case tokOperator:
+		return "operator"
+	case tokLParen:
+		return `"("`
+	case tokRParen:
+		return `")"`
+	default:
+		return `","`
+	}
+}
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int // byte offset in the expression
+}
+
+// keyword reports whether the token is the given keyword, ignoring case.
+func (t token) keyword(word string) bool {
+	return t.kind == tokWord && strings.EqualFold(t.text, word)
+}
+
+// lex splits an expression into tokens. Words are runs of anything that is
+// not whitespace, a quote, a parenthesis, a comma or an operator character,
+// so values like in_progress or 2024-05-01 need no quoting.
+func lex(input string) ([]token, error) {
+
+	var tokens []token
+	i := 0
+	for i < len(input) {
+		c := input[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+
+		case c == '(':
+			tokens = append(tokens, token{tokLParen, "(", i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{tokRParen, ")", i})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{tokComma, ",", i})
+			i++
+
+		case c == '"' || c == '\'':
+			text, end, err := lexString(input, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{tokString, text, i})
+			i = end
+
+		case strings.IndexByte("=!<>~", c) >= 0:
+			op := string(c)
+			if i+1 < len(input) && input[i+1] == '=' && c != '=' && c != '~' {
+				op += "="
+			}
+			if op == "!" {
+				return nil, errorAt(i, `unexpected "!", did you mean "!="?`)
+			}
+			tokens = append(tokens, token{tokOperator, op, i})
+			i += len(op)
+
+		default:
+			start := i
+			for i < len(input) && isWordByte(input[i]) {
+				i++
+			}
+			if i == start {
+				return nil, errorAt(i, "unexpected character %q", input[i])
+			}
+			tokens = append(tokens, token{tokWord, input[start:i], start})
+		}
+	}
+
+	tokens = append(tokens, token{tokEOF, "", len(input)})
+	return tokens, nil
+}
+
+// lexString reads a quoted string starting at input[start]. A backslash
+// escapes the next character. It returns the unquoted text and the offset
+// just past the closing quote.
+func lexString(input string, start int) (string, int, error) {
+
+	quote := input[start]
+	var text strings.Builder
+	for i := start + 1; i < len(input); i++ {
+		switch input[i] {
+		case '\\':
+			if i+1 == len(input) {
+				return "", 0, errorAt(i, "unfinished escape sequence")
+			}
+			i++
+			text.WriteByte(input[i])
+		case quote:
+			return text.String(), i + 1, nil
+		default:
+			text.WriteByte(input[i])
+		}
+	}
+	return "", 0, errorAt(start, "unterminated string")
+}
+
+func isWordByte(c byte) bool {
+	if c >= 0x80 {
+		return true // part of a multi-byte rune, allowed in values
+	}
+	r := rune(c)
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_-.:+", r)
+}
@@ -0,0 +1,273 @@
+package filter
+
+import "fmt"
+
+// MaxLength and maxDepth bound the work a single expression can cause.
+const (
+	MaxLength = 1000
+	maxDepth  = 20
+)
+
+// Node is a node of a parsed filter expression.
+type Node interface {
+	node()
+}
+
+// Logical combines two or more expressions with AND or OR.
+type Logical struct {
+	Op    string // "AND" or "OR"
+	Exprs []Node
+}
+
+// Not negates an expression.
+type Not struct {
+	Expr Node
+}
+
+// Comparison compares a field with one or more values. Op is one of
+// = != > >= < <= ~ IN "NOT IN" "IS NULL" "IS NOT NULL".
+type Comparison struct {
+	Field    string
+	Op       string
+	Values   []string
+	Pos      int // offset of the field name
+	ValuePos int // offset of the first value
+}
+
+func (Logical) node()    {}
+func (Not) node()        {}
+func (Comparison) node() {}
+
+// Error describes a problem with a filter expression and where it is.
+type Error struct {
+	Pos     int    `json:"position"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("at position %d: %s", e.Pos, e.Message)
+}
+
+func errorAt(pos int, format string, args ...any) *Error {
+	return &Error{Pos: pos, Message: fmt.Sprintf(format, args...)}
+}
+
+// Parse parses a filter expression such as
+//
+//	priority>=5 AND status IN (pending,in_progress) AND title~"report"
+//
+// Keywords are case-insensitive. AND binds tighter than OR.
+func Parse(input string) (Node, error) {
+
+	if len(input) > MaxLength {
+		return nil, errorAt(MaxLength, "expression is longer than %d characters", MaxLength)
+	}
+
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	if p.peek().kind == tokEOF {
+		return nil, errorAt(0, "expression is empty")
+	}
+
+	expr, err := p.parseOr(0)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, errorAt(tok.pos, "unexpected %s, expected AND, OR or end of input", describe(tok))
+	}
+	return expr, nil
+}
+
+type parser struct {
+	tokens []token
+	next   int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *parser) advance() token {
+	tok := p.tokens[p.next]
+	if tok.kind != tokEOF {
+		p.next++
+	}
+	return tok
+}
+
+func (p *parser) parseOr(depth int) (Node, error) {
+	return p.parseLogical("OR", depth, p.parseAnd)
+}
+
+func (p *parser) parseAnd(depth int) (Node, error) {
+	return p.parseLogical("AND", depth, p.parseUnary)
+}
+
+func (p *parser) parseLogical(op string, depth int, operand func(int) (Node, error)) (Node, error) {
+
+	first, err := operand(depth)
+	if err != nil {
+		return nil, err
+	}
+
+	exprs := []Node{first}
+	for p.peek().keyword(op) {
+		p.advance()
+		next, err := operand(depth)
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, next)
+	}
+
+	if len(exprs) == 1 {
+		return first, nil
+	}
+	return Logical{Op: op, Exprs: exprs}, nil
+}
+
+func (p *parser) parseUnary(depth int) (Node, error) {
+
+	tok := p.peek()
+	if depth > maxDepth {
+		return nil, errorAt(tok.pos, "expression is nested too deeply")
+	}
+
+	if tok.keyword("NOT") {
+		p.advance()
+		expr, err := p.parseUnary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return Not{Expr: expr}, nil
+	}
+
+	if tok.kind == tokLParen {
+		p.advance()
+		expr, err := p.parseOr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.advance(); closing.kind != tokRParen {
+			return nil, errorAt(closing.pos, `expected ")" to close "(" at position %d, found %s`, tok.pos, describe(closing))
+		}
+		return expr, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (Node, error) {
+
+	field := p.advance()
+	if field.kind != tokWord || isKeyword(field) {
+		return nil, errorAt(field.pos, "expected a field name, found %s", describe(field))
+	}
+	cmp := Comparison{Field: field.text, Pos: field.pos}
+
+	op := p.advance()
+	switch {
+	case op.kind == tokOperator:
+		cmp.Op = op.text
+
+	case op.keyword("IN"):
+		cmp.Op = "IN"
+
+	case op.keyword("NOT"):
+		if in := p.advance(); !in.keyword("IN") {
+			return nil, errorAt(in.pos, "expected IN after NOT, found %s", describe(in))
+		}
+		cmp.Op = "NOT IN"
+
+	case op.keyword("IS"):
+		cmp.Op = "IS NULL"
+		if p.peek().keyword("NOT") {
+			p.advance()
+			cmp.Op = "IS NOT NULL"
+		}
+		if null := p.advance(); !null.keyword("NULL") {
+			return nil, errorAt(null.pos, "expected NULL, found %s", describe(null))
+		}
+		cmp.ValuePos = op.pos
+		return cmp, nil
+
+	default:
+		return nil, errorAt(op.pos, "expected an operator after %q, found %s", field.text, describe(op))
+	}
+
+	cmp.ValuePos = p.peek().pos
+	if cmp.Op == "IN" || cmp.Op == "NOT IN" {
+		values, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		cmp.Values = values
+		return cmp, nil
+	}
+
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	cmp.Values = []string{value}
+	return cmp, nil
+}
+
+func (p *parser) parseList() ([]string, error) {
+
+	if open := p.advance(); open.kind != tokLParen {
+		return nil, errorAt(open.pos, `expected "(" to start a list, found %s`, describe(open))
+	}
+
+	var values []string
+	for {
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		switch tok := p.advance(); tok.kind {
+		case tokComma:
+			continue
+		case tokRParen:
+			return values, nil
+		default:
+			return nil, errorAt(tok.pos, `expected "," or ")" in list, found %s`, describe(tok))
+		}
+	}
+}
+
+func (p *parser) parseValue() (string, error) {
+
+	tok := p.advance()
+	if tok.kind == tokString || (tok.kind == tokWord && !isKeyword(tok)) {
+		return tok.text, nil
+	}
+	return "", errorAt(tok.pos, "expected a value, found %s", describe(tok))
+}
+
+func isKeyword(tok token) bool {
+	for _, keyword := range []string{"AND", "OR", "NOT", "IN", "IS", "NULL"} {
+		if tok.keyword(keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+func describe(tok token) string {
+	switch tok.kind {
+	case tokEOF, tokLParen, tokRParen, tokComma:
+		return tok.kind.String()
+	case tokString:
+		return fmt.Sprintf("string %q", tok.text)
+	default:
+		return fmt.Sprintf("%q", tok.text)
+	}
+}
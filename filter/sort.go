@@ -0,0 +1,53 @@
+package filter
+
+import "strings"
+
+// MaxSortFields caps how many fields one sort may use.
+const MaxSortFields = 4
+
+// SortField is one column of an ORDER BY.
+type SortField struct {
+	Name string // public field name
+	Field
+	Desc bool
+}
+
+// ParseSort parses a sort such as "priority:desc,created_at:asc". The
+// direction defaults to ascending. Every field must be sortable in fields
+// and may appear only once.
+func ParseSort(input string, fields Fields) ([]SortField, error) {
+
+	var sort []SortField
+	seen := map[string]bool{}
+	pos := 0
+	for _, part := range strings.Split(input, ",") {
+		start := pos
+		pos += len(part) + 1
+
+		name, dir, _ := strings.Cut(strings.TrimSpace(part), ":")
+		field, ok := fields[name]
+		if !ok || field.NoSort {
+			return nil, errorAt(start, "cannot sort by %q, expected one of %s", name, strings.Join(fields.names(true), ", "))
+		}
+		if seen[name] {
+			return nil, errorAt(start, "%q is sorted by more than once", name)
+		}
+		seen[name] = true
+
+		var desc bool
+		switch strings.ToLower(dir) {
+		case "", "asc":
+		case "desc":
+			desc = true
+		default:
+			return nil, errorAt(start+len(name)+1, "sort direction must be asc or desc, found %q", dir)
+		}
+
+		sort = append(sort, SortField{Name: name, Field: field, Desc: desc})
+	}
+
+	if len(sort) > MaxSortFields {
+		return nil, errorAt(0, "sort by at most %d fields", MaxSortFields)
+	}
+	return sort, nil
+}
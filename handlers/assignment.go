@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/Niraj1910/Task-REST-APIs/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+type AssignTaskBody struct {
+	AssigneeID uint `json:"assignee_id" binding:"required"`
+}
+
+// AssignTask godoc
+// @Summary      Assign a task
+// @Description  Assigns a task owned by the authenticated user to a registered user and notifies the assignee by email
+// @Tags         Tasks
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id   path int true "Task ID"
+// @Param        body body handlers.AssignTaskBody true "New assignee"
+// @Success      200 {object} map[string]interface{} "Task assigned"
+// @Failure      400 {object} map[string]string "Invalid input"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      404 {object} map[string]string "Task or assignee not found"
+// @Failure      500 {object} map[string]string "Server error"
+// @Router       /api/task/{id}/assignee [put]
+func AssignTask(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		taskID, ok := parseIDParam(ctx, "id", "Task ID")
+		if !ok {
+			return
+		}
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		var body AssignTaskBody
+		err := ctx.ShouldBindBodyWithJSON(&body)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid input",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		var assignee model.User
+		err = db.First(&assignee, body.AssigneeID).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				ctx.JSON(http.StatusNotFound, gin.H{"error": "Assignee not found"})
+				return
+			}
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to look up assignee"})
+			return
+		}
+
+		setAssignee(ctx, db, taskID, userID, &assignee)
+	}
+}
+
+// UnassignTask godoc
+// @Summary      Unassign a task
+// @Description  Removes the assignee from a task owned by the authenticated user
+// @Tags         Tasks
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Task ID"
+// @Success      200 {object} map[string]interface{} "Task unassigned"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      404 {object} map[string]string "Task not found or not owned"
+// @Failure      500 {object} map[string]string "Server error"
+// @Router       /api/task/{id}/assignee [delete]
+func UnassignTask(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		taskID, ok := parseIDParam(ctx, "id", "Task ID")
+		if !ok {
+			return
+		}
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		setAssignee(ctx, db, taskID, userID, nil)
+	}
+}
+
+// setAssignee moves an owned task to a new assignee (nil unassigns) and
+// writes the response.
+func setAssignee(ctx *gin.Context, db *gorm.DB, taskID, userID uint, assignee *model.User) {
+
+	var task model.Task
+	var previous *uint
+	changed := false
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("id = ? AND user_id = ?", taskID, userID).First(&task).Error
+		if err != nil {
+			return err
+		}
+
+		previous = task.AssigneeID
+
+		var assigneeID *uint
+		if assignee != nil {
+			assigneeID = &assignee.ID
+		}
+
+		changed, err = changeAssignee(tx, &task, assigneeID, userID)
+		if err != nil || !changed {
+			return err
+		}
+		return enqueueTaskWebhooks(tx, eventTaskUpdated, task.ID)
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found or not owned by you"})
+			return
+		}
+		log.Error().Err(err).Uint("task_id", taskID).Msg("Failed to change task assignee")
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update task assignee"})
+		return
+	}
+
+	if changed {
+		publishTaskEvent(eventTaskUpdated, task)
+		// the task leaves the previous assignee's list
+		if previous != nil && *previous != task.UserID {
+			publishTaskEventTo(eventTaskDeleted, task, []uint{*previous})
+		}
+	}
+	if changed && assignee != nil {
+		notifyAssignee(db, task, *assignee, userID)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"id":          task.ID,
+		"title":       task.Title,
+		"assignee_id": task.AssigneeID,
+		"changed":     changed,
+	})
+}
+
+// changeAssignee updates task.AssigneeID inside tx and records the
+// assignment in the task history. It reports whether anything changed.
+func changeAssignee(tx *gorm.DB, task *model.Task, assigneeID *uint, actorID uint) (bool, error) {
+
+	if sameAssignee(task.AssigneeID, assigneeID) {
+		return false, nil
+	}
+
+	previous := task.AssigneeID
+
+	action := "reassigned"
+	switch {
+	case previous == nil:
+		action = "assigned"
+	case assigneeID == nil:
+		action = "unassigned"
+	}
+
+	// Update writes the new value back into task as well
+	err := tx.Model(task).Updates(map[string]interface{}{"assignee_id": assigneeID, "version": nextVersion}).Error
+	if err != nil {
+		return false, err
+	}
+
+	err = recordTaskEvent(tx, task.ID, actorID, action, "assignee_id", uintPtrString(previous), uintPtrString(assigneeID))
+	if err != nil {
+		return false, err
+	}
+
+	task.AssigneeID = assigneeID
+	return true, nil
+}
+
+// notifyAssignee tells the new assignee through the channels they chose,
+// mailing in the background. Assigning a task to yourself does not notify.
+func notifyAssignee(db *gorm.DB, task model.Task, assignee model.User, actorID uint) {
+
+	if assignee.ID == actorID {
+		return
+	}
+
+	var actor model.User
+	err := db.Select("name").First(&actor, actorID).Error
+	if err != nil {
+		log.Warn().Err(err).Uint("user_id", actorID).Msg("Could not load assigner for notification")
+	}
+
+	taskLink := fmt.Sprintf("%s/api/task/%d", appBaseURL(), task.ID)
+
+	email := notify(db, model.Notification{
+		UserID:  assignee.ID,
+		Type:    eventAssigned,
+		TaskID:  &task.ID,
+		ActorID: &actorID,
+		Title:   fmt.Sprintf("%s assigned you %q", actor.Name, task.Title),
+		Link:    taskLink,
+	})
+	if !email {
+		return
+	}
+
+	go func() {
+		err := utils.SendAssignmentMail(assignee.Name, assignee.Email, actor.Name, task.Title, taskLink)
+		if err != nil {
+			log.Error().Err(err).Uint("task_id", task.ID).Str("email", assignee.Email).Msg("Failed to send assignment email")
+		}
+	}()
+}
+
+func sameAssignee(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func uintPtrString(v *uint) *string {
+	if v == nil {
+		return nil
+	}
+	s := strconv.FormatUint(uint64(*v), 10)
+	return &s
+}
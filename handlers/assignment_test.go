@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssignTask_RecordsHistory(t *testing.T) {
+	db := setupTestDB(t)
+	owner := model.User{Name: "Owner", Email: "owner@example.com"}
+	assignee := model.User{Name: "Assignee", Email: "assignee@example.com"}
+	require.NoError(t, db.Create(&owner).Error)
+	require.NoError(t, db.Create(&assignee).Error)
+	task := model.Task{Title: "Write report", UserID: owner.ID}
+	require.NoError(t, db.Create(&task).Error)
+
+	handler := AssignTask(db)
+	c, w := setupContext(http.MethodPut, "/api/task/1/assignee", fmt.Sprintf(`{"assignee_id": %d}`, assignee.ID), owner.ID)
+	c.Params = gin.Params{{Key: "id", Value: fmt.Sprint(task.ID)}}
+
+	handler(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var updated model.Task
+	db.First(&updated, task.ID)
+	require.NotNil(t, updated.AssigneeID)
+	assert.Equal(t, assignee.ID, *updated.AssigneeID)
+
+	var events []model.TaskEvent
+	db.Where("task_id = ?", task.ID).Find(&events)
+	require.Len(t, events, 1)
+	assert.Equal(t, "assigned", events[0].Action)
+	assert.Nil(t, events[0].OldValue)
+	assert.Equal(t, fmt.Sprint(assignee.ID), *events[0].NewValue)
+
+	// unassigning is recorded as well
+	handler = UnassignTask(db)
+	c, w = setupContext(http.MethodDelete, "/api/task/1/assignee", "", owner.ID)
+	c.Params = gin.Params{{Key: "id", Value: fmt.Sprint(task.ID)}}
+
+	handler(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	db.Where("task_id = ?", task.ID).Order("id").Find(&events)
+	require.Len(t, events, 2)
+	assert.Equal(t, "unassigned", events[1].Action)
+	assert.Nil(t, events[1].NewValue)
+}
+
+func TestAssignTask_NotOwner(t *testing.T) {
+	db := setupTestDB(t)
+	assignee := model.User{Name: "Assignee", Email: "assignee@example.com"}
+	require.NoError(t, db.Create(&assignee).Error)
+	task := model.Task{Title: "Write report", UserID: 1}
+	require.NoError(t, db.Create(&task).Error)
+
+	handler := AssignTask(db)
+	c, w := setupContext(http.MethodPut, "/api/task/1/assignee", fmt.Sprintf(`{"assignee_id": %d}`, assignee.ID), 2)
+	c.Params = gin.Params{{Key: "id", Value: fmt.Sprint(task.ID)}}
+
+	handler(c)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "not owned by you")
+}
+
+func TestGetUserTasks_AssignedToMe(t *testing.T) {
+	db := setupTestDB(t)
+	me := uint(7)
+	other := uint(8)
+
+	db.Create(&model.Task{Title: "Mine", UserID: me})
+	db.Create(&model.Task{Title: "Assigned to me", UserID: other, AssigneeID: &me})
+	db.Create(&model.Task{Title: "Not visible", UserID: other})
+
+	handler := GetUserTasks(db)
+	c, w := setupContext(http.MethodGet, "/api/user/task?assigned_to=me", "", me)
+
+	handler(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp TaskListResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	require.Len(t, resp.Tasks, 1)
+	assert.Equal(t, "Assigned to me", resp.Tasks[0].Title)
+
+	c, w = setupContext(http.MethodGet, "/api/user/task?created_by="+fmt.Sprint(other), "", me)
+	handler(c)
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	require.Len(t, resp.Tasks, 1)
+	assert.Equal(t, "Assigned to me", resp.Tasks[0].Title)
+}
@@ -0,0 +1,391 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/Niraj1910/Task-REST-APIs/storage"
+	"github.com/Niraj1910/Task-REST-APIs/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultAttachmentMaxBytes   = 10 << 20  // 10 MB per file
+	defaultAttachmentQuotaBytes = 100 << 20 // 100 MB per user
+	attachmentURLTTL            = 15 * time.Minute
+)
+
+// allowedAttachmentTypes are checked against the type sniffed from the file
+// content, never against the type the client claims. Office documents sniff
+// as application/zip.
+var allowedAttachmentTypes = map[string]bool{
+	"image/png":       true,
+	"image/jpeg":      true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+	"application/zip": true,
+	"text/plain":      true,
+}
+
+// UploadAttachment godoc
+// @Summary      Upload a task attachment
+// @Description  Stores a file (multipart field "file") on a task the user can see. The type is sniffed from the content and checked against an allow list; file size and the user's total quota are limited.
+// @Tags         Attachments
+// @Security     BearerAuth
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        id   path     int  true "Task ID"
+// @Param        file formData file true "File to attach"
+// @Success      201 {object} map[string]interface{} "Attachment with signed download URL"
+// @Failure      400 {object} map[string]string "Missing file"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      403 {object} map[string]string "Quota exceeded"
+// @Failure      404 {object} map[string]string "Task not found"
+// @Failure      413 {object} map[string]string "File too large"
+// @Failure      415 {object} map[string]string "File type not allowed"
+// @Failure      500 {object} map[string]string "Server error"
+// @Router       /api/task/{id}/attachments [post]
+func UploadAttachment(db *gorm.DB, store storage.Storage) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		taskID, ok := parseIDParam(ctx, "id", "Task ID")
+		if !ok {
+			return
+		}
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		task, err := findVisibleTask(db, taskID, userID)
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found or not visible to you"})
+			return
+		}
+
+		maxBytes := envInt64("ATTACHMENT_MAX_BYTES", defaultAttachmentMaxBytes)
+		quota := envInt64("ATTACHMENT_QUOTA_BYTES", defaultAttachmentQuotaBytes)
+
+		// leave some room for the multipart envelope
+		ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxBytes+1<<20)
+
+		fileHeader, err := ctx.FormFile("file")
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File too large", "max_bytes": maxBytes})
+				return
+			}
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid input", "details": "multipart field 'file' is required"})
+			return
+		}
+
+		if fileHeader.Size > maxBytes {
+			ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File too large", "max_bytes": maxBytes})
+			return
+		}
+
+		var used int64
+		// trashed attachments keep their files until they are purged
+		err = db.Unscoped().Model(&model.Attachment{}).Where("user_id = ?", userID).Select("COALESCE(SUM(size), 0)").Scan(&used).Error
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check attachment quota"})
+			return
+		}
+		if used+fileHeader.Size > quota {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "Attachment quota exceeded", "used_bytes": used, "quota_bytes": quota})
+			return
+		}
+
+		file, err := fileHeader.Open()
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+			return
+		}
+		defer file.Close()
+
+		// sniff the real type from the first 512 bytes
+		head := make([]byte, 512)
+		n, err := io.ReadFull(file, head)
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+			return
+		}
+		head = head[:n]
+
+		contentType, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+		if !allowedAttachmentTypes[contentType] {
+			ctx.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "File type not allowed", "detected_type": contentType})
+			return
+		}
+
+		key := fmt.Sprintf("tasks/%d/%s", task.ID, uuid.New().String())
+		size, err := store.Put(key, io.MultiReader(bytes.NewReader(head), file))
+		if err != nil {
+			log.Error().Err(err).Uint("task_id", task.ID).Msg("Failed to store attachment")
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store attachment"})
+			return
+		}
+
+		attachment := model.Attachment{
+			TaskID:      task.ID,
+			UserID:      userID,
+			FileName:    filepath.Base(fileHeader.Filename),
+			ContentType: contentType,
+			Size:        size,
+			StorageKey:  key,
+		}
+
+		err = db.Create(&attachment).Error
+		if err != nil {
+			log.Error().Err(err).Uint("task_id", task.ID).Msg("Failed to save attachment metadata")
+			if err := store.Delete(key); err != nil {
+				log.Error().Err(err).Str("key", key).Msg("Failed to remove orphaned attachment blob")
+			}
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save attachment"})
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, attachmentResponse(attachment))
+	}
+}
+
+// GetAttachments godoc
+// @Summary      List task attachments
+// @Description  Returns the attachments of a task with short-lived signed download URLs
+// @Tags         Attachments
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id path int true "Task ID"
+// @Success      200 {object} map[string]interface{} "Attachments"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      404 {object} map[string]string "Task not found"
+// @Failure      500 {object} map[string]string "Server error"
+// @Router       /api/task/{id}/attachments [get]
+func GetAttachments(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		taskID, ok := parseIDParam(ctx, "id", "Task ID")
+		if !ok {
+			return
+		}
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		_, err := findVisibleTask(db, taskID, userID)
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found or not visible to you"})
+			return
+		}
+
+		var attachments []model.Attachment
+		err = db.Where("task_id = ?", taskID).Order("created_at ASC").Find(&attachments).Error
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve attachments"})
+			return
+		}
+
+		items := make([]gin.H, 0, len(attachments))
+		for _, attachment := range attachments {
+			items = append(items, attachmentResponse(attachment))
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"attachments": items})
+	}
+}
+
+// DeleteAttachment godoc
+// @Summary      Delete a task attachment
+// @Description  Removes an attachment and its stored file. The uploader and the task owner may delete it.
+// @Tags         Attachments
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id           path int true "Task ID"
+// @Param        attachmentId path int true "Attachment ID"
+// @Success      200 {object} map[string]interface{} "Attachment deleted"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      404 {object} map[string]string "Attachment not found"
+// @Failure      500 {object} map[string]string "Server error"
+// @Router       /api/task/{id}/attachments/{attachmentId} [delete]
+func DeleteAttachment(db *gorm.DB, store storage.Storage) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		taskID, ok := parseIDParam(ctx, "id", "Task ID")
+		if !ok {
+			return
+		}
+
+		attachmentID, ok := parseIDParam(ctx, "attachmentId", "Attachment ID")
+		if !ok {
+			return
+		}
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		task, err := findVisibleTask(db, taskID, userID)
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found or not visible to you"})
+			return
+		}
+
+		var attachment model.Attachment
+		err = db.Where("id = ? AND task_id = ?", attachmentID, taskID).First(&attachment).Error
+		if err != nil || (attachment.UserID != userID && task.UserID != userID) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found or not deletable by you"})
+			return
+		}
+
+		err = db.Unscoped().Delete(&attachment).Error
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete attachment"})
+			return
+		}
+
+		deleteBlobs(store, []model.Attachment{attachment})
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Successfully deleted attachment", "attachment_id": attachment.ID})
+	}
+}
+
+// DownloadAttachment godoc
+// @Summary      Download an attachment
+// @Description  Streams an attachment. Authorized by the signed, expiring URL returned by the attachment endpoints instead of a JWT.
+// @Tags         Attachments
+// @Produce      octet-stream
+// @Param        attachmentId path  int    true "Attachment ID"
+// @Param        expires      query int    true "Unix expiry time"
+// @Param        signature    query string true "URL signature"
+// @Success      200 {file} file
+// @Failure      403 {object} map[string]string "Invalid or expired link"
+// @Failure      404 {object} map[string]string "Attachment not found"
+// @Router       /files/attachments/{attachmentId} [get]
+func DownloadAttachment(db *gorm.DB, store storage.Storage) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		attachmentID, ok := parseIDParam(ctx, "attachmentId", "Attachment ID")
+		if !ok {
+			return
+		}
+
+		expires, err := strconv.ParseInt(ctx.Query("expires"), 10, 64)
+		if err != nil || !validAttachmentSignature(attachmentID, expires, ctx.Query("signature")) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "Invalid download link"})
+			return
+		}
+		if time.Now().Unix() > expires {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "Download link expired"})
+			return
+		}
+
+		var attachment model.Attachment
+		err = db.First(&attachment, attachmentID).Error
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+			return
+		}
+
+		blob, err := store.Open(attachment.StorageKey)
+		if err != nil {
+			log.Error().Err(err).Str("key", attachment.StorageKey).Msg("Failed to open attachment blob")
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+			return
+		}
+		defer blob.Close()
+
+		ctx.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, blob, map[string]string{
+			"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}),
+			"X-Content-Type-Options": "nosniff",
+		})
+	}
+}
+
+func attachmentResponse(attachment model.Attachment) gin.H {
+	response := gin.H{
+		"id":           attachment.ID,
+		"task_id":      attachment.TaskID,
+		"user_id":      attachment.UserID,
+		"file_name":    attachment.FileName,
+		"content_type": attachment.ContentType,
+		"size":         attachment.Size,
+		"created_at":   attachment.CreatedAt,
+	}
+	if url, ok := signedAttachmentURL(attachment.ID, time.Now().Add(attachmentURLTTL)); ok {
+		response["download_url"] = url
+	}
+	return response
+}
+
+// signedAttachmentURL builds a download link that is valid until expires
+// without any other authentication. There is none without a signing secret.
+func signedAttachmentURL(attachmentID uint, expires time.Time) (string, bool) {
+	signature, ok := attachmentSignature(attachmentID, expires.Unix())
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf("%s/files/attachments/%d?expires=%d&signature=%s",
+		appBaseURL(), attachmentID, expires.Unix(), signature), true
+}
+
+// attachmentSignature signs a download link with ATTACHMENT_URL_SECRET,
+// or JWT_SECRET when that is unset. With neither it refuses, since links
+// signed with an empty key could be forged by anyone.
+func attachmentSignature(attachmentID uint, expires int64) (string, bool) {
+	secret := os.Getenv("ATTACHMENT_URL_SECRET")
+	if secret == "" {
+		secret = os.Getenv("JWT_SECRET")
+	}
+	if secret == "" {
+		return "", false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d:%d", attachmentID, expires)
+	return hex.EncodeToString(mac.Sum(nil)), true
+}
+
+func validAttachmentSignature(attachmentID uint, expires int64, signature string) bool {
+	expected, ok := attachmentSignature(attachmentID, expires)
+	return ok && hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// deleteBlobs removes stored files after their rows are gone. Failures only
+// leave orphaned files behind, so they are logged rather than returned.
+func deleteBlobs(store storage.Storage, attachments []model.Attachment) {
+	for _, attachment := range attachments {
+		err := store.Delete(attachment.StorageKey)
+		if err != nil {
+			log.Error().Err(err).Str("key", attachment.StorageKey).Msg("Failed to delete attachment blob")
+		}
+	}
+}
+
+func envInt64(name string, fallback int64) int64 {
+	value, err := strconv.ParseInt(os.Getenv(name), 10, 64)
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/Niraj1910/Task-REST-APIs/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00")
+
+func uploadContext(t *testing.T, taskID, userID uint, fileName string, content []byte) (*gin.Context, *httptest.ResponseRecorder) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("file", fileName)
+	require.NoError(t, err)
+	part.Write(content)
+	mw.Close()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("user_id", userID)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/task/1/attachments", &body)
+	c.Request.Header.Set("Content-Type", mw.FormDataContentType())
+	c.Params = gin.Params{{Key: "id", Value: fmt.Sprint(taskID)}}
+	return c, w
+}
+
+func TestUploadAttachment_DownloadAndPurgeWithTask(t *testing.T) {
+	t.Setenv("ATTACHMENT_URL_SECRET", "test-secret")
+	db := setupTestDB(t)
+	store, err := storage.NewLocal(t.TempDir())
+	require.NoError(t, err)
+
+	task := model.Task{Title: "Fix layout", UserID: 1}
+	require.NoError(t, db.Create(&task).Error)
+
+	// the claimed name and extension do not matter, the content does
+	c, w := uploadContext(t, task.ID, 1, "screenshot.pdf", pngHeader)
+	UploadAttachment(db, store)(c)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var resp map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, "image/png", resp["content_type"])
+
+	var attachment model.Attachment
+	require.NoError(t, db.First(&attachment).Error)
+
+	// download through the signed URL
+	downloadURL, err := url.Parse(resp["download_url"].(string))
+	require.NoError(t, err)
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, downloadURL.RequestURI(), nil)
+	c.Params = gin.Params{{Key: "attachmentId", Value: fmt.Sprint(attachment.ID)}}
+	DownloadAttachment(db, store)(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, pngHeader, w.Body.Bytes())
+
+	// a tampered signature is refused
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/files/attachments/%d?expires=9999999999&signature=abc", attachment.ID), nil)
+	c.Params = gin.Params{{Key: "attachmentId", Value: fmt.Sprint(attachment.ID)}}
+	DownloadAttachment(db, store)(c)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// deleting the task keeps the blob restorable, purging it removes the blob
+	c, w = setupContext(http.MethodDelete, "/api/task/1", "", 1)
+	c.Params = gin.Params{{Key: "id", Value: fmt.Sprint(task.ID)}}
+	DeleteTask(db)(c)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	blob, err := store.Open(attachment.StorageKey)
+	require.NoError(t, err)
+	blob.Close()
+
+	c, w = setupContext(http.MethodDelete, "/api/task/1/purge", "", 1)
+	c.Params = gin.Params{{Key: "id", Value: fmt.Sprint(task.ID)}}
+	PurgeTask(db, store)(c)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	_, err = store.Open(attachment.StorageKey)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	var count int64
+	db.Unscoped().Model(&model.Attachment{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestUploadAttachment_RejectsSniffedType(t *testing.T) {
+	db := setupTestDB(t)
+	store, err := storage.NewLocal(t.TempDir())
+	require.NoError(t, err)
+
+	task := model.Task{Title: "Fix layout", UserID: 1}
+	require.NoError(t, db.Create(&task).Error)
+
+	c, w := uploadContext(t, task.ID, 1, "notes.txt", []byte("<html><script>alert(1)</script></html>"))
+	UploadAttachment(db, store)(c)
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+}
+
+func TestUploadAttachment_QuotaExceeded(t *testing.T) {
+	t.Setenv("ATTACHMENT_QUOTA_BYTES", "40")
+	db := setupTestDB(t)
+	store, err := storage.NewLocal(t.TempDir())
+	require.NoError(t, err)
+
+	task := model.Task{Title: "Fix layout", UserID: 1}
+	require.NoError(t, db.Create(&task).Error)
+
+	c, w := uploadContext(t, task.ID, 1, "a.png", pngHeader)
+	UploadAttachment(db, store)(c)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	// a trashed attachment still holds its file
+	require.NoError(t, db.Where("1 = 1").Delete(&model.Attachment{}).Error)
+
+	c, w = uploadContext(t, task.ID, 1, "b.png", pngHeader)
+	UploadAttachment(db, store)(c)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "quota")
+}
+
+func TestAttachmentURLs_NeedASecret(t *testing.T) {
+	t.Setenv("ATTACHMENT_URL_SECRET", "")
+	t.Setenv("JWT_SECRET", "")
+
+	_, ok := signedAttachmentURL(1, time.Now().Add(time.Hour))
+	assert.False(t, ok)
+	assert.NotContains(t, attachmentResponse(model.Attachment{}), "download_url")
+
+	mac := hmac.New(sha256.New, nil)
+	fmt.Fprintf(mac, "%d:%d", 1, 9999999999)
+	assert.False(t, validAttachmentSignature(1, 9999999999, hex.EncodeToString(mac.Sum(nil))), "a link signed with an empty key")
+}
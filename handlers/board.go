@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/Niraj1910/Task-REST-APIs/rank"
+	"github.com/Niraj1910/Task-REST-APIs/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// boardStatuses are the board columns, in display order.
+var boardStatuses = []string{"pending", "in_progress", "completed"}
+
+var errNotAdjacent = errors.New("after_id and before_id must be neighbours in the column")
+
+// errNotInColumn is returned when a neighbour given to a move is not in the
+// target column.
+type errNotInColumn struct{ field string }
+
+func (e errNotInColumn) Error() string {
+	return e.field + " is not a task of yours in the target column"
+}
+
+// BoardColumn is one status column of the board.
+type BoardColumn struct {
+	Status string  `json:"status"`
+	Total  int64   `json:"total"`
+	Tasks  []gin.H `json:"tasks" swaggertype:"array,object"`
+}
+
+// GetBoard godoc
+// @Summary      Kanban board
+// @Description  Returns the user's tasks grouped into one column per status, each in board order
+// @Tags         Board
+// @Security     BearerAuth
+// @Produce      json
+// @Param        limit query int false "Tasks per column (default 100, max 500)"
+// @Success      200 {object} map[string][]handlers.BoardColumn
+// @Failure      400 {object} map[string]string "Invalid limit"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      500 {object} map[string]string "Server error"
+// @Router       /api/board [get]
+func GetBoard(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "100"))
+		if err != nil || limit < 1 || limit > 500 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 500"})
+			return
+		}
+
+		columns := make([]BoardColumn, 0, len(boardStatuses))
+		for _, status := range boardStatuses {
+			column := BoardColumn{Status: status, Tasks: []gin.H{}}
+			query := db.Model(&model.Task{}).Where("user_id = ? AND status = ?", userID, status)
+
+			if err := query.Count(&column.Total).Error; err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load the board"})
+				return
+			}
+
+			var tasks []model.Task
+			if err := query.Order("tasks.board_rank, tasks.id").Limit(limit).Find(&tasks).Error; err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load the board"})
+				return
+			}
+			for _, task := range tasks {
+				column.Tasks = append(column.Tasks, taskResponse(task))
+			}
+			columns = append(columns, column)
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"columns": columns})
+	}
+}
+
+// MoveTask godoc
+// @Summary      Move a task on the board
+// @Description  Places a task between two neighbours of a column, optionally changing its status.
+// @Description  after_id is the task that should end up directly above it, before_id the one directly below;
+// @Description  give either or both. Without neighbours the task goes to the bottom of the column.
+// @Description  Completing a blocked task is refused with 409 unless "force" is true.
+// @Tags         Board
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id   path int true "Task ID"
+// @Param        If-Match header string false "ETag from GET /api/task/{id}"
+// @Param        body body object true "status, after_id, before_id, force"
+// @Success      200 {object} types.SwaggerTask
+// @Failure      400 {object} map[string]string "Invalid input or neighbours"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      404 {object} map[string]string "Task not found or not owned"
+// @Failure      409 {object} map[string]interface{} "Task is blocked by open tasks"
+// @Failure      412 {object} map[string]string "Task was modified since the given ETag"
+// @Router       /api/task/{id}/move [post]
+func MoveTask(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		taskID, err := strconv.ParseInt(ctx.Param("id"), 10, 32)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Task ID"})
+			return
+		}
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		var body struct {
+			Status   string `json:"status" binding:"omitempty,oneof=pending in_progress completed"`
+			AfterID  *uint  `json:"after_id"`
+			BeforeID *uint  `json:"before_id"`
+			Force    bool   `json:"force"`
+		}
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid input", "details": err.Error()})
+			return
+		}
+
+		var current model.Task
+		err = db.Where("id = ? AND user_id = ?", uint(taskID), userID).First(&current).Error
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found or not owned by you"})
+			return
+		}
+		if !checkIfMatch(ctx, current) {
+			return
+		}
+
+		status := current.Status
+		if body.Status != "" {
+			status = body.Status
+		}
+
+		newRank, err := rankBetween(db, userID, current.ID, status, body.AfterID, body.BeforeID)
+		var notInColumn errNotInColumn
+		if errors.As(err, &notInColumn) || errors.Is(err, errNotAdjacent) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			log.Error().Err(err).Uint("task_id", current.ID).Msg("Failed to rank task")
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to move task"})
+			return
+		}
+
+		updates := map[string]interface{}{"board_rank": newRank}
+		if status != current.Status {
+			updates["status"] = status
+		}
+		saveTaskUpdates(ctx, db, current, updates, body.Force, userID)
+	}
+}
+
+// rankBetween returns a rank for placing taskID after afterID and before
+// beforeID in a column. When the neighbours leave no room, or the column
+// still has unranked tasks, the column is spread out first.
+func rankBetween(db *gorm.DB, userID, taskID uint, status string, afterID, beforeID *uint) (string, error) {
+
+	var column []model.Task
+	err := db.Select("id", "board_rank").
+		Where("user_id = ? AND status = ? AND id <> ?", userID, status, taskID).
+		Order("tasks.board_rank, tasks.id").Find(&column).Error
+	if err != nil {
+		return "", err
+	}
+
+	indexOf := func(id *uint, field string) (int, error) {
+		if id == nil {
+			return -1, nil
+		}
+		for i, task := range column {
+			if task.ID == *id {
+				return i, nil
+			}
+		}
+		return -1, errNotInColumn{field}
+	}
+	after, err := indexOf(afterID, "after_id")
+	if err != nil {
+		return "", err
+	}
+	before, err := indexOf(beforeID, "before_id")
+	if err != nil {
+		return "", err
+	}
+
+	// the task goes into the gap before position `at`
+	at := len(column)
+	switch {
+	case afterID != nil && beforeID != nil:
+		if before != after+1 {
+			return "", errNotAdjacent
+		}
+		at = before
+	case afterID != nil:
+		at = after + 1
+	case beforeID != nil:
+		at = before
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		lower, upper := "", ""
+		if at > 0 {
+			lower = column[at-1].BoardRank
+		}
+		if at < len(column) {
+			upper = column[at].BoardRank
+		}
+
+		if (at == 0 || lower != "") && (at == len(column) || upper != "") {
+			r, err := rank.Between(lower, upper)
+			if err == nil {
+				return r, nil
+			}
+		}
+		if err := spreadColumn(db, column); err != nil {
+			return "", err
+		}
+	}
+	return "", rank.ErrTooLong
+}
+
+// spreadColumn gives the tasks evenly spaced ranks in their current order.
+// Timestamps are kept, but versions are bumped because the rank is part of
+// every task's representation and so of its ETag.
+func spreadColumn(db *gorm.DB, column []model.Task) error {
+
+	ranks := rank.Spread(len(column))
+	return db.Transaction(func(tx *gorm.DB) error {
+		for i := range column {
+			err := tx.Model(&model.Task{}).Where("id = ?", column[i].ID).
+				UpdateColumns(map[string]any{"board_rank": ranks[i], "version": nextVersion}).Error
+			if err != nil {
+				return err
+			}
+			column[i].BoardRank = ranks[i]
+			column[i].Version++
+		}
+		return nil
+	})
+}
+
+// bottomRank returns a rank at the bottom of a status column, for new tasks
+// and tasks that change column without a position. Unranked tasks from
+// before the board existed stay at the top.
+func bottomRank(db *gorm.DB, userID uint, status string) (string, error) {
+
+	var last string
+	err := db.Model(&model.Task{}).Select("COALESCE(MAX(tasks.board_rank), '')").
+		Where("user_id = ? AND status = ?", userID, status).Scan(&last).Error
+	if err != nil {
+		return "", err
+	}
+
+	r, err := rank.Between(last, "")
+	if err != nil {
+		return rankBetween(db, userID, 0, status, nil, nil)
+	}
+	return r, nil
+}
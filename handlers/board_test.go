@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func createBoardTasks(t *testing.T, db *gorm.DB, titles ...string) []uint {
+	t.Helper()
+	var ids []uint
+	for _, title := range titles {
+		c, w := setupContext(http.MethodPost, "/api/task/new", `{"title":"`+title+`","description":"card"}`, 1)
+		CreateTask(db)(c)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var resp map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		ids = append(ids, uint(resp["id"].(float64)))
+	}
+	return ids
+}
+
+func moveTask(db *gorm.DB, id uint, body string) (int, string) {
+	c, w := setupContext(http.MethodPost, fmt.Sprintf("/api/task/%d/move", id), body, 1)
+	c.Params = gin.Params{{Key: "id", Value: fmt.Sprint(id)}}
+	MoveTask(db)(c)
+	return w.Code, w.Body.String()
+}
+
+func boardColumns(t *testing.T, db *gorm.DB) map[string][]string {
+	t.Helper()
+	c, w := setupContext(http.MethodGet, "/api/board", "", 1)
+	GetBoard(db)(c)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp struct {
+		Columns []struct {
+			Status string
+			Tasks  []struct{ Title string }
+		}
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Columns, 3)
+
+	columns := map[string][]string{}
+	for _, column := range resp.Columns {
+		columns[column.Status] = []string{}
+		for _, task := range column.Tasks {
+			columns[column.Status] = append(columns[column.Status], task.Title)
+		}
+	}
+	return columns
+}
+
+func TestBoard_MoveWithinAndAcrossColumns(t *testing.T) {
+	db := setupTestDB(t)
+	ids := createBoardTasks(t, db, "Card A", "Card B", "Card C", "Card D")
+	assert.Equal(t, []string{"Card A", "Card B", "Card C", "Card D"}, boardColumns(t, db)["pending"], "new tasks go to the bottom")
+
+	code, body := moveTask(db, ids[3], fmt.Sprintf(`{"after_id":%d,"before_id":%d}`, ids[0], ids[1]))
+	require.Equal(t, http.StatusOK, code, body)
+	code, body = moveTask(db, ids[2], fmt.Sprintf(`{"before_id":%d}`, ids[0]))
+	require.Equal(t, http.StatusOK, code, body)
+	assert.Equal(t, []string{"Card C", "Card A", "Card D", "Card B"}, boardColumns(t, db)["pending"])
+
+	code, body = moveTask(db, ids[0], `{"status":"in_progress"}`)
+	require.Equal(t, http.StatusOK, code, body)
+	code, body = moveTask(db, ids[1], fmt.Sprintf(`{"status":"in_progress","before_id":%d}`, ids[0]))
+	require.Equal(t, http.StatusOK, code, body)
+
+	columns := boardColumns(t, db)
+	assert.Equal(t, []string{"Card C", "Card D"}, columns["pending"])
+	assert.Equal(t, []string{"Card B", "Card A"}, columns["in_progress"])
+	assert.Empty(t, columns["completed"])
+
+	var moved model.Task
+	require.NoError(t, db.First(&moved, ids[1]).Error)
+	assert.Equal(t, uint(2), moved.Version, "a move is an update")
+}
+
+func TestBoard_MoveRejectsBadNeighbours(t *testing.T) {
+	db := setupTestDB(t)
+	ids := createBoardTasks(t, db, "Card A", "Card B", "Card C")
+
+	code, _ := moveTask(db, ids[0], fmt.Sprintf(`{"after_id":%d,"before_id":%d}`, ids[2], ids[1]))
+	assert.Equal(t, http.StatusBadRequest, code, "neighbours in the wrong order")
+
+	code, _ = moveTask(db, ids[0], fmt.Sprintf(`{"status":"completed","after_id":%d}`, ids[1]))
+	assert.Equal(t, http.StatusBadRequest, code, "neighbour in another column")
+
+	code, _ = moveTask(db, ids[0], fmt.Sprintf(`{"after_id":%d}`, ids[0]))
+	assert.Equal(t, http.StatusBadRequest, code, "a task is not its own neighbour")
+}
+
+func TestBoard_RebalancesDenseAndUnrankedColumns(t *testing.T) {
+	db := setupTestDB(t)
+	ids := createBoardTasks(t, db, "Card A", "Card B", "Card C")
+
+	// tasks from before the board have no rank
+	require.NoError(t, db.Model(&model.Task{}).Where("id IN ?", ids).UpdateColumn("board_rank", "").Error)
+	code, body := moveTask(db, ids[0], fmt.Sprintf(`{"after_id":%d}`, ids[1]))
+	require.Equal(t, http.StatusOK, code, body)
+	assert.Equal(t, []string{"Card B", "Card A", "Card C"}, boardColumns(t, db)["pending"])
+	var untouched model.Task
+	require.NoError(t, db.First(&untouched, ids[2]).Error)
+	assert.EqualValues(t, 2, untouched.Version, "a new rank is a new version")
+
+	// keep moving C and A into the gap right below B until it runs out
+	for i := 0; i < 200; i++ {
+		mover := ids[i%2*2]
+		other := ids[2-i%2*2]
+		code, body = moveTask(db, mover, fmt.Sprintf(`{"after_id":%d,"before_id":%d}`, ids[1], other))
+		require.Equal(t, http.StatusOK, code, body)
+	}
+	assert.Equal(t, []string{"Card B", "Card C", "Card A"}, boardColumns(t, db)["pending"])
+
+	var longest int
+	require.NoError(t, db.Model(&model.Task{}).Select("MAX(LENGTH(board_rank))").Scan(&longest).Error)
+	assert.LessOrEqual(t, longest, 24)
+}
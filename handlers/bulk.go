@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Niraj1910/Task-REST-APIs/filter"
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/Niraj1910/Task-REST-APIs/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// maxBulkTasks caps how many tasks one bulk request may touch.
+const maxBulkTasks = 500
+
+var (
+	// errBulkDryRun rolls back the bulk transaction after a dry run.
+	errBulkDryRun  = errors.New("bulk dry run")
+	errBulkTooMany = fmt.Errorf("a bulk request may touch at most %d tasks", maxBulkTasks)
+)
+
+type BulkTaskBody struct {
+	IDs      []uint      `json:"ids"`
+	Filter   *TaskFilter `json:"filter"`
+	Action   string      `json:"action" binding:"required,oneof=set_status set_priority delete restore"`
+	Status   string      `json:"status" binding:"omitempty,oneof=pending in_progress completed"`
+	Priority *int        `json:"priority" binding:"omitempty,gte=0,lte=10"`
+	Force    bool        `json:"force"`
+	DryRun   bool        `json:"dry_run"`
+}
+
+// BulkTaskResult is the outcome for one task of a bulk request. Result is
+// one of "updated", "unchanged", "failed" or "not_found".
+type BulkTaskResult struct {
+	ID     uint   `json:"id"`
+	Result string `json:"result"`
+	Error  string `json:"error,omitempty"`
+}
+
+// BulkTasks godoc
+// @Summary      Change many tasks at once
+// @Description  Applies one action to the tasks given by "ids" or matched by "filter" (same filters as GET /api/task).
+// @Description  Actions: set_status (with "status"), set_priority (with "priority"), delete, restore.
+// @Description  Everything runs in one transaction and only tasks owned by the user are touched. With "dry_run" nothing is saved.
+// @Tags         Tasks
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        body body handlers.BulkTaskBody true "Targets and action"
+// @Success      200 {object} map[string]interface{} "Per-task results"
+// @Failure      400 {object} map[string]string "Invalid input"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      500 {object} map[string]string "Server error"
+// @Router       /api/task/bulk [post]
+func BulkTasks(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		fields, err := taskFieldsFor(db, userID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve custom fields"})
+			return
+		}
+
+		var body BulkTaskBody
+		err = ctx.ShouldBindBodyWithJSON(&body)
+		if err == nil {
+			err = body.validate(fields)
+		}
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid input",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		var results []BulkTaskResult
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			tasks, missing, err := body.targets(tx, userID, fields)
+			if err != nil {
+				return err
+			}
+
+			for _, id := range missing {
+				results = append(results, BulkTaskResult{ID: id, Result: "not_found", Error: "Task not found or not owned by you"})
+			}
+
+			for _, task := range tasks {
+				result, err := body.applyTo(tx, task, userID)
+				if err != nil {
+					return err
+				}
+				results = append(results, result)
+			}
+
+			if body.DryRun {
+				return errBulkDryRun
+			}
+			return nil
+		})
+
+		switch {
+		case errors.Is(err, errBulkTooMany):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid input", "details": err.Error()})
+			return
+		case err != nil && !errors.Is(err, errBulkDryRun):
+			log.Error().Err(err).Uint("user_id", userID).Str("action", body.Action).Msg("Bulk task operation failed")
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Bulk operation failed, nothing was changed", "details": err.Error()})
+			return
+		}
+
+		if !body.DryRun {
+			eventType := eventTaskUpdated
+			switch body.Action {
+			case "delete":
+				eventType = eventTaskDeleted
+			case "restore":
+				eventType = eventTaskCreated
+			}
+			var changed []uint
+			for _, result := range results {
+				if result.Result == "updated" {
+					changed = append(changed, result.ID)
+				}
+			}
+			publishTaskEvents(db, eventType, changed)
+		}
+
+		succeeded, failed := 0, 0
+		for _, result := range results {
+			switch result.Result {
+			case "updated", "unchanged":
+				succeeded++
+			default:
+				failed++
+			}
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"action":    body.Action,
+			"dry_run":   body.DryRun,
+			"matched":   len(results),
+			"succeeded": succeeded,
+			"failed":    failed,
+			"results":   results,
+		})
+	}
+}
+
+func (b BulkTaskBody) validate(fields filter.Fields) error {
+
+	if (len(b.IDs) == 0) == (b.Filter == nil) {
+		return errors.New("provide either ids or filter")
+	}
+	if len(b.IDs) > maxBulkTasks {
+		return errBulkTooMany
+	}
+	if b.Filter != nil && b.Filter.Expression != "" {
+		if _, _, err := filter.ParseAndCompile(b.Filter.Expression, fields); err != nil {
+			return err
+		}
+	}
+
+	switch b.Action {
+	case "set_status":
+		if b.Status == "" {
+			return errors.New("set_status needs a status")
+		}
+	case "set_priority":
+		if b.Priority == nil {
+			return errors.New("set_priority needs a priority")
+		}
+	}
+	return nil
+}
+
+// targets loads the owned tasks the request points at. Restore works on the
+// trash, every other action on live tasks. For explicit IDs the ones that
+// could not be found are returned as missing.
+func (b BulkTaskBody) targets(tx *gorm.DB, userID uint, fields filter.Fields) ([]model.Task, []uint, error) {
+
+	query := tx.Where("user_id = ?", userID)
+	if b.Action == "restore" {
+		query = tx.Unscoped().Where("user_id = ? AND deleted_at IS NOT NULL", userID)
+	}
+
+	if b.Filter != nil {
+		var err error
+		query, err = b.Filter.applyAt(query, fields, userNow(tx, userID))
+		if err != nil {
+			return nil, nil, err
+		}
+	} else {
+		query = query.Where("id IN ?", b.IDs)
+	}
+
+	var tasks []model.Task
+	err := query.Order("id").Limit(maxBulkTasks + 1).Find(&tasks).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(tasks) > maxBulkTasks {
+		return nil, nil, errBulkTooMany
+	}
+
+	found := make(map[uint]bool, len(tasks))
+	for _, task := range tasks {
+		found[task.ID] = true
+	}
+
+	var missing []uint
+	for _, id := range b.IDs {
+		if !found[id] {
+			missing = append(missing, id)
+			found[id] = true // report duplicates once
+		}
+	}
+	return tasks, missing, nil
+}
+
+// applyTo runs the action on one task. Problems with the task itself end up
+// in the result; only database errors are returned and abort the request.
+func (b BulkTaskBody) applyTo(tx *gorm.DB, task model.Task, userID uint) (BulkTaskResult, error) {
+
+	result := BulkTaskResult{ID: task.ID, Result: "updated"}
+
+	switch b.Action {
+	case "set_status", "set_priority":
+		updates := map[string]interface{}{}
+
+		if b.Action == "set_priority" {
+			if task.Priority == *b.Priority {
+				result.Result = "unchanged"
+				return result, nil
+			}
+			updates["priority"] = *b.Priority
+		} else {
+			if task.Status == b.Status {
+				result.Result = "unchanged"
+				return result, nil
+			}
+			blockers, err := addStatusChange(tx, task, b.Status, b.Force, updates)
+			if err != nil {
+				return result, err
+			}
+			if len(blockers) > 0 {
+				result.Result = "failed"
+				result.Error = "Task is blocked by open tasks"
+				return result, nil
+			}
+			// like a single update, the task goes to the bottom of its new column
+			updates["board_rank"], err = bottomRank(tx, task.UserID, b.Status)
+			if err != nil {
+				return result, err
+			}
+		}
+
+		before := task
+		updates["version"] = nextVersion
+		err := tx.Model(&task).Updates(updates).Error
+		if err != nil {
+			return result, err
+		}
+		err = enqueueTaskWebhooks(tx, eventTaskUpdated, task.ID)
+		if err != nil {
+			return result, err
+		}
+		return result, recordTaskChanges(tx, before, updates, userID)
+
+	case "delete":
+		err := tx.Delete(&task).Error
+		if err != nil {
+			return result, err
+		}
+		err = recordTaskEvent(tx, task.ID, userID, "deleted", "", nil, nil)
+		if err != nil {
+			return result, err
+		}
+		err = enqueueTaskWebhooks(tx, eventTaskDeleted, task.ID)
+		if err != nil {
+			return result, err
+		}
+		return result, trashTaskChildren(tx, task.ID)
+
+	case "restore":
+		return result, restoreTask(tx, task.ID, userID)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type bulkResponse struct {
+	Matched   int              `json:"matched"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Results   []BulkTaskResult `json:"results"`
+}
+
+func runBulk(t *testing.T, db *gorm.DB, body string, userID uint) (int, bulkResponse) {
+	t.Helper()
+	c, w := setupContext(http.MethodPost, "/api/task/bulk", body, userID)
+	BulkTasks(db)(c)
+
+	var resp bulkResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return w.Code, resp
+}
+
+func TestBulkTasks_SetPriorityByIDsEnforcesOwnership(t *testing.T) {
+	db := setupTestDB(t)
+	mine := model.Task{Title: "Mine", UserID: 1}
+	theirs := model.Task{Title: "Theirs", UserID: 2}
+	require.NoError(t, db.Create(&[]*model.Task{&mine, &theirs}).Error)
+
+	code, resp := runBulk(t, db, `{"ids": [1, 2, 99], "action": "set_priority", "priority": 7}`, 1)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 3, resp.Matched)
+	assert.Equal(t, 1, resp.Succeeded)
+	assert.Equal(t, 2, resp.Failed)
+
+	db.First(&mine, mine.ID)
+	db.First(&theirs, theirs.ID)
+	assert.Equal(t, 7, mine.Priority)
+	assert.Equal(t, 0, theirs.Priority)
+
+	var events int64
+	db.Model(&model.TaskEvent{}).Where("task_id = ? AND field = ?", mine.ID, "priority").Count(&events)
+	assert.Equal(t, int64(1), events)
+}
+
+func TestBulkTasks_SetStatusMovesToBottomOfColumn(t *testing.T) {
+	db := setupTestDB(t)
+	ids := createBoardTasks(t, db, "Card A", "Card B", "Card C")
+
+	for _, id := range []uint{ids[2], ids[0]} {
+		code, resp := runBulk(t, db, fmt.Sprintf(`{"ids": [%d], "action": "set_status", "status": "in_progress"}`, id), 1)
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, 1, resp.Succeeded)
+	}
+	columns := boardColumns(t, db)
+	assert.Equal(t, []string{"Card B"}, columns["pending"])
+	assert.Equal(t, []string{"Card C", "Card A"}, columns["in_progress"])
+}
+
+func TestBulkTasks_FilterDeleteAndRestore(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.Create(&[]model.Task{
+		{Title: "Done 1", UserID: 1, Status: "completed"},
+		{Title: "Done 2", UserID: 1, Status: "completed"},
+		{Title: "Open", UserID: 1, Status: "pending"},
+	}).Error)
+
+	// dry run reports but keeps everything
+	code, resp := runBulk(t, db, `{"filter": {"status": "completed"}, "action": "delete", "dry_run": true}`, 1)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 2, resp.Succeeded)
+
+	var count int64
+	db.Model(&model.Task{}).Count(&count)
+	assert.Equal(t, int64(3), count)
+
+	code, resp = runBulk(t, db, `{"filter": {"status": "completed"}, "action": "delete"}`, 1)
+	require.Equal(t, http.StatusOK, code)
+	db.Model(&model.Task{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	code, resp = runBulk(t, db, `{"filter": {}, "action": "restore"}`, 1)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 2, resp.Succeeded)
+	db.Model(&model.Task{}).Count(&count)
+	assert.Equal(t, int64(3), count)
+}
+
+func TestBulkTasks_InvalidInput(t *testing.T) {
+	db := setupTestDB(t)
+
+	code, _ := runBulk(t, db, `{"action": "delete"}`, 1)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, _ = runBulk(t, db, `{"ids": [1], "action": "set_status"}`, 1)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, _ = runBulk(t, db, `{"ids": [1], "action": "add_label"}`, 1)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
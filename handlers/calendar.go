@@ -0,0 +1,310 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Niraj1910/Task-REST-APIs/ical"
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/Niraj1910/Task-REST-APIs/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// maxCalendarTasks caps the number of tasks in one feed.
+const maxCalendarTasks = 2000
+
+// icalStatus maps task statuses to VTODO STATUS values and back.
+var icalStatus = map[string]string{
+	"pending":     "NEEDS-ACTION",
+	"in_progress": "IN-PROCESS",
+	"completed":   "COMPLETED",
+}
+
+// CalendarFeed godoc
+// @Summary      Calendar feed of tasks
+// @Description  Public iCalendar feed of the tasks with a due date that the token's owner created or is assigned to.
+// @Description  Each task is a VTODO and, for calendar apps that ignore to-dos, a VEVENT at its due time.
+// @Description  The token comes from POST /api/user/calendar-token; the ".ics" suffix is optional.
+// @Tags         Calendar
+// @Produce      text/calendar
+// @Param        token      path  string true  "Secret calendar token, e.g. 3f9a....ics"
+// @Param        components query string false "vtodo, vevent or both (default)"
+// @Success      200 {string} string "iCalendar document"
+// @Failure      404 {object} map[string]string "Unknown token"
+// @Router       /cal/{token} [get]
+func CalendarFeed(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		token := strings.TrimSuffix(ctx.Param("token"), ".ics")
+		if token == "" {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Calendar not found"})
+			return
+		}
+
+		var user model.User
+		err := db.Where("calendar_token = ?", token).First(&user).Error
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Calendar not found"})
+			return
+		}
+
+		todos, events := true, true
+		switch ctx.DefaultQuery("components", "both") {
+		case "vtodo":
+			events = false
+		case "vevent":
+			todos = false
+		}
+
+		var tasks []model.Task
+		err = db.Scopes(visibleTasks(user.ID)).
+			Where("tasks.due_at IS NOT NULL").
+			Order("tasks.due_at").Limit(maxCalendarTasks).
+			Find(&tasks).Error
+		if err != nil {
+			log.Error().Err(err).Uint("user_id", user.ID).Msg("Failed to load calendar tasks")
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build calendar"})
+			return
+		}
+
+		ctx.Header("Content-Type", "text/calendar; charset=utf-8")
+		ctx.Header("Content-Disposition", `inline; filename="tasks.ics"`)
+		ctx.Header("Cache-Control", "private, max-age=300")
+		ctx.Status(http.StatusOK)
+
+		enc := ical.NewEncoder(ctx.Writer)
+		writeTaskCalendar(enc, user.Name, tasks, todos, events)
+		if err := enc.Close(); err != nil {
+			log.Error().Err(err).Uint("user_id", user.ID).Msg("Failed to write calendar feed")
+		}
+	}
+}
+
+// writeTaskCalendar renders tasks as a VCALENDAR.
+func writeTaskCalendar(enc *ical.Encoder, owner string, tasks []model.Task, todos, events bool) {
+
+	domain := "task-rest-apis"
+	if base, err := url.Parse(appBaseURL()); err == nil && base.Hostname() != "" {
+		domain = base.Hostname()
+	}
+	now := time.Now()
+
+	enc.Begin("VCALENDAR")
+	enc.Raw("VERSION", "2.0")
+	enc.Raw("PRODID", "-//Task-REST-APIs//Tasks//EN")
+	enc.Raw("CALSCALE", "GREGORIAN")
+	enc.Text("X-WR-CALNAME", owner+"'s tasks")
+	enc.Raw("REFRESH-INTERVAL", "PT15M", "VALUE=DURATION")
+	enc.Raw("X-PUBLISHED-TTL", "PT15M")
+
+	for _, task := range tasks {
+		link := fmt.Sprintf("%s/api/task/%d", appBaseURL(), task.ID)
+		allDay := isDateOnly(*task.DueAt)
+
+		if todos {
+			enc.Begin("VTODO")
+			enc.Raw("UID", fmt.Sprintf("task-%d@%s", task.ID, domain))
+			enc.Time("DTSTAMP", now)
+			enc.Time("CREATED", task.CreatedAt)
+			enc.Time("LAST-MODIFIED", task.UpdatedAt)
+			enc.Raw("SEQUENCE", strconv.FormatUint(uint64(task.Version), 10))
+			enc.Text("SUMMARY", task.Title)
+			if task.Description != "" {
+				enc.Text("DESCRIPTION", task.Description)
+			}
+			if allDay {
+				enc.Date("DUE", task.DueAt.UTC())
+			} else {
+				enc.Time("DUE", *task.DueAt)
+			}
+			enc.Raw("STATUS", icalStatus[task.Status])
+			enc.Raw("PRIORITY", strconv.Itoa(icalPriority(task.Priority)))
+			enc.Raw("PERCENT-COMPLETE", strconv.Itoa(percentComplete(task.Status)))
+			if task.CompletedAt != nil {
+				enc.Time("COMPLETED", *task.CompletedAt)
+			}
+			enc.Raw("URL", link, "VALUE=URI")
+			enc.End("VTODO")
+		}
+
+		if events {
+			summary := task.Title
+			if task.Status == "completed" {
+				summary = "✓ " + summary
+			}
+
+			enc.Begin("VEVENT")
+			enc.Raw("UID", fmt.Sprintf("task-%d-due@%s", task.ID, domain))
+			enc.Time("DTSTAMP", now)
+			enc.Raw("SEQUENCE", strconv.FormatUint(uint64(task.Version), 10))
+			enc.Text("SUMMARY", summary)
+			if task.Description != "" {
+				enc.Text("DESCRIPTION", task.Description)
+			}
+			if allDay {
+				enc.Date("DTSTART", task.DueAt.UTC())
+			} else {
+				enc.Time("DTSTART", *task.DueAt)
+			}
+			enc.Raw("PRIORITY", strconv.Itoa(icalPriority(task.Priority)))
+			enc.Raw("TRANSP", "TRANSPARENT")
+			enc.Raw("URL", link, "VALUE=URI")
+			enc.End("VEVENT")
+		}
+	}
+
+	enc.End("VCALENDAR")
+}
+
+// icalPriority maps the task priority (0 none, 10 most important) to the
+// iCalendar one (0 undefined, 1 highest, 9 lowest).
+func icalPriority(priority int) int {
+	if priority <= 0 {
+		return 0
+	}
+	return max(1, 10-priority)
+}
+
+// taskPriority is the inverse of icalPriority.
+func taskPriority(priority int) int {
+	if priority <= 0 || priority > 9 {
+		return 0
+	}
+	return 10 - priority
+}
+
+func percentComplete(status string) int {
+	switch status {
+	case "completed":
+		return 100
+	case "in_progress":
+		return 50
+	}
+	return 0
+}
+
+// isDateOnly reports whether a due time stands for a whole day, which is how
+// all-day dates are stored.
+func isDateOnly(t time.Time) bool {
+	t = t.UTC()
+	return t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0
+}
+
+// RotateCalendarToken godoc
+// @Summary      Create or rotate the calendar feed URL
+// @Description  Generates a new secret calendar token; the previous feed URL stops working
+// @Tags         Users
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200 {object} map[string]string "calendar_url"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      500 {object} map[string]string "Server error"
+// @Router       /api/user/calendar-token [post]
+func RotateCalendarToken(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		secret := make([]byte, 24)
+		if _, err := rand.Read(secret); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create calendar token"})
+			return
+		}
+		token := hex.EncodeToString(secret)
+
+		err := db.Model(&model.User{}).Where("id = ?", userID).Update("calendar_token", token).Error
+		if err != nil {
+			log.Error().Err(err).Uint("user_id", userID).Msg("Failed to rotate calendar token")
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create calendar token"})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"calendar_url": calendarURL(token)})
+	}
+}
+
+// DisableCalendarToken godoc
+// @Summary      Turn the calendar feed off
+// @Description  Removes the calendar token; the feed URL stops working
+// @Tags         Users
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200 {object} map[string]string "Calendar feed disabled"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Router       /api/user/calendar-token [delete]
+func DisableCalendarToken(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		err := db.Model(&model.User{}).Where("id = ?", userID).Update("calendar_token", nil).Error
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable the calendar feed"})
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{"message": "Calendar feed disabled"})
+	}
+}
+
+func calendarURL(token string) string {
+	return fmt.Sprintf("%s/cal/%s.ics", appBaseURL(), token)
+}
+
+// icsImportRows turns the VTODOs of an iCalendar file into import rows.
+// Cancelled to-dos keep their status and are reported as invalid.
+func icsImportRows(cal *ical.Component) []map[string]string {
+
+	fromICal := map[string]string{}
+	for status, value := range icalStatus {
+		fromICal[value] = status
+	}
+
+	var rows []map[string]string
+	for _, todo := range cal.Find("VTODO") {
+		row := map[string]string{}
+
+		if p, ok := todo.Get("SUMMARY"); ok {
+			row["title"] = p.Text()
+		}
+		if p, ok := todo.Get("DESCRIPTION"); ok {
+			row["description"] = p.Text()
+		}
+		if p, ok := todo.Get("PRIORITY"); ok {
+			if n, err := strconv.Atoi(strings.TrimSpace(p.Value)); err == nil {
+				row["priority"] = strconv.Itoa(taskPriority(n))
+			} else {
+				row["priority"] = p.Value
+			}
+		}
+		if p, ok := todo.Get("STATUS"); ok {
+			status, known := fromICal[strings.ToUpper(p.Value)]
+			if !known {
+				status = strings.ToLower(p.Value)
+			}
+			row["status"] = status
+		}
+		if p, ok := todo.Get("DUE"); ok {
+			if due, _, err := p.Time(); err == nil {
+				row["due_at"] = due.UTC().Format(time.RFC3339)
+			} else {
+				row["due_at"] = p.Value
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
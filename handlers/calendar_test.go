@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Niraj1910/Task-REST-APIs/ical"
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func rotateCalendarToken(t *testing.T, db *gorm.DB, userID uint) string {
+	t.Helper()
+	c, w := setupContext(http.MethodPost, "/api/user/calendar-token", "", userID)
+	RotateCalendarToken(db)(c)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp map[string]string
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return path.Base(resp["calendar_url"])
+}
+
+func getCalendar(db *gorm.DB, token string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{{Key: "token", Value: token}}
+	c.Request = httptest.NewRequest(http.MethodGet, "/cal/"+token, nil)
+	CalendarFeed(db)(c)
+	return w
+}
+
+func TestCalendarFeed_RendersTasksWithDueDates(t *testing.T) {
+	db := setupTestDB(t)
+	user := model.User{Name: "Niraj", Email: "niraj@example.com"}
+	require.NoError(t, db.Create(&user).Error)
+
+	due := time.Date(2026, 3, 14, 15, 30, 0, 0, time.UTC)
+	day := time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC)
+	done := due.Add(-time.Hour)
+	require.NoError(t, db.Create(&[]model.Task{
+		{Title: "Send the invoice, today", Priority: 9, Status: "in_progress", UserID: user.ID, DueAt: &due},
+		{Title: "Renew the domain", Status: "completed", UserID: user.ID, DueAt: &day, CompletedAt: &done},
+		{Title: "Someday, maybe", Status: "pending", UserID: user.ID},
+	}).Error)
+
+	token := rotateCalendarToken(t, db, user.ID)
+	require.True(t, strings.HasSuffix(token, ".ics"))
+
+	w := getCalendar(db, token)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "text/calendar; charset=utf-8", w.Header().Get("Content-Type"))
+
+	cal, err := ical.Parse(w.Body)
+	require.NoError(t, err)
+	todos := cal.Find("VTODO")
+	require.Len(t, todos, 2, "tasks without a due date are left out")
+	assert.Len(t, cal.Find("VEVENT"), 2)
+
+	summary, _ := todos[0].Get("SUMMARY")
+	assert.Equal(t, "Send the invoice, today", summary.Text())
+	dueProp, _ := todos[0].Get("DUE")
+	assert.Equal(t, "20260314T153000Z", dueProp.Value)
+	for name, want := range map[string]string{"STATUS": "IN-PROCESS", "PRIORITY": "1", "PERCENT-COMPLETE": "50"} {
+		p, _ := todos[0].Get(name)
+		assert.Equal(t, want, p.Value, name)
+	}
+
+	dueProp, _ = todos[1].Get("DUE")
+	assert.Equal(t, "DATE", dueProp.Params["VALUE"], "midnight due dates are all-day")
+	status, _ := todos[1].Get("STATUS")
+	assert.Equal(t, "COMPLETED", status.Value)
+	_, ok := todos[1].Get("COMPLETED")
+	assert.True(t, ok)
+}
+
+func TestCalendarFeed_RotatingTheTokenRevokesTheOldURL(t *testing.T) {
+	db := setupTestDB(t)
+	user := model.User{Name: "Niraj", Email: "niraj@example.com"}
+	require.NoError(t, db.Create(&user).Error)
+
+	old := rotateCalendarToken(t, db, user.ID)
+	current := rotateCalendarToken(t, db, user.ID)
+	assert.NotEqual(t, old, current)
+
+	assert.Equal(t, http.StatusNotFound, getCalendar(db, old).Code)
+	assert.Equal(t, http.StatusOK, getCalendar(db, current).Code)
+
+	c, w := setupContext(http.MethodGet, "/api/user/profile", "", user.ID)
+	GetUserProfile(db)(c)
+	assert.Contains(t, w.Body.String(), current)
+
+	c, _ = setupContext(http.MethodDelete, "/api/user/calendar-token", "", user.ID)
+	DisableCalendarToken(db)(c)
+	assert.Equal(t, http.StatusNotFound, getCalendar(db, current).Code)
+}
+
+const importICS = "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//Example//EN\r\n" +
+	"BEGIN:VTODO\r\nUID:1@example.com\r\nSUMMARY:Book the flights\\, both ways\r\n" +
+	"DESCRIPTION:Window seat\\nif possible\r\nPRIORITY:2\r\nSTATUS:NEEDS-ACTION\r\nDUE;VALUE=DATE:20260401\r\nEND:VTODO\r\n" +
+	"BEGIN:VTODO\r\nUID:2@example.com\r\nSUMMARY:Cancel the gym\r\nSTATUS:CANCELLED\r\nEND:VTODO\r\n" +
+	"BEGIN:VEVENT\r\nUID:3@example.com\r\nSUMMARY:Not a to-do\r\nDTSTART:20260401T090000Z\r\nEND:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestImportTasks_ICalendar(t *testing.T) {
+	db := setupTestDB(t)
+
+	w := runImport(t, db, "", "text/calendar", importICS)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var summary ImportSummary
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &summary))
+	assert.Equal(t, 2, summary.Total, "only VTODOs are imported")
+	assert.Equal(t, 1, summary.Created)
+	assert.Equal(t, 1, summary.Invalid, "cancelled to-dos have no task status")
+
+	var task model.Task
+	require.NoError(t, db.First(&task).Error)
+	assert.Equal(t, "Book the flights, both ways", task.Title)
+	assert.Equal(t, "Window seat\nif possible", task.Description)
+	assert.Equal(t, 8, task.Priority)
+	assert.Equal(t, "pending", task.Status)
+	require.NotNil(t, task.DueAt)
+	assert.True(t, task.DueAt.Equal(time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)))
+}
@@ -0,0 +1,441 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/Niraj1910/Task-REST-APIs/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+type CommentBody struct {
+	Body string `json:"body" binding:"required,max=5000"`
+	// MentionIDs are the users the @mentions in Body refer to, as picked
+	// by the client. Without them a mention only counts when one user who
+	// can see the task has that name.
+	MentionIDs []uint `json:"mention_ids" binding:"omitempty,max=20"`
+}
+
+// CreateComment godoc
+// @Summary      Comment on a task
+// @Description  Adds a Markdown comment to a task the user created or is assigned to. @username mentions notify the mentioned users who can see the task.
+// @Tags         Comments
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id   path int true "Task ID"
+// @Param        body body handlers.CommentBody true "Comment (Markdown)"
+// @Success      201 {object} map[string]interface{} "Created comment"
+// @Failure      400 {object} map[string]string "Invalid input"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      404 {object} map[string]string "Task not found"
+// @Failure      500 {object} map[string]string "Server error"
+// @Router       /api/task/{id}/comments [post]
+func CreateComment(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		taskID, ok := parseIDParam(ctx, "id", "Task ID")
+		if !ok {
+			return
+		}
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		var input CommentBody
+		err := ctx.ShouldBindBodyWithJSON(&input)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid input",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		task, err := findVisibleTask(db, taskID, userID)
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found or not visible to you"})
+			return
+		}
+
+		comment := model.Comment{
+			TaskID: task.ID,
+			UserID: userID,
+			Body:   input.Body,
+		}
+
+		err = db.Create(&comment).Error
+		if err != nil {
+			log.Error().Err(err).Uint("task_id", task.ID).Msg("Failed to create comment")
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create comment"})
+			return
+		}
+
+		mentioned := notifyMentions(db, task, comment, parseMentions(comment.Body), input.MentionIDs)
+		notifyCommented(db, task, comment, mentioned)
+
+		ctx.JSON(http.StatusCreated, commentResponse(comment))
+	}
+}
+
+// GetComments godoc
+// @Summary      List comments of a task
+// @Description  Returns the task's comments, oldest first
+// @Tags         Comments
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id    path  int true  "Task ID"
+// @Param        page  query int false "Page number"    default(1)
+// @Param        limit query int false "Items per page" default(10)
+// @Success      200 {object} map[string]interface{} "Comments with pagination meta"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      404 {object} map[string]string "Task not found"
+// @Failure      500 {object} map[string]string "Server error"
+// @Router       /api/task/{id}/comments [get]
+func GetComments(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		taskID, ok := parseIDParam(ctx, "id", "Task ID")
+		if !ok {
+			return
+		}
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		_, err := findVisibleTask(db, taskID, userID)
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found or not visible to you"})
+			return
+		}
+
+		page, limit, offset := pageParams(ctx)
+
+		query := db.Model(&model.Comment{}).Where("task_id = ?", taskID)
+
+		var total int64
+		query.Count(&total)
+
+		var comments []model.Comment
+		err = query.Order("created_at ASC, id ASC").Limit(limit).Offset(offset).Find(&comments).Error
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve comments", "details": err.Error()})
+			return
+		}
+
+		items := make([]gin.H, 0, len(comments))
+		for _, comment := range comments {
+			items = append(items, commentResponse(comment))
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"comments": items,
+			"meta": gin.H{
+				"total": total,
+				"page":  page,
+				"limit": limit,
+			},
+		})
+	}
+}
+
+// UpdateComment godoc
+// @Summary      Edit a comment
+// @Description  Replaces the body of a comment written by the authenticated user and sets edited_at
+// @Tags         Comments
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id        path int true "Task ID"
+// @Param        commentId path int true "Comment ID"
+// @Param        body      body handlers.CommentBody true "New comment body (Markdown)"
+// @Success      200 {object} map[string]interface{} "Updated comment"
+// @Failure      400 {object} map[string]string "Invalid input"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      404 {object} map[string]string "Comment not found or not written by you"
+// @Failure      500 {object} map[string]string "Server error"
+// @Router       /api/task/{id}/comments/{commentId} [put]
+func UpdateComment(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		taskID, ok := parseIDParam(ctx, "id", "Task ID")
+		if !ok {
+			return
+		}
+
+		commentID, ok := parseIDParam(ctx, "commentId", "Comment ID")
+		if !ok {
+			return
+		}
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		var input CommentBody
+		err := ctx.ShouldBindBodyWithJSON(&input)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid input",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		task, err := findVisibleTask(db, taskID, userID)
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found or not visible to you"})
+			return
+		}
+
+		var comment model.Comment
+		err = db.Where("id = ? AND task_id = ? AND user_id = ?", commentID, taskID, userID).First(&comment).Error
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Comment not found or not written by you"})
+			return
+		}
+
+		previousMentions := parseMentions(comment.Body)
+
+		now := time.Now()
+		err = db.Model(&comment).Updates(map[string]interface{}{"body": input.Body, "edited_at": now}).Error
+		if err != nil {
+			log.Error().Err(err).Uint("comment_id", commentID).Msg("Failed to update comment")
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update comment"})
+			return
+		}
+
+		// only people who were not mentioned before hear about the edit
+		var newMentions []string
+		for _, name := range parseMentions(comment.Body) {
+			if !slices.Contains(previousMentions, name) {
+				newMentions = append(newMentions, name)
+			}
+		}
+		notifyMentions(db, task, comment, newMentions, input.MentionIDs)
+
+		ctx.JSON(http.StatusOK, commentResponse(comment))
+	}
+}
+
+// DeleteComment godoc
+// @Summary      Delete a comment
+// @Description  Soft-deletes a comment. The comment's author and the task owner may delete it.
+// @Tags         Comments
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id        path int true "Task ID"
+// @Param        commentId path int true "Comment ID"
+// @Success      200 {object} map[string]interface{} "Comment deleted"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      404 {object} map[string]string "Comment not found"
+// @Failure      500 {object} map[string]string "Server error"
+// @Router       /api/task/{id}/comments/{commentId} [delete]
+func DeleteComment(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		taskID, ok := parseIDParam(ctx, "id", "Task ID")
+		if !ok {
+			return
+		}
+
+		commentID, ok := parseIDParam(ctx, "commentId", "Comment ID")
+		if !ok {
+			return
+		}
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		task, err := findVisibleTask(db, taskID, userID)
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found or not visible to you"})
+			return
+		}
+
+		var comment model.Comment
+		err = db.Where("id = ? AND task_id = ?", commentID, taskID).First(&comment).Error
+		if err != nil || (comment.UserID != userID && task.UserID != userID) {
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete comment"})
+				return
+			}
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Comment not found or not deletable by you"})
+			return
+		}
+
+		err = db.Delete(&comment).Error
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete comment"})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Successfully deleted comment", "comment_id": comment.ID})
+	}
+}
+
+func commentResponse(comment model.Comment) gin.H {
+	return gin.H{
+		"id":         comment.ID,
+		"task_id":    comment.TaskID,
+		"user_id":    comment.UserID,
+		"body":       comment.Body,
+		"created_at": comment.CreatedAt,
+		"updated_at": comment.UpdatedAt,
+		"edited_at":  comment.EditedAt,
+	}
+}
+
+var (
+	// usernames are 3-20 characters at registration
+	mentionPattern = regexp.MustCompile(`(^|[^\w@])@([A-Za-z0-9_.-]{3,20})`)
+	fencedCode     = regexp.MustCompile("(?s)```.*?```")
+	inlineCode     = regexp.MustCompile("`[^`\n]*`")
+)
+
+// parseMentions returns the distinct @usernames of a Markdown body, ignoring
+// anything inside code spans and fenced code blocks.
+func parseMentions(body string) []string {
+
+	body = fencedCode.ReplaceAllString(body, "")
+	body = inlineCode.ReplaceAllString(body, "")
+
+	var names []string
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		// "@niraj." at the end of a sentence mentions "niraj"
+		name := strings.TrimRight(match[2], ".-")
+		if len(name) >= 3 && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// notifyMentions notifies the mentioned users who can see the task and
+// returns their IDs. Names are not unique, so when the client sent ids only
+// those users count; otherwise a name must match exactly one user who can
+// see the task. The author is never notified about their own comment.
+func notifyMentions(db *gorm.DB, task model.Task, comment model.Comment, names []string, ids []uint) []uint {
+
+	if len(names) == 0 {
+		return nil
+	}
+
+	// only the owner and the assignee can see the task
+	visible := []uint{task.UserID}
+	if task.AssigneeID != nil {
+		visible = append(visible, *task.AssigneeID)
+	}
+
+	var users []model.User
+	err := db.Where("id IN ? AND name IN ?", visible, names).Find(&users).Error
+	if err != nil {
+		log.Error().Err(err).Uint("comment_id", comment.ID).Msg("Failed to look up mentioned users")
+		return nil
+	}
+
+	var author model.User
+	db.Select("name").First(&author, comment.UserID)
+
+	taskLink := fmt.Sprintf("%s/api/task/%d", appBaseURL(), task.ID)
+
+	var notified []uint
+	for _, user := range users {
+		if user.ID == comment.UserID {
+			continue
+		}
+		if ids != nil {
+			if !slices.Contains(ids, user.ID) {
+				continue
+			}
+		} else if slices.IndexFunc(users, func(other model.User) bool {
+			return other.ID != user.ID && other.Name == user.Name
+		}) >= 0 {
+			continue
+		}
+		notified = append(notified, user.ID)
+
+		email := notify(db, model.Notification{
+			UserID:  user.ID,
+			Type:    eventMentioned,
+			TaskID:  &task.ID,
+			ActorID: &comment.UserID,
+			Title:   fmt.Sprintf("%s mentioned you on %q", author.Name, task.Title),
+			Body:    comment.Body,
+			Link:    taskLink,
+		})
+		if !email {
+			continue
+		}
+
+		go func(user model.User) {
+			err := utils.SendMentionMail(user.Name, user.Email, author.Name, task.Title, comment.Body, taskLink)
+			if err != nil {
+				log.Error().Err(err).Uint("comment_id", comment.ID).Str("email", user.Email).Msg("Failed to send mention email")
+			}
+		}(user)
+	}
+	return notified
+}
+
+// notifyCommented tells the task's owner and assignee about a new comment,
+// except its author and the users already notified about a mention in it.
+func notifyCommented(db *gorm.DB, task model.Task, comment model.Comment, mentioned []uint) {
+
+	recipients := []uint{task.UserID}
+	if task.AssigneeID != nil && *task.AssigneeID != task.UserID {
+		recipients = append(recipients, *task.AssigneeID)
+	}
+
+	var author model.User
+	db.Select("name").First(&author, comment.UserID)
+
+	taskLink := fmt.Sprintf("%s/api/task/%d", appBaseURL(), task.ID)
+
+	for _, userID := range recipients {
+		if userID == comment.UserID || slices.Contains(mentioned, userID) {
+			continue
+		}
+
+		email := notify(db, model.Notification{
+			UserID:  userID,
+			Type:    eventCommented,
+			TaskID:  &task.ID,
+			ActorID: &comment.UserID,
+			Title:   fmt.Sprintf("%s commented on %q", author.Name, task.Title),
+			Body:    comment.Body,
+			Link:    taskLink,
+		})
+		if !email {
+			continue
+		}
+
+		var user model.User
+		if err := db.Select("name", "email").First(&user, userID).Error; err != nil {
+			continue
+		}
+		go func() {
+			err := utils.SendCommentMail(user.Name, user.Email, author.Name, task.Title, comment.Body, taskLink)
+			if err != nil {
+				log.Error().Err(err).Uint("comment_id", comment.ID).Str("email", user.Email).Msg("Failed to send comment email")
+			}
+		}()
+	}
+}
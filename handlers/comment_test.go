@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMentions(t *testing.T) {
+	body := "Thanks @niraj, can @alice_b look?\n```\n@ignored in code\n```\nAlso `@inline` and mail@example.com. cc @niraj."
+
+	assert.Equal(t, []string{"niraj", "alice_b"}, parseMentions(body))
+}
+
+func TestComments_CreateEditDelete(t *testing.T) {
+	db := setupTestDB(t)
+	task := model.Task{Title: "Write report", UserID: 1}
+	require.NoError(t, db.Create(&task).Error)
+	taskParam := gin.Param{Key: "id", Value: fmt.Sprint(task.ID)}
+
+	c, w := setupContext(http.MethodPost, "/api/task/1/comments", `{"body": "First **draft** is up"}`, 1)
+	c.Params = gin.Params{taskParam}
+	CreateComment(db)(c)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var created map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	commentParam := gin.Param{Key: "commentId", Value: fmt.Sprint(created["id"])}
+	assert.Nil(t, created["edited_at"])
+
+	c, w = setupContext(http.MethodPut, "/api/task/1/comments/1", `{"body": "Second draft is up"}`, 1)
+	c.Params = gin.Params{taskParam, commentParam}
+	UpdateComment(db)(c)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var edited map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &edited)
+	assert.Equal(t, "Second draft is up", edited["body"])
+	assert.NotNil(t, edited["edited_at"])
+
+	c, w = setupContext(http.MethodDelete, "/api/task/1/comments/1", "", 1)
+	c.Params = gin.Params{taskParam, commentParam}
+	DeleteComment(db)(c)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	c, w = setupContext(http.MethodGet, "/api/task/1/comments", "", 1)
+	c.Params = gin.Params{taskParam}
+	GetComments(db)(c)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var list struct {
+		Comments []map[string]interface{} `json:"comments"`
+		Meta     struct {
+			Total int64 `json:"total"`
+		} `json:"meta"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &list)
+	assert.Empty(t, list.Comments)
+	assert.Equal(t, int64(0), list.Meta.Total)
+
+	var stored model.Comment
+	require.NoError(t, db.Unscoped().First(&stored).Error)
+	assert.True(t, stored.DeletedAt.Valid, "comment should be soft-deleted")
+}
+
+func TestCreateComment_TaskNotVisible(t *testing.T) {
+	db := setupTestDB(t)
+	task := model.Task{Title: "Private task", UserID: 1}
+	require.NoError(t, db.Create(&task).Error)
+
+	c, w := setupContext(http.MethodPost, "/api/task/1/comments", `{"body": "hello"}`, 2)
+	c.Params = gin.Params{{Key: "id", Value: fmt.Sprint(task.ID)}}
+	CreateComment(db)(c)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestCreateComment_MentionsResolveByID(t *testing.T) {
+	db := setupTestDB(t)
+	users := []model.User{
+		{Name: "sam", Email: "sam.owner@example.com"},
+		{Name: "sam", Email: "sam.assignee@example.com"},
+		{Name: "sam", Email: "sam.stranger@example.com"},
+	}
+	require.NoError(t, db.Create(&users).Error)
+	task := model.Task{Title: "Write report", UserID: users[0].ID, AssigneeID: &users[1].ID}
+	require.NoError(t, db.Create(&task).Error)
+
+	mentioned := func(body string) []uint {
+		db.Where("1 = 1").Delete(&model.Notification{})
+		c, w := setupContext(http.MethodPost, "/", body, users[0].ID)
+		c.Params = taskParam(task.ID)
+		CreateComment(db)(c)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var ids []uint
+		db.Model(&model.Notification{}).Where("type = ?", eventMentioned).Pluck("user_id", &ids)
+		return ids
+	}
+
+	assert.Empty(t, mentioned(`{"body": "Over to you @sam"}`), "the name is ambiguous")
+	assert.Equal(t, []uint{users[1].ID}, mentioned(fmt.Sprintf(`{"body": "Over to you @sam", "mention_ids": [%d, %d]}`, users[1].ID, users[2].ID)))
+}
+
+func TestGetComments_ClampsLimit(t *testing.T) {
+	db := setupTestDB(t)
+	task := model.Task{Title: "Write report", UserID: 1}
+	require.NoError(t, db.Create(&task).Error)
+
+	c, w := setupContext(http.MethodGet, "/api/task/1/comments?limit=500", "", 1)
+	c.Params = taskParam(task.ID)
+	GetComments(db)(c)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var list struct {
+		Meta struct {
+			Limit int `json:"limit"`
+		} `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Equal(t, 100, list.Meta.Limit, "too large a limit is capped, not reset")
+}
@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Niraj1910/Task-REST-APIs/filter"
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// taskCursor marks a position in a sorted task list: the sort values and ID
+// of the task next to it. Backward cursors page towards the start.
+type taskCursor struct {
+	Sort     string `json:"s"`
+	Values   []any  `json:"v"`
+	Backward bool   `json:"b,omitempty"`
+}
+
+// TaskCursorPage is the response of the task lists in cursor mode.
+type TaskCursorPage struct {
+	Tasks []model.Task `json:"tasks"`
+	Meta  struct {
+		Limit      int    `json:"limit"`
+		NextCursor string `json:"next_cursor,omitempty"`
+		PrevCursor string `json:"prev_cursor,omitempty"`
+		Total      *int64 `json:"total,omitempty"`
+	} `json:"meta"`
+}
+
+// cursorRequested reports whether the client asked for cursor pagination.
+// An empty cursor asks for the first page.
+func cursorRequested(ctx *gin.Context) bool {
+	_, ok := ctx.GetQuery("cursor")
+	return ok
+}
+
+// cursorSort parses the sort for cursor pagination over the given fields and
+// appends the task ID as a tie breaker so that every position in the list
+// is unique.
+func cursorSort(sort string, available filter.Fields) ([]filter.SortField, error) {
+
+	fields, err := filter.ParseSort(sort, available)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, field := range fields {
+		if field.Nullable {
+			return nil, fmt.Errorf("cursor pagination cannot sort by %s", field.Name)
+		}
+	}
+
+	last := fields[len(fields)-1]
+	if !slices.ContainsFunc(fields, func(f filter.SortField) bool { return f.Name == "id" }) {
+		fields = append(fields, filter.SortField{Name: "id", Field: taskFields["id"], Desc: last.Desc})
+	}
+	return fields, nil
+}
+
+// paginateTasksByCursor answers a task list in cursor mode: it reads the
+// next page after (or before) the cursor, without OFFSET and without a
+// COUNT unless include_total=true.
+func paginateTasksByCursor(ctx *gin.Context, query *gorm.DB, sort string, limit int, available filter.Fields) {
+
+	fields, err := cursorSort(sort, available)
+	if err != nil {
+		invalidFilter(ctx, err)
+		return
+	}
+
+	var cursor *taskCursor
+	if raw := ctx.Query("cursor"); raw != "" {
+		cursor, err = decodeTaskCursor(raw, sort, fields)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor", "details": err.Error()})
+			return
+		}
+	}
+
+	var page TaskCursorPage
+	page.Meta.Limit = limit
+
+	if ctx.Query("include_total") == "true" {
+		var total int64
+		if err := query.Session(&gorm.Session{}).Model(&model.Task{}).Count(&total).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count tasks", "details": err.Error()})
+			return
+		}
+		page.Meta.Total = &total
+	}
+
+	backward := cursor != nil && cursor.Backward
+	if cursor != nil {
+		condition, args := keysetCondition(fields, cursor.Values, backward)
+		query = query.Where(condition, args...)
+	}
+
+	var tasks []model.Task
+	err = query.Clauses(orderBy(fields, backward)).Limit(limit + 1).Find(&tasks).Error
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch task page")
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve tasks", "details": err.Error()})
+		return
+	}
+
+	more := len(tasks) > limit
+	if more {
+		tasks = tasks[:limit]
+	}
+	if backward {
+		slices.Reverse(tasks)
+	}
+
+	// Going forward there is a previous page whenever we started from a
+	// cursor; going backward there is always a next page.
+	if len(tasks) > 0 {
+		if (backward && more) || (!backward && cursor != nil) {
+			page.Meta.PrevCursor = encodeTaskCursor(sort, fields, tasks[0], true)
+		}
+		if (!backward && more) || backward {
+			page.Meta.NextCursor = encodeTaskCursor(sort, fields, tasks[len(tasks)-1], false)
+		}
+	}
+
+	setCursorLinks(ctx, page.Meta.NextCursor, page.Meta.PrevCursor)
+
+	page.Tasks = tasks
+	ctx.JSON(http.StatusOK, page)
+}
+
+// keysetCondition selects the rows after values in the sort order (or
+// before them when backward), e.g. for priority desc, id desc:
+// priority < ? OR (priority = ? AND id < ?).
+func keysetCondition(fields []filter.SortField, values []any, backward bool) (string, []any) {
+
+	var alternatives []string
+	var args []any
+	for i, field := range fields {
+		var terms []string
+		for j := range i {
+			terms = append(terms, fields[j].Column+" = ?")
+			args = append(args, values[j])
+		}
+
+		op := ">"
+		if field.Desc != backward {
+			op = "<"
+		}
+		terms = append(terms, field.Column+" "+op+" ?")
+		args = append(args, values[i])
+
+		alternatives = append(alternatives, "("+strings.Join(terms, " AND ")+")")
+	}
+	return "(" + strings.Join(alternatives, " OR ") + ")", args
+}
+
+func encodeTaskCursor(sort string, fields []filter.SortField, task model.Task, backward bool) string {
+
+	cursor := taskCursor{Sort: sort, Backward: backward}
+	for _, field := range fields {
+		cursor.Values = append(cursor.Values, taskSortValue(task, field.Name))
+	}
+
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeTaskCursor checks that the cursor was made for this sort and turns
+// its values back into the column types.
+func decodeTaskCursor(raw, sort string, fields []filter.SortField) (*taskCursor, error) {
+
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+
+	var cursor taskCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || len(cursor.Values) != len(fields) {
+		return nil, errInvalidCursor
+	}
+	if cursor.Sort != sort {
+		return nil, errors.New("cursor was created for a different sort")
+	}
+
+	for i, field := range fields {
+		value, ok := cursorValue(cursor.Values[i], field.Kind)
+		if !ok {
+			return nil, errInvalidCursor
+		}
+		cursor.Values[i] = value
+	}
+	return &cursor, nil
+}
+
+func cursorValue(value any, kind filter.Kind) (any, bool) {
+
+	switch kind {
+	case filter.Int:
+		n, ok := value.(float64)
+		return int64(n), ok
+	case filter.Time:
+		s, ok := value.(string)
+		if !ok {
+			return nil, false
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		return t, err == nil
+	default:
+		s, ok := value.(string)
+		return s, ok
+	}
+}
+
+// taskSortValue returns the value of a sortable, non-null task field.
+func taskSortValue(task model.Task, name string) any {
+
+	switch name {
+	case "id":
+		return task.ID
+	case "title":
+		return task.Title
+	case "priority":
+		return task.Priority
+	case "status":
+		return task.Status
+	case "user_id":
+		return task.UserID
+	case "created_at":
+		return task.CreatedAt
+	case "updated_at":
+		return task.UpdatedAt
+	case "board_rank":
+		return task.BoardRank
+	}
+	return nil
+}
+
+// setCursorLinks adds an RFC 8288 Link header pointing at the neighbouring
+// pages, keeping every other query parameter.
+func setCursorLinks(ctx *gin.Context, next, prev string) {
+
+	var links []string
+	for _, link := range []struct{ rel, cursor string }{{"next", next}, {"prev", prev}} {
+		if link.cursor == "" {
+			continue
+		}
+		u := *ctx.Request.URL
+		query := u.Query()
+		query.Set("cursor", link.cursor)
+		u.RawQuery = query.Encode()
+		links = append(links, fmt.Sprintf(`<%s>; rel="%s"`, u.RequestURI(), link.rel))
+	}
+
+	if len(links) > 0 {
+		ctx.Header("Link", strings.Join(links, ", "))
+	}
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTasks_CursorPagination(t *testing.T) {
+	db := setupTestDB(t)
+	for i, priority := range []int{5, 3, 5, 8, 1, 5, 3} {
+		require.NoError(t, db.Create(&model.Task{Title: fmt.Sprintf("T%d", i+1), UserID: 1, Priority: priority}).Error)
+	}
+
+	fetch := func(cursor string, extra string) (TaskCursorPage, http.Header) {
+		query := "/api/task?limit=3&sort=priority:desc&cursor=" + url.QueryEscape(cursor) + extra
+		c, w := setupContext(http.MethodGet, query, "", 1)
+		GetTasks(db)(c)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var page TaskCursorPage
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		return page, w.Header()
+	}
+	titles := func(page TaskCursorPage) []string {
+		var out []string
+		for _, task := range page.Tasks {
+			out = append(out, task.Title)
+		}
+		return out
+	}
+
+	first, header := fetch("", "&include_total=true")
+	assert.Equal(t, []string{"T4", "T6", "T3"}, titles(first), "ties broken by id, descending like the sort")
+	require.NotNil(t, first.Meta.Total)
+	assert.Equal(t, int64(7), *first.Meta.Total)
+	assert.Empty(t, first.Meta.PrevCursor)
+	assert.Contains(t, header.Get("Link"), `rel="next"`)
+
+	// a task inserted at the front does not shift the following pages
+	require.NoError(t, db.Create(&model.Task{Title: "New", UserID: 1, Priority: 9}).Error)
+
+	second, header := fetch(first.Meta.NextCursor, "")
+	assert.Equal(t, []string{"T1", "T7", "T2"}, titles(second))
+	assert.Nil(t, second.Meta.Total, "total is opt-in")
+	assert.Contains(t, header.Get("Link"), `rel="prev"`)
+
+	third, _ := fetch(second.Meta.NextCursor, "")
+	assert.Equal(t, []string{"T5"}, titles(third))
+	assert.Empty(t, third.Meta.NextCursor)
+
+	back, _ := fetch(third.Meta.PrevCursor, "")
+	assert.Equal(t, []string{"T1", "T7", "T2"}, titles(back))
+
+	back, _ = fetch(back.Meta.PrevCursor, "")
+	assert.Equal(t, []string{"T4", "T6", "T3"}, titles(back))
+	require.NotEmpty(t, back.Meta.PrevCursor, "the new task is before it now")
+
+	front, _ := fetch(back.Meta.PrevCursor, "")
+	assert.Equal(t, []string{"New"}, titles(front))
+	assert.Empty(t, front.Meta.PrevCursor)
+}
+
+func TestGetTasks_CursorRejectsTamperingAndSortChange(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.Create(&[]model.Task{{Title: "A", UserID: 1}, {Title: "B", UserID: 1}}).Error)
+
+	c, w := setupContext(http.MethodGet, "/api/task?limit=1&cursor=", "", 1)
+	GetTasks(db)(c)
+	var page TaskCursorPage
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	require.NotEmpty(t, page.Meta.NextCursor)
+
+	for _, query := range []string{
+		"cursor=not-a-cursor",
+		"sort=priority:asc&cursor=" + page.Meta.NextCursor,
+		"sort=completed_at:desc&cursor=",
+	} {
+		c, w = setupContext(http.MethodGet, "/api/task?"+query, "", 1)
+		GetTasks(db)(c)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
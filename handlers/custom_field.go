@@ -0,0 +1,539 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Niraj1910/Task-REST-APIs/filter"
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/Niraj1910/Task-REST-APIs/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// maxCustomFieldsPerUser bounds the definitions, and so the fields a task
+// can carry.
+const maxCustomFieldsPerUser = 50
+
+// customFieldKey is the shape of a key: it is used as the JSON key of the
+// value and, as custom.<key>, in filters and sorts.
+var customFieldKey = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+type CustomFieldBody struct {
+	// Key names the field in task bodies and filters; it cannot be changed.
+	Key  string  `json:"key"`
+	Name *string `json:"name" binding:"omitempty,max=100"`
+	// Type is text, number, date, select, multi-select or user; it cannot be
+	// changed.
+	Type string `json:"type" binding:"omitempty,oneof=text number date select multi-select user"`
+	// Options are the choices of select and multi-select fields.
+	Options   []string `json:"options" binding:"omitempty,max=100,dive,max=100"`
+	ProjectID *uint    `json:"project_id"`
+}
+
+// CreateCustomField godoc
+// @Summary      Create a custom field
+// @Description  Defines an extra field for your tasks, e.g. {"key": "story_points", "name": "Story points", "type": "number"}.
+// @Description  Select and multi-select fields need options. Values are set with "custom_fields" on task create and update,
+// @Description  and filtered and sorted in GET /api/task as custom.<key>.
+// @Tags         Custom fields
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        body body handlers.CustomFieldBody true "Field definition"
+// @Success      201 {object} map[string]interface{} "Custom field"
+// @Failure      400 {object} map[string]string "Invalid field"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      409 {object} map[string]string "Key already used"
+// @Router       /api/custom-fields [post]
+func CreateCustomField(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		var input CustomFieldBody
+		err := ctx.ShouldBindJSON(&input)
+		if err == nil && (input.Name == nil || input.Type == "") {
+			err = errors.New("key, name and type are required")
+		}
+		if err == nil && !customFieldKey.MatchString(input.Key) {
+			err = errors.New("key must start with a lowercase letter and contain only lowercase letters, digits and underscores (at most 50)")
+		}
+		field := model.CustomField{UserID: userID, Key: input.Key, Type: input.Type}
+		if err == nil {
+			err = input.applyTo(&field)
+		}
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid input", "details": err.Error()})
+			return
+		}
+
+		var count int64
+		db.Model(&model.CustomField{}).Where("user_id = ?", userID).Count(&count)
+		if count >= maxCustomFieldsPerUser {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("You can define at most %d custom fields", maxCustomFieldsPerUser)})
+			return
+		}
+		var taken int64
+		db.Model(&model.CustomField{}).Where(map[string]any{"user_id": userID, "key": field.Key}).Count(&taken)
+		if taken > 0 {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "A custom field with this key exists"})
+			return
+		}
+
+		if err := db.Create(&field).Error; err != nil {
+			log.Error().Err(err).Uint("user_id", userID).Msg("Failed to create custom field")
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create custom field"})
+			return
+		}
+		ctx.JSON(http.StatusCreated, customFieldResponse(field))
+	}
+}
+
+// GetCustomFields godoc
+// @Summary      List custom fields
+// @Tags         Custom fields
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200 {object} map[string]interface{} "custom_fields"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Router       /api/custom-fields [get]
+func GetCustomFields(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		var fields []model.CustomField
+		if err := db.Where("user_id = ?", userID).Order("id").Find(&fields).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve custom fields"})
+			return
+		}
+
+		items := make([]gin.H, 0, len(fields))
+		for _, field := range fields {
+			items = append(items, customFieldResponse(field))
+		}
+		ctx.JSON(http.StatusOK, gin.H{"custom_fields": items})
+	}
+}
+
+// GetCustomField godoc
+// @Summary      Get a custom field
+// @Tags         Custom fields
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id path int true "Custom field ID"
+// @Success      200 {object} map[string]interface{} "Custom field"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      404 {object} map[string]string "Custom field not found"
+// @Router       /api/custom-fields/{id} [get]
+func GetCustomField(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		field, ok := findOwnCustomField(ctx, db)
+		if !ok {
+			return
+		}
+		ctx.JSON(http.StatusOK, customFieldResponse(field))
+	}
+}
+
+// UpdateCustomField godoc
+// @Summary      Update a custom field
+// @Description  Renames the field or changes the options of a select field. Options still used by a task cannot be removed.
+// @Tags         Custom fields
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id   path int true "Custom field ID"
+// @Param        body body handlers.CustomFieldBody true "Name and/or options"
+// @Success      200 {object} map[string]interface{} "Custom field"
+// @Failure      400 {object} map[string]string "Invalid field"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      404 {object} map[string]string "Custom field not found"
+// @Failure      409 {object} map[string]interface{} "Removed options are still in use"
+// @Router       /api/custom-fields/{id} [put]
+func UpdateCustomField(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		field, ok := findOwnCustomField(ctx, db)
+		if !ok {
+			return
+		}
+
+		var input CustomFieldBody
+		err := ctx.ShouldBindJSON(&input)
+		if err == nil && ((input.Key != "" && input.Key != field.Key) || (input.Type != "" && input.Type != field.Type)) {
+			err = errors.New("key and type cannot be changed, create a new field instead")
+		}
+		oldOptions := customFieldOptions(field)
+		if err == nil {
+			err = input.applyTo(&field)
+		}
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid input", "details": err.Error()})
+			return
+		}
+
+		var removed []any
+		for _, option := range oldOptions {
+			if !slices.Contains(customFieldOptions(field), option) {
+				removed = append(removed, option)
+			}
+		}
+		if len(removed) > 0 {
+			var inUse int64
+			condition := customFilterField(db, field).Column + " IN ?"
+			if field.Type == "multi-select" {
+				condition = customFilterField(db, field).Contains
+			}
+			db.Unscoped().Model(&model.Task{}).Where("user_id = ?", field.UserID).Where(condition, removed).Count(&inUse)
+			if inUse > 0 {
+				ctx.JSON(http.StatusConflict, gin.H{"error": "Removed options are still used by tasks", "details": removed})
+				return
+			}
+		}
+
+		if err := db.Model(&field).Select("name", "options").Updates(&field).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update custom field"})
+			return
+		}
+		ctx.JSON(http.StatusOK, customFieldResponse(field))
+	}
+}
+
+// DeleteCustomField godoc
+// @Summary      Delete a custom field
+// @Description  Deletes the field and its values on all your tasks, including those in the trash.
+// @Tags         Custom fields
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id path int true "Custom field ID"
+// @Success      200 {object} map[string]string "Custom field deleted"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      404 {object} map[string]string "Custom field not found"
+// @Router       /api/custom-fields/{id} [delete]
+func DeleteCustomField(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		field, ok := findOwnCustomField(ctx, db)
+		if !ok {
+			return
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var tasks []model.Task
+			err := tx.Unscoped().Select("id", "custom_fields").
+				Where("user_id = ?", field.UserID).
+				Where(customFilterField(tx, field).Column + " IS NOT NULL").
+				Find(&tasks).Error
+			if err != nil {
+				return err
+			}
+			for _, task := range tasks {
+				delete(task.CustomFields, field.Key)
+				err := tx.Unscoped().Model(&model.Task{}).Where("id = ?", task.ID).UpdateColumns(map[string]any{"custom_fields": task.CustomFields, "version": nextVersion}).Error
+				if err != nil {
+					return err
+				}
+			}
+			// a hard delete frees the key for a new field
+			return tx.Unscoped().Delete(&field).Error
+		})
+		if err != nil {
+			log.Error().Err(err).Uint("custom_field_id", field.ID).Msg("Failed to delete custom field")
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete custom field"})
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{"message": "Custom field deleted"})
+	}
+}
+
+// applyTo validates the name and options and copies them onto field, whose
+// type must already be set.
+func (b CustomFieldBody) applyTo(field *model.CustomField) error {
+
+	if b.ProjectID != nil {
+		return errors.New("projects are not available, custom fields belong to your own tasks")
+	}
+	if b.Name != nil {
+		name := strings.TrimSpace(*b.Name)
+		if name == "" {
+			return errors.New("name must not be empty")
+		}
+		field.Name = name
+	}
+
+	choices := field.Type == "select" || field.Type == "multi-select"
+	if b.Options != nil && !choices {
+		return fmt.Errorf("%s fields have no options", field.Type)
+	}
+	if b.Options != nil {
+		for i, option := range b.Options {
+			if strings.TrimSpace(option) == "" {
+				return errors.New("options must not be empty")
+			}
+			if slices.Contains(b.Options[:i], option) {
+				return fmt.Errorf("option %q is listed twice", option)
+			}
+		}
+		options, err := json.Marshal(b.Options)
+		if err != nil {
+			return err
+		}
+		field.Options = string(options)
+	}
+	if choices && len(customFieldOptions(*field)) == 0 {
+		return fmt.Errorf("%s fields need options", field.Type)
+	}
+	return nil
+}
+
+// mergeCustomFields checks the custom field values of a task body against
+// the task owner's fields and merges them into current. A null, empty
+// string or empty list removes a value.
+func mergeCustomFields(db *gorm.DB, ownerID uint, current model.CustomFieldValues, input map[string]any) (model.CustomFieldValues, error) {
+
+	if len(input) == 0 {
+		return current, nil
+	}
+
+	var fields []model.CustomField
+	if err := db.Where("user_id = ?", ownerID).Find(&fields).Error; err != nil {
+		return nil, err
+	}
+
+	merged := maps.Clone(current)
+	if merged == nil {
+		merged = model.CustomFieldValues{}
+	}
+	for _, key := range slices.Sorted(maps.Keys(input)) {
+		i := slices.IndexFunc(fields, func(field model.CustomField) bool { return field.Key == key })
+		if i < 0 {
+			return nil, fmt.Errorf("unknown custom field %q", key)
+		}
+		value, err := customFieldValue(db, fields[i], input[key])
+		if err != nil {
+			return nil, fmt.Errorf("custom field %q %w", key, err)
+		}
+		if value == nil {
+			delete(merged, key)
+		} else {
+			merged[key] = value
+		}
+	}
+	return merged, nil
+}
+
+// customFieldValue validates a value and returns it as it is stored, or nil
+// when it removes the value.
+func customFieldValue(db *gorm.DB, field model.CustomField, value any) (any, error) {
+
+	if value == nil {
+		return nil, nil
+	}
+
+	switch field.Type {
+	case "number":
+		n, ok := value.(float64)
+		if !ok {
+			return nil, errors.New("must be a number")
+		}
+		return n, nil
+
+	case "user":
+		n, ok := value.(float64)
+		if !ok || n < 1 || n != math.Trunc(n) || n > math.MaxUint32 {
+			return nil, errors.New("must be a user ID")
+		}
+		var count int64
+		db.Model(&model.User{}).Where("id = ?", uint(n)).Count(&count)
+		if count == 0 {
+			return nil, fmt.Errorf("refers to user %d, who does not exist", uint(n))
+		}
+		return uint(n), nil
+
+	case "multi-select":
+		list, ok := value.([]any)
+		if !ok {
+			return nil, errors.New("must be a list of options")
+		}
+		options := customFieldOptions(field)
+		var chosen []string
+		for _, item := range list {
+			option, ok := item.(string)
+			if !ok || !slices.Contains(options, option) {
+				return nil, fmt.Errorf("must only hold %s", strings.Join(options, ", "))
+			}
+			if slices.Contains(chosen, option) {
+				return nil, fmt.Errorf("holds %q twice", option)
+			}
+			chosen = append(chosen, option)
+		}
+		if len(chosen) == 0 {
+			return nil, nil
+		}
+		// stored in the order of the options so equal sets look equal
+		slices.SortFunc(chosen, func(a, b string) int { return slices.Index(options, a) - slices.Index(options, b) })
+		return chosen, nil
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return nil, errors.New("must be a string")
+	}
+	if s == "" {
+		return nil, nil
+	}
+	switch field.Type {
+	case "date":
+		t, err := time.Parse(time.DateOnly, s)
+		if err != nil {
+			return nil, errors.New("must be a date (YYYY-MM-DD)")
+		}
+		return t.Format(time.DateOnly), nil
+	case "select":
+		if options := customFieldOptions(field); !slices.Contains(options, s) {
+			return nil, fmt.Errorf("must be one of %s", strings.Join(options, ", "))
+		}
+	default:
+		if utf8.RuneCountInString(s) > 1000 {
+			return nil, errors.New("must be at most 1000 characters")
+		}
+	}
+	return s, nil
+}
+
+// taskFieldsFor is taskFields plus the custom fields of a user, named
+// custom.<key>.
+func taskFieldsFor(db *gorm.DB, userID uint) (filter.Fields, error) {
+
+	var custom []model.CustomField
+	if err := db.Where("user_id = ?", userID).Find(&custom).Error; err != nil {
+		return nil, err
+	}
+	if len(custom) == 0 {
+		return taskFields, nil
+	}
+
+	fields := maps.Clone(taskFields)
+	for _, field := range custom {
+		fields["custom."+field.Key] = customFilterField(db, field)
+	}
+	return fields, nil
+}
+
+// customFilterField describes a custom field to the filter package. The key
+// is safe to put into SQL because it matched customFieldKey when the field
+// was created. Values of the wrong JSON type read as null, so tasks of other
+// owners in shared lists never break a cast.
+func customFilterField(db *gorm.DB, field model.CustomField) filter.Field {
+
+	key := field.Key
+	f := filter.Field{Nullable: true}
+	if field.Type == "select" || field.Type == "multi-select" {
+		f.Values = customFieldOptions(field)
+	}
+
+	if db.Dialector.Name() == "postgres" {
+		// = and IN become containment tests, which idx_tasks_custom_fields serves
+		f.Equals = func(v any) (string, any) {
+			if field.Type == "multi-select" {
+				v = []any{v}
+			}
+			doc, _ := json.Marshal(map[string]any{key: v})
+			return "tasks.custom_fields @> ?::jsonb", string(doc)
+		}
+		value := "tasks.custom_fields->'" + key + "'"
+		text := "(tasks.custom_fields->>'" + key + "')"
+		switch field.Type {
+		case "number":
+			f.Kind, f.Column = filter.Number, "(CASE WHEN jsonb_typeof("+value+") = 'number' THEN "+text+"::numeric END)"
+		case "user":
+			f.Kind, f.Column = filter.Int, "(CASE WHEN jsonb_typeof("+value+") = 'number' THEN "+text+"::bigint END)"
+		case "date":
+			f.Kind, f.Column = filter.Date, text
+		case "multi-select":
+			f.Kind, f.Column, f.NoSort = filter.List, "(CASE WHEN jsonb_typeof("+value+") = 'array' THEN "+value+" END)", true
+			f.Contains = "EXISTS (SELECT 1 FROM jsonb_array_elements_text(" + f.Column + ") AS element(value) WHERE element.value IN ?)"
+		default:
+			f.Kind, f.Column = filter.String, text
+		}
+		return f
+	}
+
+	f.Column = "json_extract(tasks.custom_fields, '$." + key + "')"
+	switch field.Type {
+	case "number":
+		f.Kind = filter.Number
+	case "user":
+		f.Kind = filter.Int
+	case "date":
+		f.Kind = filter.Date
+	case "multi-select":
+		f.Kind, f.NoSort = filter.List, true
+		f.Contains = "EXISTS (SELECT 1 FROM json_each(tasks.custom_fields, '$." + key + "') WHERE json_each.value IN ?)"
+	default:
+		f.Kind = filter.String
+	}
+	return f
+}
+
+func customFieldOptions(field model.CustomField) []string {
+	var options []string
+	json.Unmarshal([]byte(field.Options), &options)
+	return options
+}
+
+func findOwnCustomField(ctx *gin.Context, db *gorm.DB) (model.CustomField, bool) {
+
+	var field model.CustomField
+
+	fieldID, ok := parseIDParam(ctx, "id", "Custom field ID")
+	if !ok {
+		return field, false
+	}
+	userID, ok := utils.UserIDFromContext(ctx)
+	if !ok {
+		return field, false
+	}
+
+	err := db.Where("id = ? AND user_id = ?", fieldID, userID).First(&field).Error
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Custom field not found"})
+		return field, false
+	}
+	return field, true
+}
+
+func customFieldResponse(field model.CustomField) gin.H {
+
+	response := gin.H{
+		"id":         field.ID,
+		"key":        field.Key,
+		"name":       field.Name,
+		"type":       field.Type,
+		"created_at": field.CreatedAt,
+		"updated_at": field.UpdatedAt,
+	}
+	if field.Type == "select" || field.Type == "multi-select" {
+		response["options"] = customFieldOptions(field)
+	}
+	return response
+}
@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func createCustomField(t *testing.T, db *gorm.DB, userID uint, body string) gin.H {
+	t.Helper()
+	c, w := setupContext(http.MethodPost, "/api/custom-fields", body, userID)
+	CreateCustomField(db)(c)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var field gin.H
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &field))
+	return field
+}
+
+func TestCustomFields_Definitions(t *testing.T) {
+	db := setupTestDB(t)
+	user := model.User{Name: "owner", Email: "owner@example.com"}
+	require.NoError(t, db.Create(&user).Error)
+
+	for _, body := range []string{
+		`{"key": "Customer", "name": "Customer", "type": "text"}`,
+		`{"key": "customer", "name": "Customer", "type": "colour"}`,
+		`{"key": "customer", "type": "text"}`,
+		`{"key": "env", "name": "Environment", "type": "select"}`,
+		`{"key": "env", "name": "Environment", "type": "select", "options": ["dev", "dev"]}`,
+		`{"key": "points", "name": "Story points", "type": "number", "options": ["1"]}`,
+		`{"key": "customer", "name": "Customer", "type": "text", "project_id": 1}`,
+	} {
+		c, w := setupContext(http.MethodPost, "/api/custom-fields", body, user.ID)
+		CreateCustomField(db)(c)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+
+	env := createCustomField(t, db, user.ID, `{"key": "env", "name": "Environment", "type": "multi-select", "options": ["dev", "staging", "prod"]}`)
+	assert.Equal(t, []any{"dev", "staging", "prod"}, env["options"])
+
+	c, w := setupContext(http.MethodPost, "/api/custom-fields", `{"key": "env", "name": "Env", "type": "text"}`, user.ID)
+	CreateCustomField(db)(c)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	task := model.Task{Title: "Deploy", UserID: user.ID, CustomFields: model.CustomFieldValues{"env": []string{"prod"}}}
+	require.NoError(t, db.Create(&task).Error)
+
+	update := func(body string) int {
+		c, w := setupContext(http.MethodPut, "/", body, user.ID)
+		c.Params = gin.Params{{Key: "id", Value: fmt.Sprint(env["id"])}}
+		UpdateCustomField(db)(c)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusBadRequest, update(`{"type": "select"}`))
+	assert.Equal(t, http.StatusConflict, update(`{"options": ["dev", "staging"]}`), "prod is in use")
+	assert.Equal(t, http.StatusOK, update(`{"name": "Stage", "options": ["dev", "prod"]}`))
+
+	c, w = setupContext(http.MethodDelete, "/", "", user.ID)
+	c.Params = gin.Params{{Key: "id", Value: fmt.Sprint(env["id"])}}
+	DeleteCustomField(db)(c)
+	require.Equal(t, http.StatusOK, w.Code)
+	var stripped model.Task
+	require.NoError(t, db.First(&stripped, task.ID).Error)
+	assert.Empty(t, stripped.CustomFields, "values go with their field")
+	assert.EqualValues(t, 2, stripped.Version, "the ETag changes with them")
+
+	createCustomField(t, db, user.ID, `{"key": "env", "name": "Environment", "type": "text"}`)
+}
+
+func TestCustomFields_TaskValuesFilterAndSort(t *testing.T) {
+	db := setupTestDB(t)
+	users := []model.User{{Name: "owner", Email: "owner@example.com"}, {Name: "reviewer", Email: "reviewer@example.com"}}
+	require.NoError(t, db.Create(&users).Error)
+	owner := users[0].ID
+
+	createCustomField(t, db, owner, `{"key": "customer", "name": "Customer", "type": "text"}`)
+	createCustomField(t, db, owner, `{"key": "points", "name": "Story points", "type": "number"}`)
+	createCustomField(t, db, owner, `{"key": "launch", "name": "Launch", "type": "date"}`)
+	createCustomField(t, db, owner, `{"key": "size", "name": "Size", "type": "select", "options": ["S", "M", "L"]}`)
+	createCustomField(t, db, owner, `{"key": "env", "name": "Environment", "type": "multi-select", "options": ["dev", "staging", "prod"]}`)
+	createCustomField(t, db, owner, `{"key": "reviewer", "name": "Reviewer", "type": "user"}`)
+
+	for _, fields := range []string{
+		`{"team": "x"}`,
+		`{"points": "three"}`,
+		`{"launch": "next week"}`,
+		`{"size": "XL"}`,
+		`{"env": ["prod", "prod"]}`,
+		`{"env": "prod"}`,
+		`{"reviewer": 999}`,
+		`{"reviewer": 1.5}`,
+	} {
+		c, w := setupContext(http.MethodPost, "/api/task/new", `{"title": "Invalid", "description": "x", "custom_fields": `+fields+`}`, owner)
+		CreateTask(db)(c)
+		assert.Equal(t, http.StatusBadRequest, w.Code, fields)
+	}
+
+	create := func(title, fields string) uint {
+		c, w := setupContext(http.MethodPost, "/api/task/new", `{"title": "`+title+`", "description": "x", "custom_fields": `+fields+`}`, owner)
+		CreateTask(db)(c)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var created struct{ ID uint }
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		return created.ID
+	}
+	acme := create("Acme rollout", fmt.Sprintf(`{"customer": "ACME Corp", "points": 5, "launch": "2026-11-02", "env": ["prod", "dev"], "reviewer": %d}`, users[1].ID))
+	create("Globex fix", `{"customer": "Globex", "points": 2.5, "size": "S", "env": ["staging"]}`)
+	create("Internal chore", `{"points": 8}`)
+	create("No fields", `{}`)
+
+	c, w := setupContext(http.MethodPut, "/", `{"custom_fields": {"customer": null, "size": "L"}}`, owner)
+	c.Params = taskParam(acme)
+	UpdateTask(db)(c)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var updated struct {
+		CustomFields map[string]any `json:"custom_fields"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &updated))
+	assert.Equal(t, map[string]any{
+		"points": 5.0, "launch": "2026-11-02", "size": "L", "env": []any{"dev", "prod"}, "reviewer": float64(users[1].ID),
+	}, updated.CustomFields, "merged, with options in their defined order")
+
+	list := func(filter, sort string) ([]string, int) {
+		query := url.Values{"filter": {filter}, "sort": {sort}}
+		c, w := setupContext(http.MethodGet, "/api/task?"+query.Encode(), "", owner)
+		GetTasks(db)(c)
+		var page struct{ Tasks []model.Task }
+		json.Unmarshal(w.Body.Bytes(), &page)
+		return taskTitles(page.Tasks), w.Code
+	}
+
+	cases := []struct {
+		filter, sort string
+		titles       []string
+	}{
+		{"custom.points >= 2.5", "custom.points:desc", []string{"Internal chore", "Acme rollout", "Globex fix"}},
+		{"custom.env = prod OR custom.env IN (staging)", "title:asc", []string{"Acme rollout", "Globex fix"}},
+		{"custom.env != dev AND custom.env IS NOT NULL", "", []string{"Globex fix"}},
+		{`custom.customer ~ "glob"`, "", []string{"Globex fix"}},
+		{"custom.launch < 2026-11-03 AND custom.size = L", "", []string{"Acme rollout"}},
+		{fmt.Sprintf("custom.reviewer = %d", users[1].ID), "", []string{"Acme rollout"}},
+		{"custom.points IS NULL", "", []string{"No fields"}},
+	}
+	for _, tc := range cases {
+		titles, code := list(tc.filter, tc.sort)
+		require.Equal(t, http.StatusOK, code, tc.filter)
+		assert.Equal(t, tc.titles, titles, tc.filter)
+	}
+
+	for _, tc := range []struct{ filter, sort string }{
+		{"custom.size = XL", ""},
+		{"custom.team = x", ""},
+		{"", "custom.env:asc"},
+	} {
+		_, code := list(tc.filter, tc.sort)
+		assert.Equal(t, http.StatusBadRequest, code, tc)
+	}
+
+	// custom fields belong to their owner
+	c, w = setupContext(http.MethodGet, "/api/task?filter=custom.points+%3E+1", "", users[1].ID)
+	GetTasks(db)(c)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestCustomFields_PatchExportBulkAndSearch(t *testing.T) {
+	db := setupTestDB(t)
+	owner := model.User{Name: "owner", Email: "owner@example.com"}
+	require.NoError(t, db.Create(&owner).Error)
+	require.Equal(t, uint(1), owner.ID, "patchTask acts as user 1")
+
+	createCustomField(t, db, owner.ID, `{"key": "points", "name": "Story points", "type": "number"}`)
+	createCustomField(t, db, owner.ID, `{"key": "env", "name": "Environment", "type": "multi-select", "options": ["dev", "prod"]}`)
+	tasks := []model.Task{
+		{Title: "Deploy the release", UserID: owner.ID, CustomFields: model.CustomFieldValues{"points": 5, "env": []string{"prod"}}},
+		{Title: "Deploy the docs", UserID: owner.ID, CustomFields: model.CustomFieldValues{"points": 1}},
+	}
+	require.NoError(t, db.Create(&tasks).Error)
+
+	w := patchTask(t, db, tasks[1].ID, mergePatchType, `{"custom_fields": {"points": 3}}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = patchTask(t, db, tasks[0].ID, jsonPatchType, `[{"op": "remove", "path": "/custom_fields/env"}]`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = patchTask(t, db, tasks[0].ID, mergePatchType, `{"custom_fields": {"points": "many"}}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	var stored []model.Task
+	db.Order("id").Find(&stored)
+	assert.Equal(t, model.CustomFieldValues{"points": 5.0}, stored[0].CustomFields)
+	assert.Equal(t, model.CustomFieldValues{"points": 3.0}, stored[1].CustomFields)
+
+	query := url.Values{"format": {"json"}, "filter": {"custom.points >= 3"}, "sort": {"custom.points:asc"}}
+	c, w := setupContext(http.MethodGet, "/api/task/export?"+query.Encode(), "", owner.ID)
+	ExportTasks(db)(c)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var exported []ExportedTask
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &exported))
+	require.Len(t, exported, 2)
+	assert.Equal(t, "Deploy the docs", exported[0].Title)
+
+	c, w = setupContext(http.MethodPost, "/api/task/bulk", `{"action": "set_priority", "priority": 9, "filter": {"expression": "custom.points > 4"}}`, owner.ID)
+	BulkTasks(db)(c)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"matched":1`)
+
+	c, w = setupContext(http.MethodGet, "/api/task/search?q=deploy&filter="+url.QueryEscape("custom.points < 4"), "", owner.ID)
+	SearchTasks(db)(c)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), "Deploy the docs")
+	assert.NotContains(t, w.Body.String(), "Deploy the release")
+
+	c, w = setupContext(http.MethodGet, "/api/task?cursor=&sort=custom.points:desc", "", owner.ID)
+	GetTasks(db)(c)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "cursor pagination cannot sort by custom.points")
+}
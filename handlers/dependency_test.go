@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func addDependency(t *testing.T, handler gin.HandlerFunc, taskID, blockerID uint) int {
+	c, w := setupContext(http.MethodPost, "/api/task/dependencies", fmt.Sprintf(`{"blocked_by_id": %d}`, blockerID), 1)
+	c.Params = gin.Params{{Key: "id", Value: fmt.Sprint(taskID)}}
+	handler(c)
+	return w.Code
+}
+
+func TestAddDependency_RejectsCycle(t *testing.T) {
+	db := setupTestDB(t)
+	a := model.Task{Title: "Design", UserID: 1}
+	b := model.Task{Title: "Build", UserID: 1}
+	c := model.Task{Title: "Ship", UserID: 1}
+	require.NoError(t, db.Create(&[]*model.Task{&a, &b, &c}).Error)
+
+	handler := AddDependency(db)
+	assert.Equal(t, http.StatusCreated, addDependency(t, handler, b.ID, a.ID))
+	assert.Equal(t, http.StatusCreated, addDependency(t, handler, c.ID, b.ID))
+	assert.Equal(t, http.StatusConflict, addDependency(t, handler, c.ID, b.ID), "duplicate")
+	assert.Equal(t, http.StatusConflict, addDependency(t, handler, a.ID, c.ID), "a <- c closes a -> b -> c")
+	assert.Equal(t, http.StatusBadRequest, addDependency(t, handler, a.ID, a.ID))
+
+	var count int64
+	db.Model(&model.TaskDependency{}).Count(&count)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestUpdateTask_CompletionRefusedWhileBlocked(t *testing.T) {
+	db := setupTestDB(t)
+	blocker := model.Task{Title: "Design", UserID: 1}
+	task := model.Task{Title: "Build", UserID: 1}
+	require.NoError(t, db.Create(&[]*model.Task{&blocker, &task}).Error)
+	require.NoError(t, db.Create(&model.TaskDependency{TaskID: task.ID, BlockedByID: blocker.ID}).Error)
+
+	complete := func(body string) int {
+		c, w := setupContext(http.MethodPut, "/api/task/2", body, 1)
+		c.Params = gin.Params{{Key: "id", Value: fmt.Sprint(task.ID)}}
+		UpdateTask(db)(c)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusConflict, complete(`{"status": "completed"}`))
+	assert.Equal(t, http.StatusOK, complete(`{"status": "completed", "force": true}`))
+
+	var updated model.Task
+	db.First(&updated, task.ID)
+	assert.Equal(t, "completed", updated.Status)
+	assert.NotNil(t, updated.CompletedAt)
+}
+
+func TestGetTasks_BlockedFilterAndTopologicalOrder(t *testing.T) {
+	db := setupTestDB(t)
+	ship := model.Task{Title: "Ship", UserID: 1, Priority: 9}
+	build := model.Task{Title: "Build", UserID: 1, Priority: 5}
+	design := model.Task{Title: "Design", UserID: 1, Priority: 1}
+	docs := model.Task{Title: "Docs", UserID: 1, Priority: 3}
+	require.NoError(t, db.Create(&[]*model.Task{&ship, &build, &design, &docs}).Error)
+	require.NoError(t, db.Create(&[]model.TaskDependency{
+		{TaskID: ship.ID, BlockedByID: build.ID},
+		{TaskID: build.ID, BlockedByID: design.ID},
+	}).Error)
+
+	titles := func(url string) []string {
+		c, w := setupContext(http.MethodGet, url, "", 1)
+		GetTasks(db)(c)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp struct{ Tasks []model.Task }
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		var out []string
+		for _, task := range resp.Tasks {
+			out = append(out, task.Title)
+		}
+		return out
+	}
+
+	assert.ElementsMatch(t, []string{"Ship", "Build"}, titles("/api/task?blocked=true"))
+	assert.ElementsMatch(t, []string{"Design", "Docs"}, titles("/api/task?blocked=false"))
+	assert.Equal(t, []string{"Docs", "Design", "Build", "Ship"}, titles("/api/task?sort=topological"))
+}
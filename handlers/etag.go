@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// errVersionConflict aborts a conditional write whose version check failed.
+var errVersionConflict = errors.New("task version changed")
+
+// nextVersion is added to every update of a task so that its ETag changes.
+var nextVersion = gorm.Expr("version + 1")
+
+// taskETag is the entity tag of a task: its ID and version.
+func taskETag(task model.Task) string {
+	return fmt.Sprintf(`"%d-%d"`, task.ID, task.Version)
+}
+
+// etagMatches reports whether an If-Match or If-None-Match header lists the
+// ETag. "*" matches any existing task. If-None-Match compares weakly, by
+// value; If-Match compares strongly, so weak tags never match there.
+func etagMatches(header, etag string, weak bool) bool {
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIfMatch answers 412 when the request carries an If-Match header that
+// does not match the current task. It reports whether the request may go on.
+// Callers must still make the write conditional on task.Version.
+func checkIfMatch(ctx *gin.Context, task model.Task) bool {
+
+	header := ctx.GetHeader("If-Match")
+	if header == "" || etagMatches(header, taskETag(task), false) {
+		return true
+	}
+	preconditionFailed(ctx)
+	return false
+}
+
+// conditionalVersion limits a write to the version the If-Match header was
+// checked against, so a concurrent update in between makes it affect no rows.
+func conditionalVersion(ctx *gin.Context, query *gorm.DB, task model.Task) *gorm.DB {
+	if ctx.GetHeader("If-Match") == "" {
+		return query
+	}
+	return query.Where("version = ?", task.Version)
+}
+
+// missedWrite explains a conditional write that affected no rows: the
+// version checked against If-Match changed, or without one the task is gone.
+func missedWrite(ctx *gin.Context) error {
+	if ctx.GetHeader("If-Match") != "" {
+		return errVersionConflict
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func preconditionFailed(ctx *gin.Context) {
+	ctx.JSON(http.StatusPreconditionFailed, gin.H{
+		"error":   "Task was modified by someone else",
+		"details": "fetch the task again and retry with its current ETag",
+	})
+}
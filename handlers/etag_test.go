@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestTaskETag_ConditionalRequests(t *testing.T) {
+	db := setupTestDB(t)
+	task := model.Task{Title: "Write report", UserID: 1}
+	require.NoError(t, db.Create(&task).Error)
+	id := gin.Params{{Key: "id", Value: fmt.Sprint(task.ID)}}
+
+	request := func(handler gin.HandlerFunc, method, body string, header map[string]string) (int, string) {
+		c, w := setupContext(method, "/api/task/"+fmt.Sprint(task.ID), body, 1)
+		c.Params = id
+		for k, v := range header {
+			c.Request.Header.Set(k, v)
+		}
+		handler(c)
+		return w.Code, w.Header().Get("ETag")
+	}
+
+	code, etag := request(GetTaskByID(db), http.MethodGet, "", nil)
+	require.Equal(t, http.StatusOK, code)
+	require.NotEmpty(t, etag)
+
+	code, _ = request(GetTaskByID(db), http.MethodGet, "", map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusNotModified, code)
+
+	// two tabs hold the same ETag: the first write wins, the second gets 412
+	code, newETag := request(UpdateTask(db), http.MethodPut, `{"title": "Write the report"}`, map[string]string{"If-Match": etag})
+	require.Equal(t, http.StatusOK, code)
+	assert.NotEqual(t, etag, newETag)
+
+	code, _ = request(UpdateTask(db), http.MethodPut, `{"title": "Write a report"}`, map[string]string{"If-Match": etag})
+	assert.Equal(t, http.StatusPreconditionFailed, code)
+
+	code, _ = request(DeleteTask(db), http.MethodDelete, "", map[string]string{"If-Match": etag})
+	assert.Equal(t, http.StatusPreconditionFailed, code)
+
+	var stored model.Task
+	require.NoError(t, db.First(&stored, task.ID).Error)
+	assert.Equal(t, "Write the report", stored.Title)
+	assert.Equal(t, uint(2), stored.Version)
+
+	code, _ = request(GetTaskByID(db), http.MethodGet, "", map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusOK, code, "the cached copy is stale")
+
+	code, _ = request(DeleteTask(db), http.MethodDelete, "", map[string]string{"If-Match": newETag})
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func TestETagComparison(t *testing.T) {
+	assert.True(t, etagMatches(`W/"1-2"`, `"1-2"`, true))
+	assert.False(t, etagMatches(`W/"1-2"`, `"1-2"`, false), "If-Match compares strongly")
+	assert.True(t, etagMatches(`"1-1", "1-2"`, `"1-2"`, false))
+
+	c, _ := setupContext(http.MethodPut, "/", "", 1)
+	assert.ErrorIs(t, missedWrite(c), gorm.ErrRecordNotFound, "without a precondition the task is gone")
+	c.Request.Header.Set("If-Match", `"1-2"`)
+	assert.ErrorIs(t, missedWrite(c), errVersionConflict)
+}
+
+func TestTaskVersion_BumpedByOtherWrites(t *testing.T) {
+	db := setupTestDB(t)
+	owner := model.User{Name: "Owner", Email: "owner@example.com"}
+	require.NoError(t, db.Create(&owner).Error)
+	task := model.Task{Title: "Write report", UserID: owner.ID}
+	require.NoError(t, db.Create(&task).Error)
+
+	c, w := setupContext(http.MethodPut, "/api/task/1/assignee", fmt.Sprintf(`{"assignee_id": %d}`, owner.ID), owner.ID)
+	c.Params = gin.Params{{Key: "id", Value: fmt.Sprint(task.ID)}}
+	AssignTask(db)(c)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	runBulk(t, db, fmt.Sprintf(`{"ids": [%d], "action": "set_priority", "priority": 4}`, task.ID), owner.ID)
+
+	var stored model.Task
+	require.NoError(t, db.First(&stored, task.ID).Error)
+	assert.Equal(t, uint(3), stored.Version)
+}
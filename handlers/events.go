@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/Niraj1910/Task-REST-APIs/events"
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/Niraj1910/Task-REST-APIs/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// Task event types on the stream.
+const (
+	eventTaskCreated = "task.created"
+	eventTaskUpdated = "task.updated"
+	eventTaskDeleted = "task.deleted"
+)
+
+// heartbeatInterval keeps idle streams from being closed by proxies.
+var heartbeatInterval = 15 * time.Second
+
+// currentBroker carries the task events; main swaps in the Postgres broker
+// so that all instances see each other's changes.
+var currentBroker atomic.Pointer[events.Broker]
+
+func init() {
+	SetEventBroker(events.NewHub(events.DefaultReplaySize))
+}
+
+// SetEventBroker sets the broker task events are published to and streamed from.
+func SetEventBroker(broker events.Broker) {
+	currentBroker.Store(&broker)
+}
+
+func eventBroker() events.Broker {
+	return *currentBroker.Load()
+}
+
+// StreamEvents godoc
+// @Summary      Stream task changes
+// @Description  Server-Sent Events stream of task.created, task.updated and task.deleted events for the tasks the user
+// @Description  created or is assigned to. The data of created and updated events is the task, of deleted events its
+// @Description  id. On reconnect the Last-Event-ID header (or last_event_id) replays what was missed; if that is no
+// @Description  longer possible a "reset" event tells the client to reload. Comments are sent as heartbeats.
+// @Tags         Events
+// @Security     BearerAuth
+// @Produce      text/event-stream
+// @Param        Last-Event-ID header string false "ID of the last event received"
+// @Param        last_event_id query  string false "Same as Last-Event-ID, for the first connection"
+// @Success      200 {string} string "Event stream"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Router       /api/events [get]
+func StreamEvents() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		lastEventID := ctx.GetHeader("Last-Event-ID")
+		if lastEventID == "" {
+			lastEventID = ctx.Query("last_event_id")
+		}
+
+		sub := eventBroker().Subscribe(userID, lastEventID)
+		defer sub.Close()
+
+		ctx.Header("Content-Type", "text/event-stream")
+		ctx.Header("Cache-Control", "no-cache")
+		ctx.Header("Connection", "keep-alive")
+		ctx.Header("X-Accel-Buffering", "no")
+		ctx.Status(http.StatusOK)
+
+		w := ctx.Writer
+		if sub.Missed {
+			fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+		}
+		for _, event := range sub.Replay {
+			writeSSE(w, event)
+		}
+		w.Flush()
+
+		heartbeat := time.NewTicker(heartbeatInterval)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case <-ctx.Request.Context().Done():
+				return
+			case event, ok := <-sub.Events:
+				if !ok {
+					// fell behind; the client reconnects and replays
+					return
+				}
+				if event.Type == eventPresence {
+					continue
+				}
+				writeSSE(w, event)
+				w.Flush()
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": heartbeat\n\n")
+				w.Flush()
+			}
+		}
+	}
+}
+
+func writeSSE(w gin.ResponseWriter, event events.Event) {
+	fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+}
+
+// publishTaskEvent streams a committed change to the task's owner and
+// assignee. A failure only costs the live update.
+func publishTaskEvent(eventType string, task model.Task) {
+	users := []uint{task.UserID}
+	if task.AssigneeID != nil && *task.AssigneeID != task.UserID {
+		users = append(users, *task.AssigneeID)
+	}
+	publishTaskEventTo(eventType, task, users)
+}
+
+// publishTaskEventTo streams a task event to the given users.
+func publishTaskEventTo(eventType string, task model.Task, users []uint) {
+
+	var data any = taskResponse(task)
+	if eventType == eventTaskDeleted {
+		data = gin.H{"id": task.ID}
+	}
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+
+	publishCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err = eventBroker().Publish(publishCtx, events.Event{Type: eventType, UserIDs: users, Data: payload})
+	if err != nil {
+		log.Error().Err(err).Uint("task_id", task.ID).Str("type", eventType).Msg("Failed to publish task event")
+	}
+}
+
+// publishTaskEvents loads the tasks, trashed ones included, and publishes
+// the event for each.
+func publishTaskEvents(db *gorm.DB, eventType string, taskIDs []uint) {
+
+	if len(taskIDs) == 0 {
+		return
+	}
+
+	var tasks []model.Task
+	if err := db.Unscoped().Where("id IN ?", taskIDs).Order("id").Find(&tasks).Error; err != nil {
+		log.Error().Err(err).Str("type", eventType).Msg("Failed to load tasks for events")
+		return
+	}
+	for _, task := range tasks {
+		publishTaskEvent(eventType, task)
+	}
+}
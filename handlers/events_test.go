@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Niraj1910/Task-REST-APIs/events"
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// eventStream connects to the stream as the user and returns a function
+// reading the next event or heartbeat block.
+func eventStream(t *testing.T, userID uint, lastEventID string) func() string {
+	t.Helper()
+
+	router := gin.New()
+	router.GET("/api/events", func(c *gin.Context) { c.Set("user_id", userID) }, StreamEvents())
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/events", nil)
+	require.NoError(t, err)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(resp.Body)
+		var block []string
+		for scanner.Scan() {
+			if scanner.Text() != "" {
+				block = append(block, scanner.Text())
+				continue
+			}
+			select {
+			case lines <- strings.Join(block, "\n"):
+			case <-ctx.Done():
+				return
+			}
+			block = nil
+		}
+	}()
+
+	return func() string {
+		select {
+		case block := <-lines:
+			return block
+		case <-time.After(2 * time.Second):
+			t.Fatal("no event received")
+			return ""
+		}
+	}
+}
+
+func TestStreamEvents_TaskChangesAndResume(t *testing.T) {
+	db := setupTestDB(t)
+	SetEventBroker(events.NewHub(10))
+	t.Cleanup(func() { SetEventBroker(events.NewHub(events.DefaultReplaySize)) })
+	require.NoError(t, db.Create(&model.User{Name: "owner", Email: "owner@example.com"}).Error)
+
+	next := eventStream(t, 1, "")
+
+	c, w := setupContext(http.MethodPost, "/api/task/new", `{"title":"Write report","description":"quarterly"}`, 1)
+	CreateTask(db)(c)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	created := next()
+	assert.Contains(t, created, "id: 1\nevent: task.created\ndata: {")
+	assert.Contains(t, created, `"title":"Write report"`)
+
+	c, w = setupContext(http.MethodPut, "/", `{"title":"Write the report"}`, 1)
+	c.Params = taskParam(1)
+	UpdateTask(db)(c)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	c, w = setupContext(http.MethodDelete, "/", "", 1)
+	c.Params = taskParam(1)
+	DeleteTask(db)(c)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// a client that reconnects after the first event gets the rest
+	resumed := eventStream(t, 1, "1")
+	assert.Contains(t, resumed(), "id: 2\nevent: task.updated")
+	assert.Equal(t, "id: 3\nevent: task.deleted\ndata: {\"id\":1}", resumed())
+
+	assert.Equal(t, "event: reset\ndata: {}", eventStream(t, 1, "unknown")())
+}
+
+func TestStreamEvents_Heartbeats(t *testing.T) {
+	SetEventBroker(events.NewHub(10))
+	t.Cleanup(func() { SetEventBroker(events.NewHub(events.DefaultReplaySize)) })
+	// set before any stream reads it
+	heartbeatInterval = 10 * time.Millisecond
+	t.Cleanup(func() { heartbeatInterval = 15 * time.Second })
+
+	next := eventStream(t, 1, "")
+	assert.Equal(t, ": heartbeat", next())
+	assert.Equal(t, ": heartbeat", next())
+}
+
+func TestStreamEvents_AssigneesSeeTasksComeAndGo(t *testing.T) {
+	db := setupTestDB(t)
+	SetEventBroker(events.NewHub(10))
+	t.Cleanup(func() { SetEventBroker(events.NewHub(events.DefaultReplaySize)) })
+	users := []model.User{{Name: "owner", Email: "owner@example.com"}, {Name: "first", Email: "first@example.com"}, {Name: "second", Email: "second@example.com"}}
+	require.NoError(t, db.Create(&users).Error)
+	task := model.Task{Title: "Write report", UserID: 1}
+	require.NoError(t, db.Create(&task).Error)
+
+	first := eventStream(t, 2, "")
+	second := eventStream(t, 3, "")
+
+	for _, assignee := range []uint{2, 3} {
+		c, w := setupContext(http.MethodPut, "/", fmt.Sprintf(`{"assignee_id": %d}`, assignee), 1)
+		c.Params = taskParam(task.ID)
+		AssignTask(db)(c)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+
+	assert.Contains(t, first(), "event: task.updated")
+	assert.Contains(t, first(), "event: task.deleted", "reassigned away")
+	assert.Contains(t, second(), "event: task.updated")
+}
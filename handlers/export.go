@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/Niraj1910/Task-REST-APIs/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// exportColumns are the columns of a CSV export, in order. Imports accept
+// the same names.
+var exportColumns = []string{"id", "title", "description", "priority", "status", "assignee_id", "created_at", "updated_at", "completed_at", "due_at"}
+
+// ExportedTask is one task in a JSON or NDJSON export.
+type ExportedTask struct {
+	ID          uint       `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Priority    int        `json:"priority"`
+	Status      string     `json:"status"`
+	AssigneeID  *uint      `json:"assignee_id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	DueAt       *time.Time `json:"due_at"`
+}
+
+// ExportTasks godoc
+// @Summary      Export tasks
+// @Description  Streams the user's tasks as CSV, a JSON array or NDJSON (one object per line).
+// @Description  Accepts the same status, blocked, filter and sort parameters as GET /api/task.
+// @Tags         Tasks
+// @Security     BearerAuth
+// @Produce      text/csv
+// @Produce      json
+// @Produce      application/x-ndjson
+// @Param        format query string false "csv (default), json or ndjson"
+// @Param        status query string false "Filter by status"
+// @Param        filter query string false "Filter expression, as for GET /api/task"
+// @Param        sort   query string false "Sort, as for GET /api/task (default created_at:asc)"
+// @Success      200 {array} handlers.ExportedTask
+// @Failure      400 {object} map[string]string "Invalid format, filter or sort"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Router       /api/task/export [get]
+func ExportTasks(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		format := ctx.DefaultQuery("format", "csv")
+		contentType, ok := map[string]string{
+			"csv":    "text/csv; charset=utf-8",
+			"json":   "application/json",
+			"ndjson": "application/x-ndjson",
+		}[format]
+		if !ok {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv, json or ndjson"})
+			return
+		}
+
+		filter, err := taskFilterFromQuery(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		fields, err := taskFieldsFor(db, userID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve custom fields"})
+			return
+		}
+		query, err := filter.applyAt(db.Model(&model.Task{}).Where("tasks.user_id = ?", userID), fields, userNow(db, userID))
+		if err != nil {
+			invalidFilter(ctx, err)
+			return
+		}
+
+		order, err := taskOrder(ctx.DefaultQuery("sort", "created_at:asc"), fields)
+		if err != nil {
+			invalidFilter(ctx, err)
+			return
+		}
+
+		// Rows streams the result instead of loading every task into memory.
+		rows, err := query.Clauses(order).Rows()
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export tasks", "details": err.Error()})
+			return
+		}
+		defer rows.Close()
+
+		filename := fmt.Sprintf("tasks-%s.%s", time.Now().Format("2006-01-02"), format)
+		ctx.Header("Content-Type", contentType)
+		ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+		ctx.Status(http.StatusOK)
+
+		writer := newTaskExportWriter(ctx.Writer, format)
+		count := 0
+		for rows.Next() {
+			var task model.Task
+			if err = db.ScanRows(rows, &task); err != nil {
+				break
+			}
+			if err = writer.write(task); err != nil {
+				break
+			}
+			count++
+		}
+		if err == nil {
+			err = rows.Err()
+		}
+		if err == nil {
+			err = writer.close()
+		}
+
+		// the status line has been sent, so failures can only be logged
+		if err != nil {
+			log.Error().Err(err).Uint("user_id", userID).Int("exported", count).Msg("Task export aborted")
+		}
+	}
+}
+
+// taskExportWriter writes tasks in one of the export formats.
+type taskExportWriter struct {
+	w      http.ResponseWriter
+	format string
+	csv    *csv.Writer
+	count  int
+}
+
+func newTaskExportWriter(w http.ResponseWriter, format string) *taskExportWriter {
+
+	writer := &taskExportWriter{w: w, format: format}
+	if format == "csv" {
+		writer.csv = csv.NewWriter(w)
+	}
+	return writer
+}
+
+func (e *taskExportWriter) write(task model.Task) error {
+
+	defer func() { e.count++ }()
+
+	switch e.format {
+	case "csv":
+		if e.count == 0 {
+			if err := e.csv.Write(exportColumns); err != nil {
+				return err
+			}
+		}
+		err := e.csv.Write(taskCSVRecord(task))
+		if e.count%100 == 99 {
+			e.csv.Flush()
+		}
+		return err
+
+	case "json":
+		prefix := ",\n"
+		if e.count == 0 {
+			prefix = "[\n"
+		}
+		if _, err := e.w.Write([]byte(prefix)); err != nil {
+			return err
+		}
+		data, err := json.Marshal(exportedTask(task))
+		if err != nil {
+			return err
+		}
+		_, err = e.w.Write(data)
+		return err
+
+	default: // ndjson
+		data, err := json.Marshal(exportedTask(task))
+		if err != nil {
+			return err
+		}
+		_, err = e.w.Write(append(data, '\n'))
+		return err
+	}
+}
+
+// close finishes the document; an empty CSV still gets its header and an
+// empty JSON export is [].
+func (e *taskExportWriter) close() error {
+
+	switch e.format {
+	case "csv":
+		if e.count == 0 {
+			e.csv.Write(exportColumns)
+		}
+		e.csv.Flush()
+		return e.csv.Error()
+	case "json":
+		end := "\n]\n"
+		if e.count == 0 {
+			end = "[]\n"
+		}
+		_, err := e.w.Write([]byte(end))
+		return err
+	}
+	return nil
+}
+
+func exportedTask(task model.Task) ExportedTask {
+	return ExportedTask{
+		ID:          task.ID,
+		Title:       task.Title,
+		Description: task.Description,
+		Priority:    task.Priority,
+		Status:      task.Status,
+		AssigneeID:  task.AssigneeID,
+		CreatedAt:   task.CreatedAt,
+		UpdatedAt:   task.UpdatedAt,
+		CompletedAt: task.CompletedAt,
+		DueAt:       task.DueAt,
+	}
+}
+
+func taskCSVRecord(task model.Task) []string {
+
+	optionalTime := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.UTC().Format(time.RFC3339)
+	}
+	assignee := ""
+	if task.AssigneeID != nil {
+		assignee = strconv.FormatUint(uint64(*task.AssigneeID), 10)
+	}
+
+	return []string{
+		strconv.FormatUint(uint64(task.ID), 10),
+		csvSafe(task.Title),
+		csvSafe(task.Description),
+		strconv.Itoa(task.Priority),
+		task.Status,
+		assignee,
+		task.CreatedAt.UTC().Format(time.RFC3339),
+		task.UpdatedAt.UTC().Format(time.RFC3339),
+		optionalTime(task.CompletedAt),
+		optionalTime(task.DueAt),
+	}
+}
+
+// csvSafe keeps spreadsheets from evaluating text cells as formulas by
+// prefixing them with a quote. Imports strip the quote again.
+func csvSafe(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// parseIDParam reads a numeric path parameter and answers 400 when it is
+// not a valid ID. label is used in the error message, e.g. "Task ID".
+func parseIDParam(ctx *gin.Context, name, label string) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param(name), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + label})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// pageParams reads the page/limit query parameters the same way the task
+// lists do: page defaults to 1 and limit to 10, capped at 100.
+func pageParams(ctx *gin.Context) (page, limit, offset int) {
+	page, _ = strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ = strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	return page, limit, (page - 1) * limit
+}
+
+// visibleTasks scopes a query to the tasks a user may see: the ones they
+// created and the ones assigned to them.
+func visibleTasks(userID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("(tasks.user_id = ? OR tasks.assignee_id = ?)", userID, userID)
+	}
+}
+
+// findVisibleTask loads a task the user created or is assigned to.
+func findVisibleTask(db *gorm.DB, taskID, userID uint) (model.Task, error) {
+	var task model.Task
+	err := db.Scopes(visibleTasks(userID)).Where("tasks.id = ?", taskID).First(&task).Error
+	return task, err
+}
+
+// appBaseURL is the public URL used in links sent by mail.
+func appBaseURL() string {
+	baseUrl := os.Getenv("PROD_URL")
+	if baseUrl == "" {
+		baseUrl = "http://localhost:4000"
+	}
+	return baseUrl
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/Niraj1910/Task-REST-APIs/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// auditedTaskFields are the task columns whose changes are kept in the
+// history, in the order their events are written.
+var auditedTaskFields = []string{"title", "description", "priority", "status", "due_at", "estimated_minutes"}
+
+// GetTaskHistory godoc
+// @Summary      Get task history
+// @Description  Returns who changed what on a task and when, newest first. Covers creation, field updates, status changes, assignment, deletion and restore.
+// @Tags         Tasks
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id    path  int true  "Task ID"
+// @Param        page  query int false "Page number"    default(1)
+// @Param        limit query int false "Items per page" default(10)
+// @Success      200 {object} map[string]interface{} "Events with pagination meta"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      404 {object} map[string]string "Task not found"
+// @Failure      500 {object} map[string]string "Server error"
+// @Router       /api/task/{id}/history [get]
+func GetTaskHistory(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		taskID, ok := parseIDParam(ctx, "id", "Task ID")
+		if !ok {
+			return
+		}
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		_, err := findVisibleTask(db, taskID, userID)
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found or not visible to you"})
+			return
+		}
+
+		page, limit, offset := pageParams(ctx)
+
+		query := db.Model(&model.TaskEvent{}).Where("task_id = ?", taskID)
+
+		var total int64
+		query.Count(&total)
+
+		var events []model.TaskEvent
+		err = query.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&events).Error
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve task history", "details": err.Error()})
+			return
+		}
+
+		items := make([]gin.H, 0, len(events))
+		for _, event := range events {
+			items = append(items, gin.H{
+				"id":         event.ID,
+				"action":     event.Action,
+				"field":      event.Field,
+				"old_value":  event.OldValue,
+				"new_value":  event.NewValue,
+				"actor_id":   event.ActorID,
+				"created_at": event.CreatedAt,
+			})
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"events": items,
+			"meta": gin.H{
+				"total": total,
+				"page":  page,
+				"limit": limit,
+			},
+		})
+	}
+}
+
+// recordTaskEvent appends an entry to a task's history. Pass the transaction
+// that performs the change so the event is only kept when the change is.
+func recordTaskEvent(tx *gorm.DB, taskID, actorID uint, action, field string, oldValue, newValue *string) error {
+	event := model.TaskEvent{
+		TaskID:   taskID,
+		ActorID:  actorID,
+		Action:   action,
+		Field:    field,
+		OldValue: oldValue,
+		NewValue: newValue,
+	}
+	return tx.Create(&event).Error
+}
+
+// recordTaskChanges writes one event per audited field that updates actually
+// changes compared to before. Status changes get their own action.
+func recordTaskChanges(tx *gorm.DB, before model.Task, updates map[string]interface{}, actorID uint) error {
+
+	previous := map[string]string{
+		"title":             before.Title,
+		"description":       before.Description,
+		"priority":          fmt.Sprint(before.Priority),
+		"status":            before.Status,
+		"due_at":            auditValue(before.DueAt),
+		"estimated_minutes": auditValue(before.EstimatedMinutes),
+	}
+
+	for _, field := range auditedTaskFields {
+		value, ok := updates[field]
+		if !ok {
+			continue
+		}
+
+		oldValue := previous[field]
+		newValue := auditValue(value)
+		if oldValue == newValue {
+			continue
+		}
+
+		action := "updated"
+		if field == "status" {
+			action = "status_changed"
+		}
+
+		err := recordTaskEvent(tx, before.ID, actorID, action, field, &oldValue, &newValue)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// auditValue formats a column value for the history. Times are stored as
+// RFC 3339 in UTC and a missing time as "".
+func auditValue(value any) string {
+	switch v := value.(type) {
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return v.UTC().Format(time.RFC3339)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	case *int:
+		if v == nil {
+			return ""
+		}
+		return fmt.Sprint(*v)
+	}
+	return fmt.Sprint(value)
+}
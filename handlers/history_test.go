@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskHistory_RecordsFieldChanges(t *testing.T) {
+	db := setupTestDB(t)
+
+	c, w := setupContext(http.MethodPost, "/api/task/new", `{"title": "Quarterly report", "description": "Q3"}`, 1)
+	CreateTask(db)(c)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var task model.Task
+	require.NoError(t, db.First(&task).Error)
+	idParam := gin.Params{{Key: "id", Value: fmt.Sprint(task.ID)}}
+
+	c, w = setupContext(http.MethodPut, "/api/task/1", `{"title": "Quarterly report v2", "description": "Q3", "priority": 0, "status": "in_progress"}`, 1)
+	c.Params = idParam
+	UpdateTask(db)(c)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	c, w = setupContext(http.MethodGet, "/api/task/1/history", "", 1)
+	c.Params = idParam
+	GetTaskHistory(db)(c)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Events []struct {
+			Action   string  `json:"action"`
+			Field    string  `json:"field"`
+			OldValue *string `json:"old_value"`
+			NewValue *string `json:"new_value"`
+			ActorID  uint    `json:"actor_id"`
+		} `json:"events"`
+		Meta struct {
+			Total int64 `json:"total"`
+		} `json:"meta"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+
+	// unchanged description and priority are not recorded
+	require.Len(t, resp.Events, 3)
+	assert.Equal(t, int64(3), resp.Meta.Total)
+
+	assert.Equal(t, "status_changed", resp.Events[0].Action)
+	assert.Equal(t, "pending", *resp.Events[0].OldValue)
+	assert.Equal(t, "in_progress", *resp.Events[0].NewValue)
+
+	assert.Equal(t, "updated", resp.Events[1].Action)
+	assert.Equal(t, "title", resp.Events[1].Field)
+	assert.Equal(t, "Quarterly report", *resp.Events[1].OldValue)
+	assert.Equal(t, uint(1), resp.Events[1].ActorID)
+
+	assert.Equal(t, "created", resp.Events[2].Action)
+}
+
+func TestTaskHistory_NotVisible(t *testing.T) {
+	db := setupTestDB(t)
+	task := model.Task{Title: "Private task", UserID: 1}
+	require.NoError(t, db.Create(&task).Error)
+
+	c, w := setupContext(http.MethodGet, "/api/task/1/history", "", 2)
+	c.Params = gin.Params{{Key: "id", Value: fmt.Sprint(task.ID)}}
+	GetTaskHistory(db)(c)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
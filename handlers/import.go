@@ -0,0 +1,670 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Niraj1910/Task-REST-APIs/ical"
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/Niraj1910/Task-REST-APIs/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+const (
+	// maxImportBytes caps the size of an uploaded import file.
+	maxImportBytes = 5 << 20
+	// maxImportRows caps the rows of one import.
+	maxImportRows = 10000
+)
+
+const (
+	// importHeartbeat is how often a running job touches its updated_at.
+	importHeartbeat = 30 * time.Second
+	// importStaleAfter without a heartbeat, a job's instance is taken to
+	// have stopped.
+	importStaleAfter = 5 * importHeartbeat
+)
+
+// importSyncRows is the largest import answered directly; bigger ones run
+// as a background job. A variable so tests can lower it.
+var importSyncRows = 500
+
+// importFields are the task fields an import can set.
+var importFields = []string{"title", "description", "priority", "status", "due_at"}
+
+// importedTask is one row of an import after mapping. It is validated with
+// the same rules as a task created through the API.
+type importedTask struct {
+	Title       string
+	Description string
+	Priority    int
+	Status      string
+	DueAt       *time.Time
+}
+
+// ImportRowResult is the outcome of one imported row. Status is "created",
+// "valid" (dry run), "invalid", "skipped" (atomic import not committed) or
+// "failed" (database error).
+type ImportRowResult struct {
+	Row    int      `json:"row"`
+	Status string   `json:"status"`
+	TaskID uint     `json:"task_id,omitempty"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+// ImportSummary is the result of an import.
+type ImportSummary struct {
+	DryRun    bool              `json:"dry_run"`
+	Atomic    bool              `json:"atomic"`
+	Committed bool              `json:"committed"`
+	Total     int               `json:"total"`
+	Created   int               `json:"created"`
+	Invalid   int               `json:"invalid"`
+	Failed    int               `json:"failed"`
+	Results   []ImportRowResult `json:"results"`
+}
+
+type importOptions struct {
+	// Mapping maps task fields to the column (CSV) or key (JSON) they are read from.
+	Mapping map[string]string
+	DryRun  bool
+	Atomic  bool
+}
+
+// ImportTasks godoc
+// @Summary      Import tasks
+// @Description  Creates tasks from a CSV file (with a header row), a JSON array of objects or NDJSON, sent as the
+// @Description  multipart field "file" or as the request body. Columns are matched by name (title, description,
+// @Description  priority, status, due_at); "mapping" renames them, e.g. {"title":"Task name","priority":"Prio"}.
+// @Description  Every row is validated and reported. With dry_run nothing is saved; with atomic either all rows are
+// @Description  imported or none. Imports of more than 500 rows run in the background: the response is 202 with a job
+// @Description  to poll at GET /api/task/import/{jobId}. iCalendar (.ics) files import each VTODO: SUMMARY,
+// @Description  DESCRIPTION, PRIORITY, STATUS and DUE become title, description, priority, status and due_at.
+// @Tags         Tasks
+// @Security     BearerAuth
+// @Accept       multipart/form-data
+// @Accept       text/csv
+// @Accept       json
+// @Accept       text/calendar
+// @Produce      json
+// @Param        file    formData file   false "CSV, JSON, NDJSON or iCalendar file"
+// @Param        format  query    string false "csv, json, ndjson or ics (default: from the file name or Content-Type)"
+// @Param        mapping query    string false "JSON object mapping task fields to column names"
+// @Param        dry_run query    bool   false "Only validate"
+// @Param        atomic  query    bool   false "Import all rows or none"
+// @Success      200 {object} handlers.ImportSummary
+// @Success      202 {object} map[string]interface{} "Import job started"
+// @Failure      400 {object} map[string]string "Unreadable file or invalid options"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      413 {object} map[string]string "Upload larger than 5 MB"
+// @Failure      500 {object} map[string]string "Server error"
+// @Router       /api/task/import [post]
+func ImportTasks(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		// the limit must be in place before anything reads the body, and
+		// the options may come from the multipart form
+		ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxImportBytes)
+		if mediaType, _, _ := mime.ParseMediaType(ctx.GetHeader("Content-Type")); mediaType == "multipart/form-data" {
+			if err := ctx.Request.ParseMultipartForm(maxImportBytes); err != nil {
+				importReadFailed(ctx, err)
+				return
+			}
+		}
+
+		opts, err := importOptionsFromRequest(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid input", "details": err.Error()})
+			return
+		}
+
+		reader, format, err := importSource(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid input", "details": err.Error()})
+			return
+		}
+		defer reader.Close()
+
+		rows, err := parseImportRows(reader, format)
+		if err != nil {
+			importReadFailed(ctx, err)
+			return
+		}
+
+		if len(rows) > importSyncRows {
+			job := model.ImportJob{UserID: userID, Status: "queued", DryRun: opts.DryRun, Atomic: opts.Atomic, Total: len(rows)}
+			if err := db.Create(&job).Error; err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start the import"})
+				return
+			}
+			go runImportJob(db, job.ID, userID, rows, opts)
+
+			ctx.JSON(http.StatusAccepted, gin.H{
+				"job_id":     job.ID,
+				"status":     job.Status,
+				"total":      job.Total,
+				"status_url": fmt.Sprintf("/api/task/import/%d", job.ID),
+			})
+			return
+		}
+
+		summary, err := importTasks(db, userID, rows, opts, nil)
+		if err != nil {
+			log.Error().Err(err).Uint("user_id", userID).Msg("Task import failed")
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import tasks"})
+			return
+		}
+		ctx.JSON(http.StatusOK, summary)
+	}
+}
+
+// GetImportJob godoc
+// @Summary      Get an import job
+// @Description  Returns the progress of a background import and, once it has finished, its per-row results
+// @Tags         Tasks
+// @Security     BearerAuth
+// @Produce      json
+// @Param        jobId path int true "Import job ID"
+// @Success      200 {object} map[string]interface{} "Job status"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      404 {object} map[string]string "Job not found"
+// @Router       /api/task/import/{jobId} [get]
+func GetImportJob(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		jobID, ok := parseIDParam(ctx, "jobId", "Job ID")
+		if !ok {
+			return
+		}
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		var job model.ImportJob
+		err := db.Where("id = ? AND user_id = ?", jobID, userID).First(&job).Error
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Import job not found"})
+			return
+		}
+
+		response := gin.H{
+			"id":          job.ID,
+			"status":      job.Status,
+			"dry_run":     job.DryRun,
+			"atomic":      job.Atomic,
+			"total":       job.Total,
+			"processed":   job.Processed,
+			"created":     job.Created,
+			"failed":      job.Failed,
+			"created_at":  job.CreatedAt,
+			"finished_at": job.FinishedAt,
+		}
+		if job.Error != "" {
+			response["error"] = job.Error
+		}
+		if job.Results != "" {
+			response["summary"] = json.RawMessage(job.Results)
+		}
+		ctx.JSON(http.StatusOK, response)
+	}
+}
+
+// FailInterruptedImports marks queued or running jobs as failed once their
+// instance has stopped sending heartbeats; their goroutines are gone. Jobs
+// of live instances are left alone, so every instance may run this.
+func FailInterruptedImports(db *gorm.DB, now time.Time) (int64, error) {
+	result := db.Model(&model.ImportJob{}).
+		Where("status IN ? AND updated_at < ?", []string{"queued", "running"}, now.Add(-importStaleAfter).UTC()).
+		Updates(map[string]interface{}{"status": "failed", "error": "interrupted: the server running it stopped", "finished_at": now.UTC()})
+	return result.RowsAffected, result.Error
+}
+
+func runImportJob(db *gorm.DB, jobID, userID uint, rows []map[string]string, opts importOptions) {
+
+	jobs := db.Model(&model.ImportJob{}).Where("id = ?", jobID)
+	jobs.Session(&gorm.Session{}).Update("status", "running")
+
+	// the heartbeat tells other instances this job is still alive
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(importHeartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				jobs.Session(&gorm.Session{}).Update("updated_at", time.Now())
+			}
+		}
+	}()
+
+	progress := func(processed int) {
+		jobs.Session(&gorm.Session{}).Update("processed", processed)
+	}
+
+	summary, err := importTasks(db, userID, rows, opts, progress)
+
+	now := time.Now()
+	updates := map[string]interface{}{"status": "completed", "finished_at": &now}
+	if err != nil {
+		log.Error().Err(err).Uint("job_id", jobID).Msg("Import job failed")
+		updates["status"] = "failed"
+		updates["error"] = err.Error()
+	} else {
+		results, _ := json.Marshal(summary)
+		updates["results"] = string(results)
+		updates["processed"] = summary.Total
+		updates["created"] = summary.Created
+		updates["failed"] = summary.Invalid + summary.Failed
+	}
+
+	if err := jobs.Session(&gorm.Session{}).Updates(updates).Error; err != nil {
+		log.Error().Err(err).Uint("job_id", jobID).Msg("Failed to save import job result")
+	}
+}
+
+// importTasks validates every row and, unless it is a dry run, creates the
+// valid ones. In atomic mode nothing is created if any row is invalid or
+// any insert fails. progress, if set, is called every 100 rows.
+func importTasks(db *gorm.DB, userID uint, rows []map[string]string, opts importOptions, progress func(int)) (ImportSummary, error) {
+
+	summary := ImportSummary{DryRun: opts.DryRun, Atomic: opts.Atomic, Total: len(rows)}
+	tasks := make([]*importedTask, len(rows))
+
+	for i, row := range rows {
+		result := ImportRowResult{Row: i + 1, Status: "valid"}
+		task, errs := mapImportRow(row, opts.Mapping)
+		if len(errs) > 0 {
+			result.Status = "invalid"
+			result.Errors = errs
+			summary.Invalid++
+		} else {
+			tasks[i] = task
+		}
+		summary.Results = append(summary.Results, result)
+	}
+
+	if opts.DryRun {
+		return summary, nil
+	}
+
+	if opts.Atomic {
+		if summary.Invalid > 0 {
+			markImportSkipped(&summary)
+			return summary, nil
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			for i, task := range tasks {
+				id, err := createImportedTask(tx, userID, *task)
+				if err != nil {
+					return fmt.Errorf("row %d: %w", i+1, err)
+				}
+				summary.Results[i].Status = "created"
+				summary.Results[i].TaskID = id
+				if progress != nil && (i+1)%100 == 0 {
+					progress(i + 1)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return ImportSummary{}, err
+		}
+		summary.Created = len(tasks)
+		summary.Committed = true
+		publishImportedTasks(db, summary)
+		return summary, nil
+	}
+
+	for i, task := range tasks {
+		if task != nil {
+			var id uint
+			err := db.Transaction(func(tx *gorm.DB) error {
+				var err error
+				id, err = createImportedTask(tx, userID, *task)
+				return err
+			})
+			if err != nil {
+				summary.Results[i].Status = "failed"
+				summary.Results[i].Errors = []string{err.Error()}
+				summary.Failed++
+			} else {
+				summary.Results[i].Status = "created"
+				summary.Results[i].TaskID = id
+				summary.Created++
+			}
+		}
+		if progress != nil && (i+1)%100 == 0 {
+			progress(i + 1)
+		}
+	}
+	summary.Committed = summary.Created > 0
+	publishImportedTasks(db, summary)
+	return summary, nil
+}
+
+func publishImportedTasks(db *gorm.DB, summary ImportSummary) {
+	var ids []uint
+	for _, result := range summary.Results {
+		if result.Status == "created" {
+			ids = append(ids, result.TaskID)
+		}
+	}
+	publishTaskEvents(db, eventTaskCreated, ids)
+}
+
+func markImportSkipped(summary *ImportSummary) {
+	for i := range summary.Results {
+		if summary.Results[i].Status == "valid" {
+			summary.Results[i].Status = "skipped"
+		}
+	}
+}
+
+func createImportedTask(tx *gorm.DB, userID uint, row importedTask) (uint, error) {
+
+	task := model.Task{
+		Title:       row.Title,
+		Description: row.Description,
+		Priority:    row.Priority,
+		Status:      row.Status,
+		UserID:      userID,
+		DueAt:       row.DueAt,
+	}
+	if task.Status == "completed" {
+		now := time.Now()
+		task.CompletedAt = &now
+	}
+
+	var err error
+	if task.BoardRank, err = bottomRank(tx, userID, task.Status); err != nil {
+		return 0, err
+	}
+	if err := tx.Create(&task).Error; err != nil {
+		return 0, err
+	}
+	if err := enqueueTaskWebhooks(tx, eventTaskCreated, task.ID); err != nil {
+		return 0, err
+	}
+	return task.ID, recordTaskEvent(tx, task.ID, userID, "created", "", nil, &task.Title)
+}
+
+// mapImportRow reads the task fields from a row through the column mapping
+// and validates them. It returns every problem of the row.
+func mapImportRow(row map[string]string, mapping map[string]string) (*importedTask, []string) {
+
+	value := func(field string) string {
+		column := field
+		if mapped, ok := mapping[field]; ok {
+			column = mapped
+		}
+		for key, v := range row {
+			if strings.EqualFold(strings.TrimSpace(key), column) {
+				return strings.TrimSpace(v)
+			}
+		}
+		return ""
+	}
+
+	var errs []string
+	task := importedTask{
+		Title:       uncsvSafe(value("title")),
+		Description: uncsvSafe(value("description")),
+		Status:      value("status"),
+	}
+	if task.Status == "" {
+		task.Status = "pending"
+	}
+	if due := value("due_at"); due != "" {
+		dueAt, err := parseImportTime(due)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("due_at: %q is not a date (2006-01-02) or RFC 3339 time", due))
+		}
+		task.DueAt = dueAt
+	}
+	if priority := value("priority"); priority != "" {
+		n, err := strconv.Atoi(priority)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("priority: %q is not a whole number", priority))
+		}
+		task.Priority = n
+	}
+
+	switch n := utf8.RuneCountInString(task.Title); {
+	case n == 0:
+		errs = append(errs, "title: is required")
+	case n < 5 || n > 200:
+		errs = append(errs, "title: must be 5 to 200 characters")
+	}
+	if utf8.RuneCountInString(task.Description) > 1000 {
+		errs = append(errs, "description: must be at most 1000 characters")
+	}
+	if task.Priority < 0 || task.Priority > 10 {
+		errs = append(errs, "priority: must be between 0 and 10")
+	}
+	if !slices.Contains([]string{"pending", "in_progress", "completed"}, task.Status) {
+		errs = append(errs, fmt.Sprintf("status: %q is not pending, in_progress or completed", task.Status))
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return &task, nil
+}
+
+func parseImportTime(s string) (*time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, errors.New("invalid time")
+}
+
+// uncsvSafe removes the quote csvSafe puts in front of formula-like text.
+func uncsvSafe(s string) string {
+	if len(s) > 1 && s[0] == '\'' && strings.ContainsRune("=+-@\t\r", rune(s[1])) {
+		return s[1:]
+	}
+	return s
+}
+
+// importReadFailed answers an upload that could not be read, with 413 if
+// it was over the size limit.
+func importReadFailed(ctx *gin.Context, err error) {
+
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Imports are limited to %d MB", maxImportBytes>>20)})
+		return
+	}
+	ctx.JSON(http.StatusBadRequest, gin.H{"error": "Could not read the import", "details": err.Error()})
+}
+
+func importOptionsFromRequest(ctx *gin.Context) (importOptions, error) {
+
+	param := func(name string) string {
+		if value := ctx.Query(name); value != "" {
+			return value
+		}
+		return ctx.PostForm(name)
+	}
+
+	opts := importOptions{
+		DryRun: param("dry_run") == "true",
+		Atomic: param("atomic") == "true",
+	}
+
+	if raw := param("mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &opts.Mapping); err != nil {
+			return opts, errors.New("mapping must be a JSON object of field to column name")
+		}
+		for field := range opts.Mapping {
+			if !slices.Contains(importFields, field) {
+				return opts, fmt.Errorf("mapping: unknown task field %q, expected one of %s", field, strings.Join(importFields, ", "))
+			}
+		}
+	}
+	return opts, nil
+}
+
+// importSource finds the uploaded data and its format: the multipart field
+// "file", or the request body itself.
+func importSource(ctx *gin.Context) (io.ReadCloser, string, error) {
+
+	format := ctx.Query("format")
+	mediaType, _, _ := mime.ParseMediaType(ctx.GetHeader("Content-Type"))
+
+	if mediaType == "multipart/form-data" {
+		header, err := ctx.FormFile("file")
+		if err != nil {
+			return nil, "", errors.New("multipart field \"file\" is required")
+		}
+		if format == "" {
+			format = strings.TrimPrefix(path.Ext(header.Filename), ".")
+		}
+		file, err := header.Open()
+		if err != nil {
+			return nil, "", err
+		}
+		if format = normalizeImportFormat(format); format == "" {
+			file.Close()
+			return nil, "", errors.New("format must be csv, json, ndjson or ics")
+		}
+		return file, format, nil
+	}
+
+	if format == "" {
+		switch mediaType {
+		case "text/csv":
+			format = "csv"
+		case "application/json":
+			format = "json"
+		case "application/x-ndjson":
+			format = "ndjson"
+		case "text/calendar":
+			format = "ics"
+		}
+	}
+	if format = normalizeImportFormat(format); format == "" {
+		return nil, "", errors.New("format must be csv, json, ndjson or ics")
+	}
+	return ctx.Request.Body, format, nil
+}
+
+func normalizeImportFormat(format string) string {
+	switch strings.ToLower(format) {
+	case "csv":
+		return "csv"
+	case "json":
+		return "json"
+	case "ndjson", "jsonl":
+		return "ndjson"
+	case "ics", "ical":
+		return "ics"
+	}
+	return ""
+}
+
+// parseImportRows reads the rows of an import as column name → value.
+func parseImportRows(r io.Reader, format string) ([]map[string]string, error) {
+
+	var rows []map[string]string
+	add := func(row map[string]string) error {
+		if len(rows) == maxImportRows {
+			return fmt.Errorf("an import may have at most %d rows", maxImportRows)
+		}
+		rows = append(rows, row)
+		return nil
+	}
+
+	switch format {
+	case "ics":
+		cal, err := ical.Parse(r)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range icsImportRows(cal) {
+			if err := add(row); err != nil {
+				return nil, err
+			}
+		}
+
+	case "csv":
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		header, err := reader.Read()
+		if err != nil {
+			return nil, fmt.Errorf("missing header row: %w", err)
+		}
+		// a UTF-8 byte order mark, as written by spreadsheet programs
+		header[0] = strings.TrimPrefix(header[0], "\uFEFF")
+
+		for {
+			record, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			row := map[string]string{}
+			for i, column := range header {
+				if i < len(record) {
+					row[column] = record[i]
+				}
+			}
+			if err := add(row); err != nil {
+				return nil, err
+			}
+		}
+
+	case "json", "ndjson":
+		decoder := json.NewDecoder(r)
+		decoder.UseNumber()
+
+		if format == "json" {
+			if tok, err := decoder.Token(); err != nil || tok != json.Delim('[') {
+				return nil, errors.New("JSON import must be an array of objects")
+			}
+		}
+		for decoder.More() {
+			var object map[string]any
+			if err := decoder.Decode(&object); err != nil {
+				return nil, fmt.Errorf("row %d: %w", len(rows)+1, err)
+			}
+			row := map[string]string{}
+			for key, value := range object {
+				if value != nil {
+					row[key] = fmt.Sprint(value)
+				}
+			}
+			if err := add(row); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return rows, nil
+}
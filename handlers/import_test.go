@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func runImport(t *testing.T, db *gorm.DB, query, contentType, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("user_id", uint(1))
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/task/import?"+query, strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", contentType)
+	ImportTasks(db)(c)
+	return w
+}
+
+const importCSV = "Task name,Notes,Prio,status\n" +
+	"Write quarterly report,Q3 numbers,7,pending\n" +
+	"Ship,too short,3,pending\n" +
+	"Plan the offsite,,high,done\n" +
+	"'=Formula looking title,,2,completed\n"
+
+func TestImportTasks_MappingValidationAndDryRun(t *testing.T) {
+	db := setupTestDB(t)
+	mapping := "mapping=" + url.QueryEscape(`{"title":"Task name","description":"Notes","priority":"Prio"}`)
+
+	w := runImport(t, db, mapping+"&dry_run=true", "text/csv", importCSV)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var summary ImportSummary
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &summary))
+	assert.Equal(t, 4, summary.Total)
+	assert.Equal(t, 2, summary.Invalid)
+	assert.Equal(t, []string{"valid", "invalid", "invalid", "valid"},
+		[]string{summary.Results[0].Status, summary.Results[1].Status, summary.Results[2].Status, summary.Results[3].Status})
+	assert.Len(t, summary.Results[2].Errors, 2, "every problem of a row is reported")
+
+	var count int64
+	db.Model(&model.Task{}).Count(&count)
+	assert.Zero(t, count, "a dry run saves nothing")
+
+	w = runImport(t, db, mapping, "text/csv", importCSV)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &summary))
+	assert.Equal(t, 2, summary.Created)
+	assert.True(t, summary.Committed)
+
+	var tasks []model.Task
+	db.Order("id").Find(&tasks)
+	require.Len(t, tasks, 2)
+	assert.Equal(t, "Write quarterly report", tasks[0].Title)
+	assert.Equal(t, 7, tasks[0].Priority)
+	assert.Equal(t, "=Formula looking title", tasks[1].Title)
+	assert.NotNil(t, tasks[1].CompletedAt)
+	assert.Equal(t, summary.Results[0].TaskID, tasks[0].ID)
+}
+
+func TestImportTasks_SizeLimit(t *testing.T) {
+	db := setupTestDB(t)
+
+	// the options are form fields, so the form is read before the file
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	require.NoError(t, form.WriteField("dry_run", "true"))
+	file, err := form.CreateFormFile("file", "tasks.csv")
+	require.NoError(t, err)
+	file.Write([]byte("title\n"))
+	for body.Len() <= maxImportBytes {
+		file.Write([]byte(strings.Repeat("A long enough task title\n", 1000)))
+	}
+	require.NoError(t, form.Close())
+
+	w := runImport(t, db, "", form.FormDataContentType(), body.String())
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code, w.Body.String())
+
+	line := strings.Repeat("x", 599) + "\n"
+	w = runImport(t, db, "", "text/csv", "title\n"+strings.Repeat(line, maxImportBytes/len(line)+1))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code, w.Body.String())
+}
+
+func TestImportTasks_AtomicAndJSON(t *testing.T) {
+	db := setupTestDB(t)
+
+	body := `[{"title": "Write quarterly report", "priority": 4}, {"title": "No"}]`
+	w := runImport(t, db, "atomic=true", "application/json", body)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var summary ImportSummary
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &summary))
+	assert.False(t, summary.Committed)
+	assert.Equal(t, "skipped", summary.Results[0].Status)
+
+	var count int64
+	db.Model(&model.Task{}).Count(&count)
+	assert.Zero(t, count, "one bad row stops an atomic import")
+
+	ndjson := "{\"title\": \"Write quarterly report\", \"priority\": 4}\n{\"title\": \"Book the venue\"}\n"
+	w = runImport(t, db, "atomic=true&format=ndjson", "application/octet-stream", ndjson)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &summary))
+	assert.True(t, summary.Committed)
+	assert.Equal(t, 2, summary.Created)
+
+	w = runImport(t, db, "mapping="+url.QueryEscape(`{"owner":"x"}`), "text/csv", importCSV)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = runImport(t, db, "", "text/plain", importCSV)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "the format must be known")
+}
+
+func TestImportTasks_LargeImportRunsAsJob(t *testing.T) {
+	db := setupTestDB(t)
+	// the job goroutine and the test must share the in-memory database
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+
+	defer func(previous int) { importSyncRows = previous }(importSyncRows)
+	importSyncRows = 2
+
+	w := runImport(t, db, "", "text/csv", importCSV)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+
+	var started struct {
+		JobID uint `json:"job_id"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &started))
+
+	var job map[string]any
+	require.Eventually(t, func() bool {
+		c, w := setupContext(http.MethodGet, "/api/task/import/1", "", 1)
+		c.Params = gin.Params{{Key: "jobId", Value: fmt.Sprint(started.JobID)}}
+		GetImportJob(db)(c)
+		json.Unmarshal(w.Body.Bytes(), &job)
+		return job["status"] == "completed"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.EqualValues(t, 4, job["processed"])
+	summary := job["summary"].(map[string]any)
+	assert.EqualValues(t, 4, summary["total"])
+
+	c, w := setupContext(http.MethodGet, "/api/task/import/1", "", 2)
+	c.Params = gin.Params{{Key: "jobId", Value: fmt.Sprint(started.JobID)}}
+	GetImportJob(db)(c)
+	assert.Equal(t, http.StatusNotFound, w.Code, "jobs are private")
+}
+
+func TestFailInterruptedImports(t *testing.T) {
+	db := setupTestDB(t)
+	jobs := []model.ImportJob{
+		{UserID: 1, Status: "running"},
+		{UserID: 1, Status: "running"},
+		{UserID: 1, Status: "completed"},
+	}
+	require.NoError(t, db.Create(&jobs).Error)
+	now := time.Now()
+	for _, job := range []model.ImportJob{jobs[0], jobs[2]} {
+		require.NoError(t, db.Model(&job).UpdateColumn("updated_at", now.Add(-time.Hour)).Error)
+	}
+
+	failed, err := FailInterruptedImports(db, now)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, failed)
+
+	var statuses []string
+	db.Model(&model.ImportJob{}).Order("id").Pluck("status", &statuses)
+	assert.Equal(t, []string{"failed", "running", "completed"}, statuses, "only jobs without a heartbeat fail")
+}
+
+func TestExportTasks_Formats(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.Create(&[]model.Task{
+		{Title: "Write report", Description: "=SUM(A1:A3)", Priority: 7, UserID: 1},
+		{Title: "Book venue", Priority: 2, UserID: 1, Status: "completed"},
+		{Title: "Someone else's", UserID: 2},
+	}).Error)
+
+	export := func(query string) *httptest.ResponseRecorder {
+		c, w := setupContext(http.MethodGet, "/api/task/export?"+query, "", 1)
+		ExportTasks(db)(c)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		return w
+	}
+
+	w := export("format=csv")
+	assert.Contains(t, w.Header().Get("Content-Disposition"), ".csv")
+	records, err := csv.NewReader(w.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, exportColumns, records[0])
+	assert.Equal(t, "'=SUM(A1:A3)", records[1][2], "formulas are neutralised")
+
+	w = export("format=json&filter=" + "priority%3E5")
+	var tasks []ExportedTask
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tasks))
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "Write report", tasks[0].Title)
+
+	w = export("format=json&status=in_progress")
+	assert.JSONEq(t, "[]", w.Body.String())
+
+	w = export("format=ndjson&sort=priority:asc")
+	var titles []string
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		var task ExportedTask
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &task))
+		titles = append(titles, task.Title)
+	}
+	assert.Equal(t, []string{"Book venue", "Write report"}, titles)
+
+	c, w := setupContext(http.MethodGet, "/api/task/export?format=xml", "", 1)
+	ExportTasks(db)(c)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
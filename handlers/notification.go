@@ -0,0 +1,360 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/Niraj1910/Task-REST-APIs/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// Event types users are notified about.
+const (
+	eventAssigned      = "assigned"
+	eventMentioned     = "mentioned"
+	eventCommented     = "commented"
+	eventDueSoon       = "due_soon"
+	eventProjectInvite = "project_invite"
+)
+
+// NotificationChannels says how one type of event reaches a user.
+type NotificationChannels struct {
+	InApp bool `json:"in_app"`
+	Email bool `json:"email"`
+}
+
+// defaultChannels apply to the event types a user has not configured.
+// Every comment on your tasks would be a lot of mail, so it is opt-in.
+var defaultChannels = map[string]NotificationChannels{
+	eventAssigned:      {InApp: true, Email: true},
+	eventMentioned:     {InApp: true, Email: true},
+	eventCommented:     {InApp: true, Email: false},
+	eventDueSoon:       {InApp: true, Email: true},
+	eventProjectInvite: {InApp: true, Email: true},
+}
+
+// NotificationPreferenceBody changes the channels of one event type; a
+// missing channel keeps its current setting.
+type NotificationPreferenceBody struct {
+	InApp *bool `json:"in_app"`
+	Email *bool `json:"email"`
+}
+
+// GetNotifications godoc
+// @Summary      List notifications
+// @Description  Returns the user's notification inbox, newest first, with the number of unread notifications
+// @Tags         Notifications
+// @Security     BearerAuth
+// @Produce      json
+// @Param        unread query bool false "Only unread notifications"
+// @Param        page   query int  false "Page number" default(1)
+// @Param        limit  query int  false "Items per page" default(10)
+// @Success      200 {object} map[string]interface{} "notifications, unread_count and meta"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Router       /api/notifications [get]
+func GetNotifications(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		page, limit, offset := pageParams(ctx)
+
+		query := db.Model(&model.Notification{}).Where("user_id = ?", userID)
+		if ctx.Query("unread") == "true" {
+			query = query.Where("read_at IS NULL")
+		}
+
+		var total, unread int64
+		query.Count(&total)
+		db.Model(&model.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&unread)
+
+		var notifications []model.Notification
+		err := query.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&notifications).Error
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve notifications"})
+			return
+		}
+
+		items := make([]gin.H, 0, len(notifications))
+		for _, notification := range notifications {
+			items = append(items, notificationResponse(notification))
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"notifications": items,
+			"unread_count":  unread,
+			"meta": gin.H{
+				"total": total,
+				"page":  page,
+				"limit": limit,
+			},
+		})
+	}
+}
+
+// MarkNotificationRead godoc
+// @Summary      Mark a notification as read
+// @Tags         Notifications
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id path int true "Notification ID"
+// @Success      200 {object} map[string]interface{} "Notification"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      404 {object} map[string]string "Notification not found"
+// @Router       /api/notifications/{id}/read [post]
+func MarkNotificationRead(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		notificationID, ok := parseIDParam(ctx, "id", "Notification ID")
+		if !ok {
+			return
+		}
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		var notification model.Notification
+		err := db.Where("id = ? AND user_id = ?", notificationID, userID).First(&notification).Error
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+			return
+		}
+
+		// reading it again keeps the first read time
+		if notification.ReadAt == nil {
+			err = db.Model(&notification).Update("read_at", time.Now()).Error
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification"})
+				return
+			}
+		}
+
+		ctx.JSON(http.StatusOK, notificationResponse(notification))
+	}
+}
+
+// MarkAllNotificationsRead godoc
+// @Summary      Mark all notifications as read
+// @Tags         Notifications
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200 {object} map[string]interface{} "Number of notifications marked"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Router       /api/notifications/read-all [post]
+func MarkAllNotificationsRead(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		result := db.Model(&model.Notification{}).
+			Where("user_id = ? AND read_at IS NULL", userID).
+			Update("read_at", time.Now())
+		if result.Error != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notifications"})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"updated": result.RowsAffected})
+	}
+}
+
+// DeleteNotification godoc
+// @Summary      Delete a notification
+// @Tags         Notifications
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id path int true "Notification ID"
+// @Success      200 {object} map[string]string "Notification deleted"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      404 {object} map[string]string "Notification not found"
+// @Router       /api/notifications/{id} [delete]
+func DeleteNotification(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		notificationID, ok := parseIDParam(ctx, "id", "Notification ID")
+		if !ok {
+			return
+		}
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		result := db.Where("id = ? AND user_id = ?", notificationID, userID).Delete(&model.Notification{})
+		if result.Error != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete notification"})
+			return
+		}
+		if result.RowsAffected == 0 {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{"message": "Notification deleted"})
+	}
+}
+
+// GetNotificationPreferences godoc
+// @Summary      Get notification preferences
+// @Description  Returns the channels (in_app, email) of every event type: assigned, mentioned, commented, due_soon and
+// @Description  project_invite
+// @Tags         Notifications
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200 {object} map[string]handlers.NotificationChannels
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Router       /api/notifications/preferences [get]
+func GetNotificationPreferences(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		preferences, err := notificationPreferences(db, userID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve notification preferences"})
+			return
+		}
+		ctx.JSON(http.StatusOK, preferences)
+	}
+}
+
+// UpdateNotificationPreferences godoc
+// @Summary      Update notification preferences
+// @Description  Sets the channels of the given event types, e.g. {"commented": {"email": true}}. Other event types
+// @Description  and channels keep their settings.
+// @Tags         Notifications
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        body body map[string]handlers.NotificationPreferenceBody true "Channels per event type"
+// @Success      200 {object} map[string]handlers.NotificationChannels
+// @Failure      400 {object} map[string]string "Invalid input or unknown event type"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Router       /api/notifications/preferences [put]
+func UpdateNotificationPreferences(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		var input map[string]NotificationPreferenceBody
+		if err := ctx.ShouldBindJSON(&input); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid input", "details": err.Error()})
+			return
+		}
+		for eventType := range input {
+			if _, ok := defaultChannels[eventType]; !ok {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown event type %q", eventType)})
+				return
+			}
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			for eventType, change := range input {
+				preference := model.NotificationPreference{UserID: userID, EventType: eventType}
+				err := tx.Where(&preference).Attrs(model.NotificationPreference{
+					InApp: defaultChannels[eventType].InApp,
+					Email: defaultChannels[eventType].Email,
+				}).FirstOrInit(&preference).Error
+				if err != nil {
+					return err
+				}
+				if change.InApp != nil {
+					preference.InApp = *change.InApp
+				}
+				if change.Email != nil {
+					preference.Email = *change.Email
+				}
+				if err := tx.Save(&preference).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			log.Error().Err(err).Uint("user_id", userID).Msg("Failed to save notification preferences")
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save notification preferences"})
+			return
+		}
+
+		preferences, err := notificationPreferences(db, userID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve notification preferences"})
+			return
+		}
+		ctx.JSON(http.StatusOK, preferences)
+	}
+}
+
+// notificationPreferences returns the channels of every event type, the
+// user's own settings over the defaults.
+func notificationPreferences(db *gorm.DB, userID uint) (map[string]NotificationChannels, error) {
+
+	preferences := make(map[string]NotificationChannels, len(defaultChannels))
+	for eventType, channels := range defaultChannels {
+		preferences[eventType] = channels
+	}
+
+	var rows []model.NotificationPreference
+	if err := db.Where("user_id = ?", userID).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if _, ok := preferences[row.EventType]; ok {
+			preferences[row.EventType] = NotificationChannels{InApp: row.InApp, Email: row.Email}
+		}
+	}
+	return preferences, nil
+}
+
+// notify puts the notification into the user's inbox if they want in-app
+// notifications of its type, and reports whether they also want an email.
+// The caller sends the email, it knows which template to use.
+func notify(db *gorm.DB, notification model.Notification) (email bool) {
+
+	channels := defaultChannels[notification.Type]
+	var preference model.NotificationPreference
+	err := db.Where("user_id = ? AND event_type = ?", notification.UserID, notification.Type).Take(&preference).Error
+	if err == nil {
+		channels = NotificationChannels{InApp: preference.InApp, Email: preference.Email}
+	}
+
+	if channels.InApp {
+		if err := db.Create(&notification).Error; err != nil {
+			log.Error().Err(err).Uint("user_id", notification.UserID).Str("type", notification.Type).Msg("Failed to store notification")
+		}
+	}
+	return channels.Email
+}
+
+func notificationResponse(notification model.Notification) gin.H {
+	return gin.H{
+		"id":         notification.ID,
+		"type":       notification.Type,
+		"task_id":    notification.TaskID,
+		"actor_id":   notification.ActorID,
+		"title":      notification.Title,
+		"body":       notification.Body,
+		"link":       notification.Link,
+		"read":       notification.ReadAt != nil,
+		"read_at":    notification.ReadAt,
+		"created_at": notification.CreatedAt,
+	}
+}
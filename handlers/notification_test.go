@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type notificationList struct {
+	Notifications []struct {
+		ID    uint
+		Type  string
+		Title string
+		Read  bool
+	}
+	UnreadCount int64 `json:"unread_count"`
+}
+
+func listNotifications(t *testing.T, db *gorm.DB, userID uint, query string) notificationList {
+	t.Helper()
+	c, w := setupContext(http.MethodGet, "/api/notifications"+query, "", userID)
+	GetNotifications(db)(c)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var list notificationList
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	return list
+}
+
+func TestNotifications_InboxFromAssignmentsAndComments(t *testing.T) {
+	db := setupTestDB(t)
+	users := []model.User{
+		{Name: "owner", Email: "owner@example.com"},
+		{Name: "assignee", Email: "assignee@example.com"},
+	}
+	require.NoError(t, db.Create(&users).Error)
+	task := model.Task{Title: "Write report", UserID: users[0].ID}
+	require.NoError(t, db.Create(&task).Error)
+
+	c, w := setupContext(http.MethodPut, "/", fmt.Sprintf(`{"assignee_id": %d}`, users[1].ID), users[0].ID)
+	c.Params = taskParam(task.ID)
+	AssignTask(db)(c)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// the assignee is mentioned and hears about it once; the owner gets the comment
+	c, w = setupContext(http.MethodPost, "/", `{"body": "Over to you @assignee"}`, users[0].ID)
+	c.Params = taskParam(task.ID)
+	CreateComment(db)(c)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	c, w = setupContext(http.MethodPost, "/", `{"body": "On it"}`, users[1].ID)
+	c.Params = taskParam(task.ID)
+	CreateComment(db)(c)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	inbox := listNotifications(t, db, users[1].ID, "")
+	require.Len(t, inbox.Notifications, 2)
+	assert.Equal(t, "mentioned", inbox.Notifications[0].Type)
+	assert.Equal(t, `owner assigned you "Write report"`, inbox.Notifications[1].Title)
+	assert.Equal(t, int64(2), inbox.UnreadCount)
+
+	owner := listNotifications(t, db, users[0].ID, "")
+	require.Len(t, owner.Notifications, 1)
+	assert.Equal(t, "commented", owner.Notifications[0].Type)
+
+	c, w = setupContext(http.MethodPost, "/", "", users[0].ID)
+	c.Params = gin.Params{{Key: "id", Value: fmt.Sprint(inbox.Notifications[0].ID)}}
+	MarkNotificationRead(db)(c)
+	assert.Equal(t, http.StatusNotFound, w.Code, "someone else's notification")
+
+	c, w = setupContext(http.MethodPost, "/", "", users[1].ID)
+	c.Params = gin.Params{{Key: "id", Value: fmt.Sprint(inbox.Notifications[0].ID)}}
+	MarkNotificationRead(db)(c)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	unread := listNotifications(t, db, users[1].ID, "?unread=true")
+	require.Len(t, unread.Notifications, 1)
+	assert.Equal(t, "assigned", unread.Notifications[0].Type)
+	assert.Equal(t, int64(1), unread.UnreadCount)
+
+	c, w = setupContext(http.MethodPost, "/", "", users[1].ID)
+	MarkAllNotificationsRead(db)(c)
+	assert.JSONEq(t, `{"updated":1}`, w.Body.String())
+
+	c, w = setupContext(http.MethodDelete, "/", "", users[1].ID)
+	c.Params = gin.Params{{Key: "id", Value: fmt.Sprint(inbox.Notifications[1].ID)}}
+	DeleteNotification(db)(c)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Len(t, listNotifications(t, db, users[1].ID, "").Notifications, 1)
+}
+
+func TestNotifications_PreferencesDecideChannels(t *testing.T) {
+	db := setupTestDB(t)
+
+	c, w := setupContext(http.MethodPut, "/", `{"commented": {"email": true}, "assigned": {"in_app": false}}`, 1)
+	UpdateNotificationPreferences(db)(c)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var preferences map[string]NotificationChannels
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &preferences))
+	assert.Equal(t, NotificationChannels{InApp: true, Email: true}, preferences["commented"])
+	assert.Equal(t, NotificationChannels{InApp: false, Email: true}, preferences["assigned"])
+	assert.Equal(t, NotificationChannels{InApp: true, Email: true}, preferences["mentioned"], "untouched types keep the defaults")
+
+	c, w = setupContext(http.MethodPut, "/", `{"assigned": {"email": false}}`, 1)
+	UpdateNotificationPreferences(db)(c)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &preferences))
+	assert.Equal(t, NotificationChannels{InApp: false, Email: false}, preferences["assigned"])
+
+	c, w = setupContext(http.MethodPut, "/", `{"birthday": {"email": true}}`, 1)
+	UpdateNotificationPreferences(db)(c)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	assert.False(t, notify(db, model.Notification{UserID: 1, Type: eventAssigned, Title: "muted"}))
+	assert.True(t, notify(db, model.Notification{UserID: 1, Type: eventCommented, Title: "both"}))
+	assert.False(t, notify(db, model.Notification{UserID: 2, Type: eventCommented, Title: "default"}))
+
+	inbox := listNotifications(t, db, 1, "")
+	require.Len(t, inbox.Notifications, 1)
+	assert.Equal(t, "both", inbox.Notifications[0].Title)
+}
@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/Niraj1910/Task-REST-APIs/patch"
+	"github.com/Niraj1910/Task-REST-APIs/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"gorm.io/gorm"
+)
+
+const (
+	mergePatchType = "application/merge-patch+json"
+	jsonPatchType  = "application/json-patch+json"
+
+	// maxPatchBytes caps the size of a patch document.
+	maxPatchBytes = 64 << 10
+)
+
+// TaskDocument is the JSON document of a task that PATCH operates on. ID and
+// version are read-only; JSON Patch can still "test" them.
+type TaskDocument struct {
+	ID               uint       `json:"id"`
+	Version          uint       `json:"version"`
+	Title            string     `json:"title" binding:"required,min=5,max=200"`
+	Description      string     `json:"description" binding:"max=1000"`
+	Priority         int        `json:"priority" binding:"gte=0,lte=10"`
+	Status           string     `json:"status" binding:"required,oneof=pending in_progress completed"`
+	DueAt            *time.Time `json:"due_at"`
+	EstimatedMinutes *int       `json:"estimated_minutes" binding:"omitempty,gte=0,lte=1000000"`
+	// CustomFields holds the values of the owner's custom fields by key.
+	CustomFields map[string]any `json:"custom_fields"`
+}
+
+// PatchTask godoc
+// @Summary      Patch a task
+// @Description  Applies a JSON Merge Patch (Content-Type application/merge-patch+json, RFC 7396) or a JSON Patch
+// @Description  (Content-Type application/json-patch+json, RFC 6902) to the task document
+// @Description  {id, version, title, description, priority, status, due_at, estimated_minutes, custom_fields}. The patched document is validated as a whole
+// @Description  and only fields that actually changed are written. In a merge patch null clears description and
+// @Description  resets priority to 0. Completing a blocked task needs ?force=true.
+// @Tags         Tasks
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id       path   int    true  "Task ID"
+// @Param        force    query  bool   false "Complete the task even if it has open blockers"
+// @Param        If-Match header string false "ETag from GET /api/task/{id}; the patch only applies if the task is unchanged"
+// @Param        body     body   handlers.TaskDocument true "Merge patch, or an array of JSON Patch operations"
+// @Success      200 {object} types.SwaggerTask
+// @Failure      400 {object} map[string]string "Malformed patch, or an operation does not fit the task"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      404 {object} map[string]string "Task not found or not owned"
+// @Failure      409 {object} map[string]interface{} "Task is blocked by open tasks, or a JSON Patch test failed"
+// @Failure      412 {object} map[string]string "Task was modified since the given ETag"
+// @Failure      415 {object} map[string]string "Unsupported patch format"
+// @Failure      422 {object} map[string]string "Patched task is invalid"
+// @Router       /api/task/{id} [patch]
+func PatchTask(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		taskID, ok := parseIDParam(ctx, "id", "Task ID")
+		if !ok {
+			return
+		}
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		mediaType, _, _ := mime.ParseMediaType(ctx.GetHeader("Content-Type"))
+		if mediaType != mergePatchType && mediaType != jsonPatchType {
+			ctx.JSON(http.StatusUnsupportedMediaType, gin.H{
+				"error":   "Unsupported patch format",
+				"details": "use " + mergePatchType + " or " + jsonPatchType,
+			})
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(ctx.Request.Body, maxPatchBytes+1))
+		if err != nil || len(body) > maxPatchBytes {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Patch is missing or too large"})
+			return
+		}
+
+		var current model.Task
+		err = db.Where("id = ? AND user_id = ?", taskID, userID).First(&current).Error
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found or not owned by you"})
+			return
+		}
+		if !checkIfMatch(ctx, current) {
+			return
+		}
+
+		original := taskDocument(current)
+		patched, err := applyTaskPatch(original, mediaType, body)
+		if err != nil {
+			var patchErr *patch.Error
+			switch {
+			case errors.Is(err, patch.ErrTestFailed) && errors.As(err, &patchErr):
+				ctx.JSON(http.StatusConflict, gin.H{"error": "Patch test failed", "details": patchErr})
+			case errors.As(err, &patchErr):
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "Patch could not be applied", "details": patchErr})
+			case errors.Is(err, errInvalidTaskDocument):
+				ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Patched task is invalid", "details": err.Error()})
+			default:
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "Malformed patch", "details": err.Error()})
+			}
+			return
+		}
+
+		updates := changedTaskFields(original, patched)
+		if changes := changedCustomFields(original.CustomFields, patched.CustomFields); len(changes) > 0 {
+			updates["custom_fields"], err = mergeCustomFields(db, current.UserID, current.CustomFields, changes)
+			if err != nil {
+				ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Patched task is invalid", "details": err.Error()})
+				return
+			}
+		}
+		if len(updates) == 0 {
+			ctx.Header("ETag", taskETag(current))
+			ctx.JSON(http.StatusOK, taskResponse(current))
+			return
+		}
+
+		saveTaskUpdates(ctx, db, current, updates, ctx.Query("force") == "true", userID)
+	}
+}
+
+var errInvalidTaskDocument = errors.New("invalid task")
+
+func taskDocument(task model.Task) TaskDocument {
+	return TaskDocument{
+		ID:               task.ID,
+		Version:          task.Version,
+		Title:            task.Title,
+		Description:      task.Description,
+		Priority:         task.Priority,
+		Status:           task.Status,
+		DueAt:            task.DueAt,
+		EstimatedMinutes: task.EstimatedMinutes,
+		CustomFields:     task.CustomFields,
+	}
+}
+
+// applyTaskPatch patches the task document and validates the result.
+func applyTaskPatch(original TaskDocument, mediaType string, body []byte) (TaskDocument, error) {
+
+	var doc any
+	data, _ := json.Marshal(original)
+	json.Unmarshal(data, &doc)
+
+	var err error
+	if mediaType == mergePatchType {
+		doc, err = patch.Merge(doc, body)
+	} else {
+		doc, err = patch.Apply(doc, body)
+	}
+	if err != nil {
+		return TaskDocument{}, err
+	}
+
+	data, err = json.Marshal(doc)
+	if err != nil {
+		return TaskDocument{}, err
+	}
+
+	var patched TaskDocument
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&patched); err != nil {
+		return TaskDocument{}, fmt.Errorf("%w: %s", errInvalidTaskDocument, err)
+	}
+
+	if patched.ID != original.ID || patched.Version != original.Version {
+		return TaskDocument{}, fmt.Errorf("%w: id and version are read-only", errInvalidTaskDocument)
+	}
+	if err := binding.Validator.ValidateStruct(patched); err != nil {
+		return TaskDocument{}, fmt.Errorf("%w: %s", errInvalidTaskDocument, err)
+	}
+	return patched, nil
+}
+
+// changedTaskFields lists the columns whose value differs after the patch.
+func changedTaskFields(before, after TaskDocument) map[string]interface{} {
+
+	updates := map[string]interface{}{}
+	if after.Title != before.Title {
+		updates["title"] = after.Title
+	}
+	if after.Description != before.Description {
+		updates["description"] = after.Description
+	}
+	if after.Priority != before.Priority {
+		updates["priority"] = after.Priority
+	}
+	if after.Status != before.Status {
+		updates["status"] = after.Status
+	}
+	if !sameTime(after.DueAt, before.DueAt) {
+		updates["due_at"] = after.DueAt
+	}
+	if auditValue(after.EstimatedMinutes) != auditValue(before.EstimatedMinutes) {
+		updates["estimated_minutes"] = after.EstimatedMinutes
+	}
+	return updates
+}
+
+// changedCustomFields turns the patched custom field values into the input
+// of mergeCustomFields: changed values, and null for removed ones.
+func changedCustomFields(before, after map[string]any) map[string]any {
+
+	changes := map[string]any{}
+	for key, value := range after {
+		if !reflect.DeepEqual(before[key], value) {
+			changes[key] = value
+		}
+	}
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			changes[key] = nil
+		}
+	}
+	return changes
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
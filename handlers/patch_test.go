@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func patchTask(t *testing.T, db *gorm.DB, taskID uint, contentType, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("user_id", uint(1))
+	c.Request = httptest.NewRequest(http.MethodPatch, fmt.Sprintf("/api/task/%d", taskID), bytes.NewBufferString(body))
+	c.Request.Header.Set("Content-Type", contentType)
+	c.Params = gin.Params{{Key: "id", Value: fmt.Sprint(taskID)}}
+	PatchTask(db)(c)
+	return w
+}
+
+func TestPatchTask_MergePatch(t *testing.T) {
+	db := setupTestDB(t)
+	task := model.Task{Title: "Write report", Description: "Q3 numbers", Priority: 7, UserID: 1}
+	require.NoError(t, db.Create(&task).Error)
+
+	w := patchTask(t, db, task.ID, "application/merge-patch+json", `{"title": "Write the report"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var stored model.Task
+	db.First(&stored, task.ID)
+	assert.Equal(t, "Write the report", stored.Title)
+	assert.Equal(t, 7, stored.Priority, "absent fields are kept")
+	assert.Equal(t, "Q3 numbers", stored.Description)
+
+	w = patchTask(t, db, task.ID, "application/merge-patch+json", `{"description": null, "priority": 0}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	db.First(&stored, task.ID)
+	assert.Empty(t, stored.Description, "null clears the description")
+	assert.Equal(t, 0, stored.Priority)
+
+	var events []model.TaskEvent
+	db.Where("task_id = ?", task.ID).Order("id").Find(&events)
+	require.Len(t, events, 3, "only changed fields are recorded")
+	assert.Equal(t, []string{"title", "description", "priority"}, []string{events[0].Field, events[1].Field, events[2].Field})
+
+	versionBefore := stored.Version
+	w = patchTask(t, db, task.ID, "application/merge-patch+json", `{"title": "Write the report"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	db.First(&stored, task.ID)
+	assert.Equal(t, versionBefore, stored.Version, "a no-op patch writes nothing")
+}
+
+func TestPatchTask_JSONPatch(t *testing.T) {
+	db := setupTestDB(t)
+	task := model.Task{Title: "Write report", Priority: 3, UserID: 1}
+	require.NoError(t, db.Create(&task).Error)
+
+	w := patchTask(t, db, task.ID, "application/json-patch+json", `[
+		{"op": "test", "path": "/version", "value": 1},
+		{"op": "replace", "path": "/status", "value": "in_progress"},
+		{"op": "copy", "from": "/title", "path": "/description"}
+	]`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var stored model.Task
+	db.First(&stored, task.ID)
+	assert.Equal(t, "in_progress", stored.Status)
+	assert.Equal(t, "Write report", stored.Description)
+	assert.Equal(t, 3, stored.Priority)
+
+	w = patchTask(t, db, task.ID, "application/json-patch+json", `[{"op": "test", "path": "/version", "value": 1}]`)
+	assert.Equal(t, http.StatusConflict, w.Code, "the version moved on")
+}
+
+func TestPatchTask_Rejects(t *testing.T) {
+	db := setupTestDB(t)
+	task := model.Task{Title: "Write report", UserID: 1}
+	require.NoError(t, db.Create(&task).Error)
+
+	cases := []struct {
+		contentType, body string
+		code              int
+	}{
+		{"application/json", `{"title": "Something else"}`, http.StatusUnsupportedMediaType},
+		{"application/merge-patch+json", `{"title": `, http.StatusBadRequest},
+		{"application/merge-patch+json", `{"priority": 11}`, http.StatusUnprocessableEntity},
+		{"application/merge-patch+json", `{"title": null}`, http.StatusUnprocessableEntity},
+		{"application/merge-patch+json", `{"user_id": 2}`, http.StatusUnprocessableEntity},
+		{"application/merge-patch+json", `{"id": 99}`, http.StatusUnprocessableEntity},
+		{"application/json-patch+json", `[{"op": "replace", "path": "/priority", "value": "high"}]`, http.StatusUnprocessableEntity},
+		{"application/json-patch+json", `[{"op": "remove", "path": "/nope"}]`, http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		w := patchTask(t, db, task.ID, c.contentType, c.body)
+		assert.Equal(t, c.code, w.Code, "%s %s: %s", c.contentType, c.body, w.Body.String())
+	}
+
+	var stored model.Task
+	db.First(&stored, task.ID)
+	assert.Equal(t, uint(1), stored.Version)
+}
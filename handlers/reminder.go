@@ -0,0 +1,406 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/Niraj1910/Task-REST-APIs/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+const (
+	// maxRemindersPerTask caps the reminders one user sets on a task.
+	maxRemindersPerTask = 10
+	// reminderBatch is how many reminders one run sends at most.
+	reminderBatch = 100
+	// staleReminder drops reminders of tasks that have been due for longer,
+	// e.g. after downtime; the digest covers overdue tasks.
+	staleReminder = 24 * time.Hour
+)
+
+// dueTimeLayout formats due times in emails, in the recipient's time zone.
+const dueTimeLayout = "Mon, 02 Jan 2006 15:04 MST"
+
+// The mail senders are variables so tests can count the mails.
+var (
+	sendReminderMail = utils.SendReminderMail
+	sendDigestMail   = utils.SendDigestMail
+)
+
+type ReminderBody struct {
+	// MinutesBefore is how long before the due time to send the email.
+	MinutesBefore *int `json:"minutes_before" binding:"required,gte=0,lte=43200"`
+}
+
+// CreateReminder godoc
+// @Summary      Add a due-date reminder
+// @Description  Emails the user minutes_before the task's due time, e.g. 60 for one hour before. The reminder follows
+// @Description  later changes of the due date. Each reminder is sent at most once.
+// @Tags         Reminders
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id   path int true "Task ID"
+// @Param        body body handlers.ReminderBody true "Reminder"
+// @Success      201 {object} map[string]interface{} "Created reminder"
+// @Failure      400 {object} map[string]string "Invalid input, no due date or time already passed"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      404 {object} map[string]string "Task not found"
+// @Router       /api/task/{id}/reminders [post]
+func CreateReminder(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		taskID, ok := parseIDParam(ctx, "id", "Task ID")
+		if !ok {
+			return
+		}
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		var input ReminderBody
+		if err := ctx.ShouldBindJSON(&input); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid input", "details": err.Error()})
+			return
+		}
+
+		task, err := findVisibleTask(db, taskID, userID)
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found or not visible to you"})
+			return
+		}
+		if task.DueAt == nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Task has no due date"})
+			return
+		}
+
+		remindAt := task.DueAt.Add(-time.Duration(*input.MinutesBefore) * time.Minute)
+		if !remindAt.After(time.Now()) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "That reminder time has already passed"})
+			return
+		}
+
+		var count int64
+		db.Model(&model.TaskReminder{}).Where("task_id = ? AND user_id = ?", taskID, userID).Count(&count)
+		if count >= maxRemindersPerTask {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("A task can have at most %d reminders per user", maxRemindersPerTask)})
+			return
+		}
+
+		reminder := model.TaskReminder{
+			TaskID:        taskID,
+			UserID:        userID,
+			MinutesBefore: *input.MinutesBefore,
+			RemindAt:      remindAt.UTC(),
+		}
+		if err := db.Create(&reminder).Error; err != nil {
+			log.Error().Err(err).Uint("task_id", taskID).Msg("Failed to create reminder")
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create reminder"})
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, reminderResponse(reminder))
+	}
+}
+
+// GetReminders godoc
+// @Summary      List reminders of a task
+// @Description  Returns the user's reminders on a task, soonest first
+// @Tags         Reminders
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id path int true "Task ID"
+// @Success      200 {object} map[string]interface{} "reminders"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      404 {object} map[string]string "Task not found"
+// @Router       /api/task/{id}/reminders [get]
+func GetReminders(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		taskID, ok := parseIDParam(ctx, "id", "Task ID")
+		if !ok {
+			return
+		}
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		if _, err := findVisibleTask(db, taskID, userID); err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found or not visible to you"})
+			return
+		}
+
+		var reminders []model.TaskReminder
+		err := db.Where("task_id = ? AND user_id = ?", taskID, userID).Order("remind_at, id").Find(&reminders).Error
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve reminders"})
+			return
+		}
+
+		items := make([]gin.H, 0, len(reminders))
+		for _, reminder := range reminders {
+			items = append(items, reminderResponse(reminder))
+		}
+		ctx.JSON(http.StatusOK, gin.H{"reminders": items})
+	}
+}
+
+// DeleteReminder godoc
+// @Summary      Delete a reminder
+// @Tags         Reminders
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id         path int true "Task ID"
+// @Param        reminderId path int true "Reminder ID"
+// @Success      200 {object} map[string]string "Reminder deleted"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      404 {object} map[string]string "Reminder not found"
+// @Router       /api/task/{id}/reminders/{reminderId} [delete]
+func DeleteReminder(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		taskID, ok := parseIDParam(ctx, "id", "Task ID")
+		if !ok {
+			return
+		}
+		reminderID, ok := parseIDParam(ctx, "reminderId", "Reminder ID")
+		if !ok {
+			return
+		}
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		result := db.Where("id = ? AND task_id = ? AND user_id = ?", reminderID, taskID, userID).Delete(&model.TaskReminder{})
+		if result.Error != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete reminder"})
+			return
+		}
+		if result.RowsAffected == 0 {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Reminder not found"})
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{"message": "Reminder deleted"})
+	}
+}
+
+// rescheduleReminders moves the reminders of a task to a new due date.
+// Reminders that were sent fire again if their new time is still ahead.
+func rescheduleReminders(tx *gorm.DB, taskID uint, dueAt *time.Time) error {
+
+	if dueAt == nil {
+		return nil
+	}
+
+	var reminders []model.TaskReminder
+	if err := tx.Where("task_id = ?", taskID).Find(&reminders).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, reminder := range reminders {
+		remindAt := dueAt.Add(-time.Duration(reminder.MinutesBefore) * time.Minute)
+		updates := map[string]interface{}{"remind_at": remindAt.UTC()}
+		if remindAt.After(now) {
+			updates["sent_at"] = nil
+		}
+		if err := tx.Model(&reminder).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SendDueReminders delivers the reminders whose time has come through the
+// user's due_soon channels and returns how many were sent. Every reminder
+// is claimed with a conditional UPDATE before it is sent, so with several
+// instances running each goes out at most once.
+func SendDueReminders(db *gorm.DB, now time.Time) (int, error) {
+
+	var due []struct {
+		ID         uint
+		TaskID     uint
+		UserID     uint
+		Title      string
+		AssigneeID *uint
+		OwnerID    uint
+		DueAt      time.Time
+	}
+	err := db.Model(&model.TaskReminder{}).
+		Select("task_reminders.id, task_reminders.task_id, task_reminders.user_id, tasks.title, tasks.assignee_id, tasks.user_id AS owner_id, tasks.due_at").
+		Joins("JOIN tasks ON tasks.id = task_reminders.task_id AND tasks.deleted_at IS NULL").
+		Where("task_reminders.sent_at IS NULL AND task_reminders.remind_at <= ?", now.UTC()).
+		Where("tasks.due_at > ? AND tasks.status <> ?", now.Add(-staleReminder).UTC(), "completed").
+		Order("task_reminders.remind_at").Limit(reminderBatch).
+		Scan(&due).Error
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, reminder := range due {
+		claim := db.Model(&model.TaskReminder{}).
+			Where("id = ? AND sent_at IS NULL", reminder.ID).
+			Update("sent_at", now.UTC())
+		if claim.Error != nil {
+			return sent, claim.Error
+		}
+		if claim.RowsAffected == 0 {
+			continue // another instance has it
+		}
+
+		// the reminder's owner may have lost access to the task since
+		if reminder.UserID != reminder.OwnerID && (reminder.AssigneeID == nil || *reminder.AssigneeID != reminder.UserID) {
+			continue
+		}
+
+		var user model.User
+		if err := db.Select("id", "name", "email", "time_zone").First(&user, reminder.UserID).Error; err != nil {
+			log.Warn().Err(err).Uint("reminder_id", reminder.ID).Msg("Reminder user not found")
+			continue
+		}
+
+		dueTime := reminder.DueAt.In(userLocation(user)).Format(dueTimeLayout)
+		taskLink := fmt.Sprintf("%s/api/task/%d", appBaseURL(), reminder.TaskID)
+		email := notify(db, model.Notification{
+			UserID: user.ID,
+			Type:   eventDueSoon,
+			TaskID: &reminder.TaskID,
+			Title:  fmt.Sprintf("%q is due %s", reminder.Title, dueTime),
+			Link:   taskLink,
+		})
+		if email {
+			if err := sendReminderMail(user.Name, user.Email, reminder.Title, dueTime, taskLink); err != nil {
+				log.Error().Err(err).Uint("reminder_id", reminder.ID).Msg("Failed to send reminder")
+				continue
+			}
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// SendDigests emails the daily and weekly digests that are due: at the
+// user's digest hour in their time zone, weekly ones on Mondays. A digest
+// with nothing overdue or upcoming is skipped. Users are claimed like
+// reminders, so each digest goes out at most once per period.
+func SendDigests(db *gorm.DB, now time.Time) (int, error) {
+
+	var users []model.User
+	err := db.Select("id", "name", "email", "time_zone", "digest_frequency", "digest_hour", "digest_sent_at").
+		Where("digest_frequency IN ?", []string{"daily", "weekly"}).
+		Find(&users).Error
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, user := range users {
+		start := digestPeriodStart(user, now)
+		if user.DigestSentAt != nil && !user.DigestSentAt.Before(start) {
+			continue
+		}
+
+		claim := db.Model(&model.User{}).
+			Where("id = ? AND (digest_sent_at IS NULL OR digest_sent_at < ?)", user.ID, start.UTC()).
+			Update("digest_sent_at", now.UTC())
+		if claim.Error != nil {
+			return sent, claim.Error
+		}
+		if claim.RowsAffected == 0 {
+			continue
+		}
+
+		ok, err := sendDigest(db, user, now)
+		if err != nil {
+			log.Error().Err(err).Uint("user_id", user.ID).Msg("Failed to send digest")
+			continue
+		}
+		if ok {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// digestPeriodStart returns when the user's current digest period began:
+// the last digest hour in their time zone, on a Monday for weekly digests.
+func digestPeriodStart(user model.User, now time.Time) time.Time {
+
+	local := now.In(userLocation(user))
+	start := time.Date(local.Year(), local.Month(), local.Day(), user.DigestHour, 0, 0, 0, local.Location())
+	if user.DigestFrequency == "weekly" {
+		start = start.AddDate(0, 0, -(int(local.Weekday())+6)%7)
+		if start.After(now) {
+			start = start.AddDate(0, 0, -7)
+		}
+	} else if start.After(now) {
+		start = start.AddDate(0, 0, -1)
+	}
+	return start
+}
+
+// sendDigest emails the user's overdue tasks and those due before the next
+// digest. It reports false when there was nothing to send.
+func sendDigest(db *gorm.DB, user model.User, now time.Time) (bool, error) {
+
+	horizon := now.Add(24 * time.Hour)
+	period := "daily"
+	if user.DigestFrequency == "weekly" {
+		horizon = now.Add(7 * 24 * time.Hour)
+		period = "weekly"
+	}
+
+	var tasks []model.Task
+	err := db.Scopes(visibleTasks(user.ID)).
+		Where("tasks.due_at IS NOT NULL AND tasks.due_at < ? AND tasks.status <> ?", horizon.UTC(), "completed").
+		Order("tasks.due_at").Limit(100).
+		Find(&tasks).Error
+	if err != nil || len(tasks) == 0 {
+		return false, err
+	}
+
+	loc := userLocation(user)
+	var overdue, upcoming []utils.DigestTask
+	for _, task := range tasks {
+		line := utils.DigestTask{
+			Title:   task.Title,
+			DueTime: task.DueAt.In(loc).Format(dueTimeLayout),
+			Link:    fmt.Sprintf("%s/api/task/%d", appBaseURL(), task.ID),
+		}
+		if task.DueAt.Before(now) {
+			overdue = append(overdue, line)
+		} else {
+			upcoming = append(upcoming, line)
+		}
+	}
+
+	return true, sendDigestMail(user.Name, user.Email, period, overdue, upcoming)
+}
+
+func userLocation(user model.User) *time.Location {
+	if loc, err := time.LoadLocation(user.TimeZone); err == nil && user.TimeZone != "" {
+		return loc
+	}
+	return time.UTC
+}
+
+func reminderResponse(reminder model.TaskReminder) gin.H {
+	return gin.H{
+		"id":             reminder.ID,
+		"task_id":        reminder.TaskID,
+		"minutes_before": reminder.MinutesBefore,
+		"remind_at":      reminder.RemindAt,
+		"sent_at":        reminder.SentAt,
+	}
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/Niraj1910/Task-REST-APIs/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReminders_SentOnceAndFollowDueDate(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.Create(&model.User{Name: "Niraj", Email: "niraj@example.com", TimeZone: "Europe/Berlin"}).Error)
+
+	var mails []string
+	sendReminderMail = func(userName, toEmail, taskTitle, dueTime, taskLink string) error {
+		mails = append(mails, toEmail+": "+taskTitle+" at "+dueTime)
+		return nil
+	}
+	t.Cleanup(func() { sendReminderMail = utils.SendReminderMail })
+
+	now := time.Now().Truncate(time.Minute)
+	due := now.Add(2 * time.Hour)
+	task := model.Task{Title: "File the tax return", UserID: 1, DueAt: &due}
+	require.NoError(t, db.Create(&task).Error)
+
+	w := timeRequest(db, CreateReminder, http.MethodPost, "/", `{"minutes_before":60}`, taskParam(task.ID))
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	w = timeRequest(db, CreateReminder, http.MethodPost, "/", `{"minutes_before":180}`, taskParam(task.ID))
+	assert.Equal(t, http.StatusBadRequest, w.Code, "that time has passed")
+
+	sent, err := SendDueReminders(db, now)
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+
+	sent, err = SendDueReminders(db, now.Add(61*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	sent, err = SendDueReminders(db, now.Add(62*time.Minute))
+	require.NoError(t, err)
+	assert.Zero(t, sent, "a reminder is sent once")
+	require.Len(t, mails, 1)
+	assert.Contains(t, mails[0], due.In(userLocation(model.User{TimeZone: "Europe/Berlin"})).Format(dueTimeLayout))
+
+	// postponing the task re-arms the reminder
+	c, w := setupContext(http.MethodPut, "/", fmt.Sprintf(`{"due_at":%q}`, due.Add(3*time.Hour).Format(time.RFC3339)), 1)
+	c.Params = taskParam(task.ID)
+	UpdateTask(db)(c)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	sent, err = SendDueReminders(db, now.Add(61*time.Minute))
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+	sent, err = SendDueReminders(db, now.Add(4*time.Hour+time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+}
+
+func TestReminders_ClaimedByOneInstance(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.Create(&model.User{Name: "Niraj", Email: "niraj@example.com"}).Error)
+
+	due := time.Now().Add(time.Hour).UTC()
+	task := model.Task{Title: "Renew the passport", UserID: 1, DueAt: &due}
+	require.NoError(t, db.Create(&task).Error)
+	reminder := model.TaskReminder{TaskID: task.ID, UserID: 1, MinutesBefore: 60, RemindAt: due.Add(-time.Hour)}
+	require.NoError(t, db.Create(&reminder).Error)
+
+	// another instance claims the reminder while this one is sending
+	var sent int
+	sendReminderMail = func(userName, toEmail, taskTitle, dueTime, taskLink string) error {
+		sent++
+		other, err := SendDueReminders(db, time.Now())
+		require.NoError(t, err)
+		assert.Zero(t, other)
+		return nil
+	}
+	t.Cleanup(func() { sendReminderMail = utils.SendReminderMail })
+
+	n, err := SendDueReminders(db, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, 1, sent)
+}
+
+func TestDigests_SentAtLocalHourOncePerPeriod(t *testing.T) {
+	db := setupTestDB(t)
+
+	// 08:00 in Kolkata is 02:30 UTC
+	enabled := time.Date(2026, 3, 9, 12, 0, 0, 0, time.UTC)
+	users := []model.User{
+		{Name: "Niraj", Email: "niraj@example.com", TimeZone: "Asia/Kolkata", DigestFrequency: "daily", DigestHour: 8, DigestSentAt: &enabled},
+		{Name: "Asha", Email: "asha@example.com", TimeZone: "Asia/Kolkata", DigestFrequency: "weekly", DigestHour: 8, DigestSentAt: &enabled},
+		{Name: "Ravi", Email: "ravi@example.com", DigestFrequency: "off"},
+	}
+	require.NoError(t, db.Create(&users).Error)
+
+	at := func(day, hour, minute int) time.Time { return time.Date(2026, 3, day, hour, minute, 0, 0, time.UTC) }
+	overdue, soon, later := at(9, 18, 0), at(10, 20, 0), at(14, 9, 0)
+	tasks := []model.Task{
+		{Title: "Overdue", UserID: 1, DueAt: &overdue},
+		{Title: "Soon", UserID: 1, DueAt: &soon},
+		{Title: "Later", UserID: 1, DueAt: &later},
+		{Title: "Done", UserID: 1, DueAt: &overdue, Status: "completed"},
+		{Title: "Asha's", UserID: 2, DueAt: &later},
+		{Title: "Ravi's", UserID: 3, DueAt: &overdue},
+	}
+	require.NoError(t, db.Create(&tasks).Error)
+
+	type digest struct {
+		email, period     string
+		overdue, upcoming []string
+	}
+	var mails []digest
+	sendDigestMail = func(userName, toEmail, period string, overdue, upcoming []utils.DigestTask) error {
+		d := digest{email: toEmail, period: period}
+		for _, task := range overdue {
+			d.overdue = append(d.overdue, task.Title)
+		}
+		for _, task := range upcoming {
+			d.upcoming = append(d.upcoming, task.Title)
+		}
+		mails = append(mails, d)
+		return nil
+	}
+	t.Cleanup(func() { sendDigestMail = utils.SendDigestMail })
+
+	// Tuesday 10 March, 07:59 in Kolkata
+	sent, err := SendDigests(db, at(10, 2, 29))
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+
+	sent, err = SendDigests(db, at(10, 2, 30))
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent, "weekly digests go out on Mondays")
+	sent, err = SendDigests(db, at(10, 3, 0))
+	require.NoError(t, err)
+	assert.Zero(t, sent, "one digest per day")
+
+	require.Len(t, mails, 1)
+	assert.Equal(t, digest{
+		email:    "niraj@example.com",
+		period:   "daily",
+		overdue:  []string{"Overdue"},
+		upcoming: []string{"Soon"},
+	}, mails[0])
+
+	// Monday 16 March
+	sent, err = SendDigests(db, at(16, 2, 30))
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent)
+	assert.Equal(t, "weekly", mails[len(mails)-1].period)
+}
@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"html"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/Niraj1910/Task-REST-APIs/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Highlight markers used inside the database. They are private-use runes so
+// they cannot clash with task text; the response turns them into <mark> tags
+// after HTML-escaping the snippet.
+const (
+	highlightStart = "\uE000"
+	highlightEnd   = "\uE001"
+)
+
+// searchHit is a task matched by SearchTasks with its relevance and
+// highlighted title and description fragments.
+type searchHit struct {
+	model.Task
+	Rank           float64
+	TitleHighlight string
+	Snippet        string
+}
+
+// SearchTasks godoc
+// @Summary      Search tasks
+// @Description  Full-text search over the title and description of the authenticated user's tasks, ranked by relevance (title matches weigh more) with highlighted snippets.
+// @Description  Combines with the status filter and the created_at/priority sort options of GET /api/task.
+// @Tags         Tasks
+// @Security     BearerAuth
+// @Produce      json
+// @Param        q      query string true  "Search text"
+// @Param        status query string false "Filter by status"
+// @Param        sort   query string false "relevance (default), created_at:desc, created_at:asc, priority:desc or priority:asc"
+// @Param        page   query int    false "Page number"    default(1)
+// @Param        limit  query int    false "Items per page" default(10)
+// @Success      200 {object} map[string]interface{} "Ranked results with pagination meta"
+// @Failure      400 {object} map[string]string "Missing or invalid query"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      500 {object} map[string]string "Server error"
+// @Router       /api/task/search [get]
+func SearchTasks(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		text := strings.TrimSpace(ctx.Query("q"))
+		if text == "" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Query parameter q is required"})
+			return
+		}
+
+		filter, err := taskFilterFromQuery(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		order, ok := searchOrder(ctx.DefaultQuery("sort", "relevance"))
+		if !ok {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported sort option"})
+			return
+		}
+
+		page, limit, offset := pageParams(ctx)
+
+		fields, err := taskFieldsFor(db, userID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve custom fields"})
+			return
+		}
+		base, err := filter.applyAt(db.Table("tasks").Where("tasks.user_id = ? AND tasks.deleted_at IS NULL", userID), fields, userNow(db, userID))
+		if err != nil {
+			invalidFilter(ctx, err)
+			return
+		}
+
+		var hits []searchHit
+		var total int64
+
+		switch db.Dialector.Name() {
+		case "postgres":
+			hits, total, err = searchPostgres(base, text, order, limit, offset)
+		case "sqlite":
+			hits, total, err = searchSQLite(db, base, text, order, limit, offset)
+		default:
+			ctx.JSON(http.StatusNotImplemented, gin.H{"error": "Search is not available on this database"})
+			return
+		}
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search tasks", "details": err.Error()})
+			return
+		}
+
+		results := make([]gin.H, 0, len(hits))
+		for _, hit := range hits {
+			results = append(results, gin.H{
+				"task":            hit.Task,
+				"rank":            hit.Rank,
+				"title_highlight": renderHighlight(hit.TitleHighlight),
+				"snippet":         renderHighlight(hit.Snippet),
+			})
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"results": results,
+			"meta": gin.H{
+				"total": total,
+				"page":  page,
+				"limit": limit,
+				"query": text,
+			},
+		})
+	}
+}
+
+// searchOrder maps the sort parameter to an ORDER BY clause. An empty clause
+// means relevance.
+func searchOrder(sort string) (string, bool) {
+	switch sort {
+	case "relevance":
+		return "", true
+	case "created_at:desc":
+		return "tasks.created_at DESC", true
+	case "created_at:asc":
+		return "tasks.created_at ASC", true
+	case "priority:desc":
+		return "tasks.priority DESC", true
+	case "priority:asc":
+		return "tasks.priority ASC", true
+	}
+	return "", false
+}
+
+func searchPostgres(base *gorm.DB, text, order string, limit, offset int) ([]searchHit, int64, error) {
+
+	const tsQuery = "websearch_to_tsquery('english', ?)"
+	options := `StartSel="` + highlightStart + `", StopSel="` + highlightEnd + `"`
+
+	query := base.Where("tasks.search_vector @@ "+tsQuery, text)
+
+	var total int64
+	err := query.Session(&gorm.Session{}).Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if order == "" {
+		order = "rank DESC"
+	}
+
+	var hits []searchHit
+	err = query.Select(
+		"tasks.*, ts_rank(tasks.search_vector, "+tsQuery+") AS rank, "+
+			"ts_headline('english', tasks.title, "+tsQuery+", ?) AS title_highlight, "+
+			"ts_headline('english', coalesce(tasks.description, ''), "+tsQuery+", ?) AS snippet",
+		text, text, options+", HighlightAll=true", text, options+", MaxFragments=2, MaxWords=20, MinWords=5",
+	).Order(order).Limit(limit).Offset(offset).Scan(&hits).Error
+	return hits, total, err
+}
+
+func searchSQLite(db, base *gorm.DB, text, order string, limit, offset int) ([]searchHit, int64, error) {
+
+	match := ftsMatchExpression(text)
+	if match == "" {
+		return nil, 0, nil
+	}
+
+	query := base.Joins("JOIN tasks_fts ON tasks_fts.rowid = tasks.id").Where("tasks_fts MATCH ?", match)
+
+	var total int64
+	err := query.Session(&gorm.Session{}).Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var definition string
+	db.Raw(`SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'tasks_fts'`).Scan(&definition)
+
+	var hits []searchHit
+
+	if strings.Contains(strings.ToLower(definition), "fts5") {
+		if order == "" {
+			// bm25 is lower for better matches
+			order = "rank DESC"
+		}
+		err = query.Select(
+			"tasks.*, -bm25(tasks_fts, 10.0, 1.0) AS rank, "+
+				"highlight(tasks_fts, 0, ?, ?) AS title_highlight, "+
+				"snippet(tasks_fts, 1, ?, ?, '…', 16) AS snippet",
+			highlightStart, highlightEnd, highlightStart, highlightEnd,
+		).Order(order).Limit(limit).Offset(offset).Scan(&hits).Error
+		return hits, total, err
+	}
+
+	// FTS4 has no ranking function of its own; config.SQLiteDriver adds one
+	if order == "" {
+		order = "rank DESC"
+	}
+	err = query.Select(
+		"tasks.*, fts4_rank(matchinfo(tasks_fts, 'pcx'), 10.0, 1.0) AS rank, "+
+			"snippet(tasks_fts, ?, ?, '', 0, 64) AS title_highlight, "+
+			"snippet(tasks_fts, ?, ?, '…', 1, 16) AS snippet",
+		highlightStart, highlightEnd, highlightStart, highlightEnd,
+	).Order(order).Limit(limit).Offset(offset).Scan(&hits).Error
+	return hits, total, err
+}
+
+// ftsKeywords are operators when written bare in a MATCH expression.
+var ftsKeywords = map[string]bool{"AND": true, "OR": true, "NOT": true, "NEAR": true}
+
+// ftsMatchExpression turns free text into a safe SQLite MATCH expression:
+// every word must match and is quoted so it can't act as an operator; the
+// last one is matched as a prefix so search-as-you-type works.
+func ftsMatchExpression(text string) string {
+
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for i, word := range words {
+		last := i == len(words)-1
+		switch {
+		case last && !ftsKeywords[word]:
+			// bare word prefix query, understood by FTS4 and FTS5
+			words[i] = word + "*"
+		default:
+			words[i] = `"` + word + `"`
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// renderHighlight HTML-escapes a fragment and turns the highlight markers
+// into <mark> tags.
+func renderHighlight(fragment string) string {
+	escaped := html.EscapeString(fragment)
+	escaped = strings.ReplaceAll(escaped, highlightStart, "<mark>")
+	return strings.ReplaceAll(escaped, highlightEnd, "</mark>")
+}
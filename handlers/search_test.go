@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type searchResponse struct {
+	Results []struct {
+		Task           model.Task `json:"task"`
+		Rank           float64    `json:"rank"`
+		TitleHighlight string     `json:"title_highlight"`
+		Snippet        string     `json:"snippet"`
+	} `json:"results"`
+	Meta struct {
+		Total int64 `json:"total"`
+	} `json:"meta"`
+}
+
+func TestSearchTasks_RanksAndHighlights(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.Create(&[]model.Task{
+		{Title: "Buy groceries", Description: "Milk, eggs and a <b>report</b> card", UserID: 1},
+		{Title: "Quarterly report", Description: "Finish the report for Q3", UserID: 1, Status: "in_progress"},
+		{Title: "Someone else's report", UserID: 2},
+		{Title: "Call plumber", UserID: 1},
+	}).Error)
+
+	c, w := setupContext(http.MethodGet, "/api/task/search?q=report", "", 1)
+	SearchTasks(db)(c)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp searchResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+
+	require.Len(t, resp.Results, 2)
+	assert.Equal(t, int64(2), resp.Meta.Total)
+	assert.Equal(t, "Quarterly report", resp.Results[0].Task.Title, "title matches rank first")
+	assert.Greater(t, resp.Results[0].Rank, resp.Results[1].Rank)
+	assert.Equal(t, "Quarterly <mark>report</mark>", resp.Results[0].TitleHighlight)
+	assert.Contains(t, resp.Results[1].Snippet, "&lt;b&gt;<mark>report</mark>&lt;/b&gt;")
+}
+
+func TestSearchTasks_RanksAcrossPages(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.Create(&[]model.Task{
+		{Title: "Old report", UserID: 1},
+		{Title: "Draft", Description: "report", UserID: 1},
+		{Title: "Review", Description: "report", UserID: 1},
+	}).Error)
+	// the best match is the oldest
+	require.NoError(t, db.Model(&model.Task{}).Where("title = ?", "Old report").
+		UpdateColumn("updated_at", time.Now().Add(-time.Hour)).Error)
+
+	search := func(page int) searchResponse {
+		c, w := setupContext(http.MethodGet, fmt.Sprintf("/api/task/search?q=report&limit=2&page=%d", page), "", 1)
+		SearchTasks(db)(c)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp searchResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp
+	}
+
+	first := search(1)
+	assert.Equal(t, int64(3), first.Meta.Total)
+	require.Len(t, first.Results, 2)
+	assert.Equal(t, "Old report", first.Results[0].Task.Title)
+	assert.Len(t, search(2).Results, 1, "later pages are ranked as well")
+}
+
+func TestSearchTasks_UpdatesAndFilters(t *testing.T) {
+	db := setupTestDB(t)
+	task := model.Task{Title: "Draft proposal", UserID: 1}
+	require.NoError(t, db.Create(&task).Error)
+	require.NoError(t, db.Create(&model.Task{Title: "Proposal review", UserID: 1, Status: "completed"}).Error)
+
+	// the index follows updates and soft deletes
+	require.NoError(t, db.Model(&task).Update("title", "Draft budget").Error)
+
+	search := func(url string) searchResponse {
+		c, w := setupContext(http.MethodGet, url, "", 1)
+		SearchTasks(db)(c)
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp searchResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp
+	}
+
+	assert.Len(t, search("/api/task/search?q=budg").Results, 1, "prefix match on the last word")
+	assert.Len(t, search("/api/task/search?q=proposal").Results, 1)
+	assert.Len(t, search("/api/task/search?q=proposal&status=pending").Results, 0)
+
+	require.NoError(t, db.Delete(&task).Error)
+	assert.Len(t, search("/api/task/search?q=budget").Results, 0)
+
+	// FTS syntax in user input is treated as plain words
+	assert.Len(t, search(`/api/task/search?q=review"+(`).Results, 1)
+	assert.Len(t, search(`/api/task/search?q=proposal+OR`).Results, 0, "OR is a word, not an operator")
+}
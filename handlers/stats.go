@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/Niraj1910/Task-REST-APIs/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// PriorityCount is the number of tasks with one priority.
+type PriorityCount struct {
+	Priority int   `json:"priority"`
+	Count    int64 `json:"count"`
+}
+
+// TaskStatsPeriod is one day or week of task activity.
+type TaskStatsPeriod struct {
+	// Period is the day, or the Monday starting the week, as YYYY-MM-DD.
+	Period    string `json:"period"`
+	Created   int64  `json:"created"`
+	Completed int64  `json:"completed"`
+}
+
+// TaskStreaks counts consecutive days on which a task was completed.
+type TaskStreaks struct {
+	// Current ends today, or yesterday while nothing is completed today yet.
+	Current int `json:"current"`
+	Longest int `json:"longest"`
+}
+
+// TaskStats summarises the tasks a user created or is assigned to.
+type TaskStats struct {
+	From           string           `json:"from"`
+	To             string           `json:"to"`
+	Group          string           `json:"group"`
+	TimeZone       string           `json:"tz"`
+	Total          int64            `json:"total"`
+	ByStatus       map[string]int64 `json:"by_status"`
+	ByPriority     []PriorityCount  `json:"by_priority"`
+	Completed      int64            `json:"completed"`
+	CompletionRate float64          `json:"completion_rate"`
+	Overdue        int64            `json:"overdue"`
+	// AverageCompletionHours is the mean time from creation to completion,
+	// null without completed tasks.
+	AverageCompletionHours *float64          `json:"average_completion_hours"`
+	Activity               []TaskStatsPeriod `json:"activity"`
+	Streaks                TaskStreaks       `json:"streaks"`
+}
+
+// GetTaskStats godoc
+// @Summary      Task statistics
+// @Description  Counts the user's tasks (created or assigned, not in the trash) by status and priority, with the completion
+// @Description  rate, overdue tasks and the average time to complete. activity lists the tasks created and completed per
+// @Description  day or week between from and to; streaks count consecutive days with a completed task.
+// @Tags         Users
+// @Security     BearerAuth
+// @Produce      json
+// @Param        from  query string false "First day, YYYY-MM-DD (default: 6 days before to)"
+// @Param        to    query string false "Last day, YYYY-MM-DD (default: today)"
+// @Param        group query string false "day (default) or week"
+// @Param        tz    query string false "IANA time zone of the days (default: the user's time zone, else UTC)"
+// @Success      200 {object} handlers.TaskStats
+// @Failure      400 {object} map[string]string "Invalid range, group or time zone"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Router       /api/user/stats [get]
+func GetTaskStats(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		group := ctx.DefaultQuery("group", "day")
+		if group != "day" && group != "week" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "group must be day or week"})
+			return
+		}
+
+		period, err := dateRangeFromQuery(ctx, true, userZone(db, userID))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		stats, err := buildTaskStats(db, userID, period, group, time.Now())
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute task statistics", "details": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusOK, stats)
+	}
+}
+
+// buildTaskStats runs the aggregates in the database; only one row per
+// status, priority, day or completion day comes back.
+func buildTaskStats(db *gorm.DB, userID uint, period dateRange, group string, now time.Time) (TaskStats, error) {
+
+	tasks := func() *gorm.DB {
+		return db.Model(&model.Task{}).Scopes(visibleTasks(userID))
+	}
+
+	stats := TaskStats{
+		From:       period.from,
+		To:         period.to,
+		Group:      group,
+		TimeZone:   period.loc.String(),
+		ByStatus:   map[string]int64{"pending": 0, "in_progress": 0, "completed": 0},
+		ByPriority: []PriorityCount{},
+		Activity:   []TaskStatsPeriod{},
+	}
+
+	duration, err := secondsBetweenExpr(db, "tasks.created_at", "tasks.completed_at")
+	if err != nil {
+		return stats, err
+	}
+	var totals struct {
+		Total      int64
+		Completed  int64
+		Overdue    int64
+		AvgSeconds *float64
+	}
+	err = tasks().Select(`COUNT(*) AS total,
+		COALESCE(SUM(CASE WHEN tasks.status = 'completed' THEN 1 ELSE 0 END), 0) AS completed,
+		COALESCE(SUM(CASE WHEN tasks.status <> 'completed' AND tasks.due_at < ? THEN 1 ELSE 0 END), 0) AS overdue,
+		AVG(CASE WHEN tasks.status = 'completed' AND tasks.completed_at IS NOT NULL THEN `+duration+` END) AS avg_seconds`, now.UTC()).
+		Scan(&totals).Error
+	if err != nil {
+		return stats, err
+	}
+	stats.Total, stats.Completed, stats.Overdue = totals.Total, totals.Completed, totals.Overdue
+	if totals.Total > 0 {
+		stats.CompletionRate = math.Round(float64(totals.Completed)/float64(totals.Total)*10000) / 10000
+	}
+	if totals.AvgSeconds != nil {
+		hours := math.Round(*totals.AvgSeconds/36) / 100
+		stats.AverageCompletionHours = &hours
+	}
+
+	var statuses []struct {
+		Status string
+		Count  int64
+	}
+	if err := tasks().Select("tasks.status, COUNT(*) AS count").Group("tasks.status").Scan(&statuses).Error; err != nil {
+		return stats, err
+	}
+	for _, row := range statuses {
+		stats.ByStatus[row.Status] = row.Count
+	}
+
+	err = tasks().Select("tasks.priority, COUNT(*) AS count").Group("tasks.priority").Order("tasks.priority").Scan(&stats.ByPriority).Error
+	if err != nil {
+		return stats, err
+	}
+
+	created, err := countPerDay(tasks(), "tasks.created_at", period)
+	if err != nil {
+		return stats, err
+	}
+	completed, err := countPerDay(tasks().Where("tasks.status = ?", "completed"), "tasks.completed_at", period)
+	if err != nil {
+		return stats, err
+	}
+	byPeriod := map[string]*TaskStatsPeriod{}
+	for day := period.start; day.Before(period.end); day = day.AddDate(0, 0, 1) {
+		key := statsPeriod(day, group)
+		p := byPeriod[key]
+		if p == nil {
+			p = &TaskStatsPeriod{Period: key}
+			byPeriod[key] = p
+		}
+		p.Created += created[day.Format(time.DateOnly)]
+		p.Completed += completed[day.Format(time.DateOnly)]
+	}
+	for _, p := range byPeriod {
+		stats.Activity = append(stats.Activity, *p)
+	}
+	sort.Slice(stats.Activity, func(i, j int) bool { return stats.Activity[i].Period < stats.Activity[j].Period })
+
+	// streaks look at all completions, not just the range
+	dayExpr, args, err := localDayExpr(db, "tasks.completed_at", period)
+	if err != nil {
+		return stats, err
+	}
+	var days []struct{ Day string }
+	err = tasks().Select(dayExpr+" AS day", args...).
+		Where("tasks.status = ? AND tasks.completed_at IS NOT NULL", "completed").
+		Group("day").Order("day").
+		Scan(&days).Error
+	if err != nil {
+		return stats, err
+	}
+	completionDays := make([]string, 0, len(days))
+	for _, row := range days {
+		completionDays = append(completionDays, row.Day)
+	}
+	stats.Streaks = completionStreaks(completionDays, now.In(period.loc).Format(time.DateOnly))
+
+	return stats, nil
+}
+
+// countPerDay counts the rows per local day of column within the range.
+func countPerDay(query *gorm.DB, column string, period dateRange) (map[string]int64, error) {
+
+	dayExpr, args, err := localDayExpr(query, column, period)
+	if err != nil {
+		return nil, err
+	}
+	var rows []struct {
+		Day   string
+		Count int64
+	}
+	err = query.Select(dayExpr+" AS day, COUNT(*) AS count", args...).
+		Where(column+" >= ? AND "+column+" < ?", period.start.UTC(), period.end.UTC()).
+		Group("day").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Day] = row.Count
+	}
+	return counts, nil
+}
+
+// localDayExpr is SQL giving the YYYY-MM-DD day of column in the range's
+// time zone. SQLite knows no time zones, so there the offset at the start
+// of the range is used; days across a DST change may be an hour off.
+func localDayExpr(db *gorm.DB, column string, period dateRange) (string, []any, error) {
+
+	switch db.Dialector.Name() {
+	case "postgres":
+		return "to_char(" + column + " AT TIME ZONE ?, 'YYYY-MM-DD')", []any{period.loc.String()}, nil
+	case "sqlite":
+		_, offset := period.start.In(period.loc).Zone()
+		return "date(" + column + ", ?)", []any{fmt.Sprintf("%+d minutes", offset/60)}, nil
+	}
+	return "", nil, errors.New("task statistics are not supported on this database")
+}
+
+// secondsBetweenExpr is SQL giving the seconds from one time column to
+// another.
+func secondsBetweenExpr(db *gorm.DB, from, to string) (string, error) {
+
+	switch db.Dialector.Name() {
+	case "postgres":
+		return "EXTRACT(EPOCH FROM (" + to + " - " + from + "))", nil
+	case "sqlite":
+		return "(julianday(" + to + ") - julianday(" + from + ")) * 86400", nil
+	}
+	return "", errors.New("task statistics are not supported on this database")
+}
+
+// statsPeriod is the day, or the Monday of its week.
+func statsPeriod(day time.Time, group string) string {
+	if group == "week" {
+		day = day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	}
+	return day.Format(time.DateOnly)
+}
+
+// completionStreaks finds runs of consecutive days in the sorted days.
+func completionStreaks(days []string, today string) TaskStreaks {
+
+	var streaks TaskStreaks
+	run := 0
+	var previous time.Time
+	for _, value := range days {
+		day, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			continue
+		}
+		if run > 0 && day.Equal(previous.AddDate(0, 0, 1)) {
+			run++
+		} else {
+			run = 1
+		}
+		previous = day
+		streaks.Longest = max(streaks.Longest, run)
+	}
+
+	current, err := time.Parse(time.DateOnly, today)
+	if err == nil && run > 0 && (previous.Equal(current) || previous.Equal(current.AddDate(0, 0, -1))) {
+		streaks.Current = run
+	}
+	return streaks
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func taskStats(t *testing.T, db *gorm.DB, userID uint, query string) TaskStats {
+	t.Helper()
+	c, w := setupContext(http.MethodGet, "/api/user/stats"+query, "", userID)
+	GetTaskStats(db)(c)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var stats TaskStats
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+	return stats
+}
+
+func TestGetTaskStats(t *testing.T) {
+	db := setupTestDB(t)
+	users := []model.User{{Name: "owner", Email: "owner@example.com"}, {Name: "other", Email: "other@example.com"}}
+	require.NoError(t, db.Create(&users).Error)
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	at := func(days, hours int) *time.Time {
+		t := today.AddDate(0, 0, days).Add(time.Duration(hours) * time.Hour)
+		return &t
+	}
+	task := func(title string, priority int, created *time.Time, completed *time.Time, due *time.Time) model.Task {
+		status := "pending"
+		if completed != nil {
+			status = "completed"
+		}
+		return model.Task{Title: title, Priority: priority, Status: status, UserID: users[0].ID, CompletedAt: completed, DueAt: due,
+			Model: gorm.Model{CreatedAt: *created}}
+	}
+	tasks := []model.Task{
+		// completed on three days in a row up to today, after 26h, 27h and 6h
+		task("a", 1, at(-3, 8), at(-2, 10), nil),
+		task("b", 1, at(-2, 8), at(-1, 11), nil),
+		task("c", 3, at(0, 1), at(0, 7), nil),
+		// an earlier, single completion day
+		task("d", 3, at(-10, 1), at(-9, 1), nil),
+		task("overdue", 5, at(-1, 9), nil, at(-1, 0)),
+		task("upcoming", 5, at(0, 2), nil, at(3, 0)),
+	}
+	require.NoError(t, db.Create(&tasks).Error)
+	// trashed and other users' tasks do not count
+	require.NoError(t, db.Delete(&model.Task{}, tasks[5].ID).Error)
+	require.NoError(t, db.Create(&model.Task{Title: "theirs", UserID: users[1].ID}).Error)
+
+	stats := taskStats(t, db, users[0].ID, "")
+	assert.EqualValues(t, 5, stats.Total)
+	assert.Equal(t, map[string]int64{"pending": 1, "in_progress": 0, "completed": 4}, stats.ByStatus)
+	assert.Equal(t, []PriorityCount{{1, 2}, {3, 2}, {5, 1}}, stats.ByPriority)
+	assert.EqualValues(t, 4, stats.Completed)
+	assert.Equal(t, 0.8, stats.CompletionRate)
+	assert.EqualValues(t, 1, stats.Overdue)
+	require.NotNil(t, stats.AverageCompletionHours)
+	assert.Equal(t, 20.75, *stats.AverageCompletionHours, "(26 + 27 + 6 + 24) / 4")
+	assert.Equal(t, TaskStreaks{Current: 3, Longest: 3}, stats.Streaks)
+
+	require.Len(t, stats.Activity, 7)
+	assert.Equal(t, today.AddDate(0, 0, -6).Format(time.DateOnly), stats.Activity[0].Period)
+	assert.Equal(t, TaskStatsPeriod{Period: today.Format(time.DateOnly), Created: 1, Completed: 1}, stats.Activity[6])
+	assert.Equal(t, TaskStatsPeriod{Period: today.AddDate(0, 0, -1).Format(time.DateOnly), Created: 1, Completed: 1}, stats.Activity[5])
+
+	from := today.AddDate(0, 0, -13).Format(time.DateOnly)
+	weekly := taskStats(t, db, users[0].ID, "?group=week&from="+from)
+	var created, completed int64
+	for _, p := range weekly.Activity {
+		day, err := time.Parse(time.DateOnly, p.Period)
+		require.NoError(t, err)
+		assert.Equal(t, time.Monday, day.Weekday())
+		created += p.Created
+		completed += p.Completed
+	}
+	assert.EqualValues(t, 5, created)
+	assert.EqualValues(t, 4, completed)
+
+	// seen from twelve hours west, task c was created and completed yesterday
+	west := taskStats(t, db, users[0].ID, "?tz=Etc/GMT%2B12&to="+today.AddDate(0, 0, -1).Format(time.DateOnly))
+	assert.Equal(t, TaskStatsPeriod{Period: today.AddDate(0, 0, -1).Format(time.DateOnly), Created: 1, Completed: 1}, west.Activity[6])
+
+	// without tz the days are those of the user's own time zone
+	require.NoError(t, db.Model(&users[0]).Update("time_zone", "Etc/GMT+12").Error)
+	stored := taskStats(t, db, users[0].ID, "?to="+today.AddDate(0, 0, -1).Format(time.DateOnly))
+	assert.Equal(t, "Etc/GMT+12", stored.TimeZone)
+	assert.Equal(t, west.Activity, stored.Activity)
+	assert.Equal(t, "UTC", taskStats(t, db, users[0].ID, "?tz=UTC").TimeZone)
+
+	c, w := setupContext(http.MethodGet, "/api/user/stats?group=month", "", users[0].ID)
+	GetTaskStats(db)(c)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestCompletionStreaks(t *testing.T) {
+	days := []string{"2026-01-01", "2026-01-02", "2026-01-03", "2026-01-05", "2026-01-06"}
+	assert.Equal(t, TaskStreaks{Current: 2, Longest: 3}, completionStreaks(days, "2026-01-07"))
+	assert.Equal(t, TaskStreaks{Current: 0, Longest: 3}, completionStreaks(days, "2026-01-08"))
+	assert.Equal(t, TaskStreaks{}, completionStreaks(nil, "2026-01-08"))
+}
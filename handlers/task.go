@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/Niraj1910/Task-REST-APIs/model"
 	_ "github.com/Niraj1910/Task-REST-APIs/types"
@@ -34,8 +36,13 @@ func CreateTask(db *gorm.DB) gin.HandlerFunc {
 		}
 
 		var taskBody struct {
-			Title       string `json:"title" binding:"required,min=5,max=200"`
-			Description string `json:"description" binding:"required,omitempty,max=1000"`
+			Title            string     `json:"title" binding:"required,min=5,max=200"`
+			Description      string     `json:"description" binding:"required,omitempty,max=1000"`
+			AssigneeID       *uint      `json:"assignee_id"`
+			DueAt            *time.Time `json:"due_at"`
+			EstimatedMinutes *int       `json:"estimated_minutes" binding:"omitempty,gte=0,lte=1000000"`
+			// CustomFields sets values of your custom fields by key.
+			CustomFields map[string]any `json:"custom_fields"`
 		}
 		err := ctx.ShouldBindBodyWithJSON(&taskBody)
 		if err != nil {
@@ -46,36 +53,88 @@ func CreateTask(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
+		customFields, err := mergeCustomFields(db, userID, nil, taskBody.CustomFields)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid input",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		var assignee model.User
+		if taskBody.AssigneeID != nil {
+			err = db.First(&assignee, *taskBody.AssigneeID).Error
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{
+					"error":   "invalid input",
+					"details": "assignee not found",
+				})
+				return
+			}
+		}
+
 		task := model.Task{
-			Title:       taskBody.Title,
-			Description: taskBody.Description,
-			UserID:      userID,
+			Title:            taskBody.Title,
+			Description:      taskBody.Description,
+			UserID:           userID,
+			DueAt:            taskBody.DueAt,
+			EstimatedMinutes: taskBody.EstimatedMinutes,
+			CustomFields:     customFields,
 		}
 
-		err = db.Create(&task).Error
+		err = db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			task.BoardRank, err = bottomRank(tx, userID, "pending")
+			if err != nil {
+				return err
+			}
+			err = tx.Create(&task).Error
+			if err != nil {
+				return err
+			}
+			err = recordTaskEvent(tx, task.ID, userID, "created", "", nil, &task.Title)
+			if err != nil {
+				return err
+			}
+			_, err = changeAssignee(tx, &task, taskBody.AssigneeID, userID)
+			if err != nil {
+				return err
+			}
+			return enqueueTaskWebhooks(tx, eventTaskCreated, task.ID)
+		})
 		if err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create task"})
 			return
 		}
 
-		ctx.JSON(http.StatusCreated, gin.H{"id": task.ID, "title": task.Title, "description": task.Description, "userId": task.UserID})
+		publishTaskEvent(eventTaskCreated, task)
+		if task.AssigneeID != nil {
+			notifyAssignee(db, task, assignee, userID)
+		}
+
+		ctx.JSON(http.StatusCreated, gin.H{"id": task.ID, "title": task.Title, "description": task.Description, "userId": task.UserID, "assigneeId": task.AssigneeID, "dueAt": task.DueAt, "estimatedMinutes": task.EstimatedMinutes, "customFields": task.CustomFields})
 
 	}
 }
 
 // UpdateTask godoc
 // @Summary      Update a task
-// @Description  Updates task fields (partial update allowed) if owned by the user
+// @Description  Updates task fields (partial update allowed) if owned by the user.
+// @Description  Completing a task with open blockers is refused with 409 unless "force" is true.
 // @Tags         Tasks
 // @Security     BearerAuth
 // @Accept       json
 // @Produce      json
 // @Param        id   path int true "Task ID"
+// @Param        If-Match header string false "ETag from GET /api/task/{id}; the update only happens if the task is unchanged"
 // @Param        body body model.Task true "Updated fields"
 // @Success      200 {object} model.Task
 // @Failure      400 {object} map[string]string "Invalid input"
 // @Failure      401 {object} map[string]string "Unauthorized"
 // @Failure      404 {object} map[string]string "Task not found or not owned"
+// @Failure      409 {object} map[string]interface{} "Task is blocked by open tasks"
+// @Failure      412 {object} map[string]string "Task was modified since the given ETag"
 // @Failure      500 {object} map[string]string "Server error"
 // @Router       /api/task/{id} [put]
 func UpdateTask(db *gorm.DB) gin.HandlerFunc {
@@ -94,10 +153,15 @@ func UpdateTask(db *gorm.DB) gin.HandlerFunc {
 		}
 
 		var taskBody struct {
-			Title       string `json:"title" binding:"omitempty,min=5,max=200"`
-			Description string `json:"description" binding:"omitempty,max=1000"`
-			Priority    int    `json:"priority" binding:"omitempty,gte=0,lte=10"`
-			Status      string `json:"status" binding:"omitempty,oneof=pending in_progress completed"`
+			Title            string     `json:"title" binding:"omitempty,min=5,max=200"`
+			Description      string     `json:"description" binding:"omitempty,max=1000"`
+			Priority         *int       `json:"priority" binding:"omitempty,gte=0,lte=10"`
+			Status           string     `json:"status" binding:"omitempty,oneof=pending in_progress completed"`
+			DueAt            *time.Time `json:"due_at"`
+			Force            bool       `json:"force"`
+			EstimatedMinutes *int       `json:"estimated_minutes" binding:"omitempty,gte=0,lte=1000000"`
+			// CustomFields sets values by key; null removes one.
+			CustomFields map[string]any `json:"custom_fields"`
 		}
 
 		err = ctx.ShouldBindBodyWithJSON(&taskBody)
@@ -116,63 +180,162 @@ func UpdateTask(db *gorm.DB) gin.HandlerFunc {
 		if taskBody.Description != "" {
 			updates["description"] = taskBody.Description
 		}
-		if taskBody.Priority >= 0 && taskBody.Priority <= 10 {
-			updates["priority"] = taskBody.Priority
+		if taskBody.Priority != nil {
+			updates["priority"] = *taskBody.Priority
 		}
 		if taskBody.Status != "" {
 			updates["status"] = taskBody.Status
 		}
+		if taskBody.DueAt != nil {
+			updates["due_at"] = taskBody.DueAt
+		}
+		if taskBody.EstimatedMinutes != nil {
+			updates["estimated_minutes"] = taskBody.EstimatedMinutes
+		}
 
-		if len(updates) == 0 {
+		if len(updates) == 0 && len(taskBody.CustomFields) == 0 {
 			ctx.JSON(http.StatusBadRequest, gin.H{"error": "No fields provided to update"})
 			return
 		}
 
-		result := db.Model(&model.Task{}).Where("ID = ? AND user_id = ?", uint(taskID), userID).Updates(updates)
-
-		if result.Error != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{
-				"error":   "Failed to update task",
-				"details": result.Error.Error(),
-			})
+		var current model.Task
+		err = db.Where("id = ? AND user_id = ?", uint(taskID), userID).First(&current).Error
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found or not owned by you"})
 			return
 		}
-
-		if result.RowsAffected == 0 {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found or not owned by you"})
+		if !checkIfMatch(ctx, current) {
 			return
 		}
 
-		var updatedTask model.Task
-		err = db.First(&updatedTask, taskID).Error
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch updated task"})
-			return
+		if len(taskBody.CustomFields) > 0 {
+			updates["custom_fields"], err = mergeCustomFields(db, current.UserID, current.CustomFields, taskBody.CustomFields)
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{
+					"error":   "invalid input",
+					"details": err.Error(),
+				})
+				return
+			}
 		}
 
-		ctx.JSON(http.StatusOK, gin.H{
-			"id":          updatedTask.ID,
-			"title":       updatedTask.Title,
-			"description": updatedTask.Description,
-			"priority":    updatedTask.Priority,
-			"status":      updatedTask.Status,
-			"user_id":     updatedTask.UserID,
+		saveTaskUpdates(ctx, db, current, updates, taskBody.Force, userID)
+	}
+}
+
+// saveTaskUpdates writes the changed fields of an owned task and answers
+// with the updated task. It refuses to complete a blocked task unless forced
+// and honours If-Match atomically.
+func saveTaskUpdates(ctx *gin.Context, db *gorm.DB, current model.Task, updates map[string]interface{}, force bool, userID uint) {
+
+	status, _ := updates["status"].(string)
+	blockers, err := addStatusChange(db, current, status, force, updates)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check task dependencies"})
+		return
+	}
+	if len(blockers) > 0 {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"error":      "Task is blocked by open tasks",
+			"blocked_by": blockers,
+			"details":    "complete the blocking tasks first or send \"force\": true",
+		})
+		return
+	}
+
+	// a task changing column without a position goes to the bottom
+	if status, ok := updates["status"].(string); ok && status != current.Status {
+		if _, ok := updates["board_rank"]; !ok {
+			updates["board_rank"], err = bottomRank(db, userID, status)
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update task"})
+				return
+			}
+		}
+	}
+	updates["version"] = nextVersion
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		query := tx.Model(&model.Task{}).Where("ID = ? AND user_id = ?", current.ID, userID)
+		result := conditionalVersion(ctx, query, current).Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return missedWrite(ctx)
+		}
+		if dueAt, ok := updates["due_at"].(*time.Time); ok {
+			if err := rescheduleReminders(tx, current.ID, dueAt); err != nil {
+				return err
+			}
+		}
+		if err := enqueueTaskWebhooks(tx, eventTaskUpdated, current.ID); err != nil {
+			return err
+		}
+		return recordTaskChanges(tx, current, updates, userID)
+	})
+
+	if errors.Is(err, errVersionConflict) {
+		preconditionFailed(ctx)
+		return
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found or not owned by you"})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to update task",
+			"details": err.Error(),
 		})
+		return
+	}
+
+	var updatedTask model.Task
+	err = db.First(&updatedTask, current.ID).Error
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch updated task"})
+		return
+	}
 
+	publishTaskEvent(eventTaskUpdated, updatedTask)
+
+	ctx.Header("ETag", taskETag(updatedTask))
+	ctx.JSON(http.StatusOK, taskResponse(updatedTask))
+}
+
+// taskResponse is the JSON of a single task.
+func taskResponse(task model.Task) gin.H {
+	return gin.H{
+		"id":                task.ID,
+		"title":             task.Title,
+		"description":       task.Description,
+		"priority":          task.Priority,
+		"status":            task.Status,
+		"user_id":           task.UserID,
+		"assignee_id":       task.AssigneeID,
+		"due_at":            task.DueAt,
+		"version":           task.Version,
+		"board_rank":        task.BoardRank,
+		"estimated_minutes": task.EstimatedMinutes,
+		"parent_id":         task.ParentID,
+		"custom_fields":     task.CustomFields,
 	}
 }
 
 // DeleteTask godoc
 // @Summary      Delete a task
-// @Description  Deletes a task if it belongs to the authenticated user
+// @Description  Moves a task that belongs to the authenticated user to the trash, together with its comments and attachments
 // @Tags         Tasks
 // @Security     BearerAuth
 // @Accept       json
 // @Produce      json
 // @Param        id path int true "Task ID"
+// @Param        If-Match header string false "ETag from GET /api/task/{id}; the task is only deleted if unchanged"
 // @Success      200 {object} map[string]string "Task deleted"
 // @Failure      401 {object} map[string]string "Unauthorized"
 // @Failure      404 {object} map[string]string "Task not found or not owned"
+// @Failure      412 {object} map[string]string "Task was modified since the given ETag"
 // @Router       /api/task/{id} [delete]
 func DeleteTask(db *gorm.DB) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
@@ -189,30 +352,67 @@ func DeleteTask(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		result := db.Where("id = ? AND user_id = ?", taskId, userID).Delete(&model.Task{})
-		if result.Error != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete the task"})
+		var current model.Task
+		err = db.Where("id = ? AND user_id = ?", taskId, userID).First(&current).Error
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found or not owned by you"})
+			return
+		}
+		if !checkIfMatch(ctx, current) {
 			return
 		}
 
-		if result.RowsAffected == 0 {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found or not owned by you"})
+		err = db.Transaction(func(tx *gorm.DB) error {
+			query := tx.Where("id = ? AND user_id = ?", taskId, userID)
+			result := conditionalVersion(ctx, query, current).Delete(&model.Task{})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return missedWrite(ctx)
+			}
+
+			err := recordTaskEvent(tx, uint(taskId), userID, "deleted", "", nil, nil)
+			if err != nil {
+				return err
+			}
+			err = enqueueTaskWebhooks(tx, eventTaskDeleted, uint(taskId))
+			if err != nil {
+				return err
+			}
+			return trashTaskChildren(tx, uint(taskId))
+		})
+		if err != nil {
+			if errors.Is(err, errVersionConflict) {
+				preconditionFailed(ctx)
+				return
+			}
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found or not owned by you"})
+				return
+			}
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete the task"})
 			return
 		}
 
+		publishTaskEvent(eventTaskDeleted, current)
+
 		ctx.JSON(http.StatusOK, gin.H{"message": "Successfully deleted task", "task_id": taskId})
 	}
 }
 
 // GetTaskByID godoc
 // @Summary      Get a single task by ID
-// @Description  Returns a task if it belongs to the authenticated user
+// @Description  Returns a task if it was created by or assigned to the authenticated user.
+// @Description  The ETag header changes with every update of the task.
 // @Tags         Tasks
 // @Security     BearerAuth
 // @Accept       json
 // @Produce      json
 // @Param        id path int true "Task ID"
+// @Param        If-None-Match header string false "ETag of a cached copy"
 // @Success      200 {object} model.Task
+// @Success      304 "Not modified since the given ETag"
 // @Failure      401 {object} map[string]string "Unauthorized"
 // @Failure      404 {object} map[string]string "Task not found or not owned"
 // @Router       /api/task/{id} [get]
@@ -231,21 +431,20 @@ func GetTaskByID(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		var task model.Task
-
-		err = db.Where("id = ? AND user_id = ?", taskID, userID).First(&task).Error
+		task, err := findVisibleTask(db, uint(taskID), userID)
 		if err != nil {
 			ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found or not owned by you"})
+			return
 		}
 
-		ctx.JSON(http.StatusOK, gin.H{
-			"id":          task.ID,
-			"title":       task.Title,
-			"description": task.Description,
-			"priority":    task.Priority,
-			"status":      task.Status,
-			"user_id":     task.UserID,
-		})
+		etag := taskETag(task)
+		ctx.Header("ETag", etag)
+		if match := ctx.GetHeader("If-None-Match"); match != "" && etagMatches(match, etag, true) {
+			ctx.AbortWithStatus(http.StatusNotModified)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, taskResponse(task))
 	}
 }
 
@@ -259,7 +458,13 @@ func GetTaskByID(db *gorm.DB) gin.HandlerFunc {
 // @Param        page   query     int     false  "Page number"                  default(1)
 // @Param        limit  query     int     false  "Items per page"               default(10)
 // @Param        status query     string  false  "Filter by status (pending, completed, etc.)"
-// @Success      200     {object} types.SwaggerTaskListResponse
+// @Param        blocked query    bool    false  "Only tasks with (true) or without (false) open blockers"
+// @Param        filter query     string  false  "Filter expression, e.g. priority>=5 AND status IN (pending,in_progress) AND title~\"report\"; custom fields are custom.<key>"
+// @Param        sort   query     string  false  "Comma separated field:asc|desc list, e.g. priority:desc,custom.story_points:asc (default created_at:desc), or topological (blockers first)"
+// @Param        cursor query     string  false  "Switches to cursor pagination; empty for the first page, then next_cursor or prev_cursor from the previous response"
+// @Param        include_total query bool false "In cursor mode, also count all matching tasks"
+// @Success      200     {object} types.SwaggerTaskListResponse "Offset mode; cursor mode returns handlers.TaskCursorPage with a Link header"
+// @Failure      400     {object} map[string]interface{} "Invalid filter or sort, with the error position"
 // @Failure      401     {object} map[string]string "Unauthorized"
 // @Router       /api/task [get]
 func GetTasks(db *gorm.DB) gin.HandlerFunc {
@@ -271,8 +476,10 @@ func GetTasks(db *gorm.DB) gin.HandlerFunc {
 
 		pageStr := ctx.DefaultQuery("page", "1")
 		limitStr := ctx.DefaultQuery("limit", "10")
-		sort := ctx.DefaultQuery("sort", "created_at:desc")
-		status := ctx.Query("status")
+		sort := ctx.Query("sort")
+		if sort == "" {
+			sort = "created_at:desc" // newest first
+		}
 
 		page, _ := strconv.Atoi(pageStr)
 		limit, _ := strconv.Atoi(limitStr)
@@ -292,28 +499,45 @@ func GetTasks(db *gorm.DB) gin.HandlerFunc {
 		// build base query
 		query := db.Where("user_id = ?", userID)
 
-		if status != "" {
-			query = query.Where("status = ?", status)
+		filter, err := taskFilterFromQuery(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		fields, err := taskFieldsFor(db, userID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve custom fields"})
+			return
+		}
+		query, err = filter.applyAt(query, fields, userNow(db, userID))
+		if err != nil {
+			invalidFilter(ctx, err)
+			return
+		}
+
+		if cursorRequested(ctx) {
+			paginateTasksByCursor(ctx, query, sort, limit, fields)
+			return
 		}
 
-		// Optional sorting
-		switch sort {
-		case "created_at:asc":
-			query = query.Order("created_at ASC")
-		case "priority:asc":
-			query = query.Order("priority ASC")
-		case "priority:desc":
-			query = query.Order("priority DESC")
-		default:
-			query = query.Order("created_at DESC") // newest first
+		if sort == "topological" {
+			getTasksTopological(ctx, db, query, page, limit)
+			return
 		}
 
+		order, err := taskOrder(sort, fields)
+		if err != nil {
+			invalidFilter(ctx, err)
+			return
+		}
+		query = query.Clauses(order)
+
 		var total int64
 		query.Model(&model.Task{}).Count(&total)
 
 		var tasks []model.Task
 
-		err := query.Limit(limit).Offset(offset).Find(&tasks).Error
+		err = query.Limit(limit).Offset(offset).Find(&tasks).Error
 		if err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve tasks", "details": err.Error()})
 			return
@@ -326,3 +550,70 @@ func GetTasks(db *gorm.DB) gin.HandlerFunc {
 		})
 	}
 }
+
+// getTasksTopological answers GetTasks with sort=topological: every matching
+// task is ordered after its blockers before the page is cut out.
+func getTasksTopological(ctx *gin.Context, db *gorm.DB, query *gorm.DB, page, limit int) {
+
+	var tasks []model.Task
+	err := query.Order("created_at ASC").Find(&tasks).Error
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve tasks", "details": err.Error()})
+		return
+	}
+
+	ids := make([]uint, 0, len(tasks))
+	for _, task := range tasks {
+		ids = append(ids, task.ID)
+	}
+
+	var edges []model.TaskDependency
+	if len(ids) > 0 {
+		err = db.Where("task_id IN ? AND blocked_by_id IN ?", ids, ids).Find(&edges).Error
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve task dependencies", "details": err.Error()})
+			return
+		}
+	}
+
+	ordered := topologicalOrder(tasks, edges)
+
+	start := min((page-1)*limit, len(ordered))
+	end := min(start+limit, len(ordered))
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"Total": len(ordered),
+		"Page":  page,
+		"Tasks": ordered[start:end],
+	})
+}
+
+// addStatusChange adds status bookkeeping to updates: completed_at is set
+// when a task becomes completed and cleared when it is reopened. Completing a
+// task with open blockers is refused unless force is set; the blockers are
+// returned and updates is left untouched in that case.
+func addStatusChange(db *gorm.DB, current model.Task, status string, force bool, updates map[string]interface{}) ([]uint, error) {
+
+	if status == "" {
+		return nil, nil
+	}
+
+	if status == "completed" {
+		if current.Status == "completed" {
+			return nil, nil
+		}
+		if !force {
+			blockers, err := openBlockers(db, current.ID)
+			if err != nil || len(blockers) > 0 {
+				return blockers, err
+			}
+		}
+		updates["status"] = status
+		updates["completed_at"] = time.Now()
+		return nil, nil
+	}
+
+	updates["status"] = status
+	updates["completed_at"] = nil
+	return nil, nil
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Niraj1910/Task-REST-APIs/filter"
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// taskFields whitelists the task fields usable in filter expressions and
+// sorts.
+var taskFields = filter.Fields{
+	"id":                {Column: "tasks.id", Kind: filter.Int},
+	"title":             {Column: "tasks.title", Kind: filter.String},
+	"description":       {Column: "tasks.description", Kind: filter.String, NoSort: true},
+	"priority":          {Column: "tasks.priority", Kind: filter.Int},
+	"status":            {Column: "tasks.status", Kind: filter.String, Values: []string{"pending", "in_progress", "completed"}},
+	"user_id":           {Column: "tasks.user_id", Kind: filter.Int},
+	"assignee_id":       {Column: "tasks.assignee_id", Kind: filter.Int, Nullable: true},
+	"created_at":        {Column: "tasks.created_at", Kind: filter.Time},
+	"updated_at":        {Column: "tasks.updated_at", Kind: filter.Time},
+	"completed_at":      {Column: "tasks.completed_at", Kind: filter.Time, Nullable: true},
+	"due_at":            {Column: "tasks.due_at", Kind: filter.Time, Nullable: true},
+	"board_rank":        {Column: "tasks.board_rank", Kind: filter.String},
+	"estimated_minutes": {Column: "tasks.estimated_minutes", Kind: filter.Int, Nullable: true},
+	"parent_id":         {Column: "tasks.parent_id", Kind: filter.Int, Nullable: true},
+}
+
+// TaskFilter holds the filters GetTasks understands. Bulk operations accept
+// the same filters as a JSON object.
+type TaskFilter struct {
+	Status  string `json:"status" binding:"omitempty,oneof=pending in_progress completed"`
+	Blocked *bool  `json:"blocked"`
+	// Expression is a filter expression, e.g. `priority>=5 AND title~"report"`
+	// or `due_at < tomorrow`.
+	Expression string `json:"expression"`
+}
+
+// taskFilterFromQuery reads the GetTasks filter query parameters.
+func taskFilterFromQuery(ctx *gin.Context) (TaskFilter, error) {
+
+	f := TaskFilter{Status: ctx.Query("status"), Expression: ctx.Query("filter")}
+
+	if blocked := ctx.Query("blocked"); blocked != "" {
+		value, err := strconv.ParseBool(blocked)
+		if err != nil {
+			return f, fmt.Errorf("blocked must be true or false")
+		}
+		f.Blocked = &value
+	}
+	return f, nil
+}
+
+// userNow is the current time in the user's time zone, in which relative
+// dates in filter expressions are resolved.
+func userNow(db *gorm.DB, userID uint) time.Time {
+	return time.Now().In(userZone(db, userID))
+}
+
+// userZone loads the stored time zone of a user, UTC if there is none.
+func userZone(db *gorm.DB, userID uint) *time.Location {
+	var user model.User
+	db.Select("id", "time_zone").First(&user, userID)
+	return userLocation(user)
+}
+
+// applyAt adds the filter conditions to a query on tasks, over the given
+// fields (from taskFieldsFor) and with relative dates in the expression
+// resolved against now (from userNow). Only the expression can fail, with a
+// *filter.Error.
+func (f TaskFilter) applyAt(query *gorm.DB, fields filter.Fields, now time.Time) (*gorm.DB, error) {
+
+	if f.Status != "" {
+		query = query.Where("tasks.status = ?", f.Status)
+	}
+
+	if f.Blocked != nil {
+		if *f.Blocked {
+			query = query.Where(blockedCondition)
+		} else {
+			query = query.Where("NOT " + blockedCondition)
+		}
+	}
+
+	if f.Expression != "" {
+		condition, args, err := filter.ParseAndCompileAt(f.Expression, fields, now)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where(condition, args...)
+	}
+	return query, nil
+}
+
+// taskOrder parses a multi-field sort like "priority:desc,created_at:asc"
+// over the given fields.
+func taskOrder(sort string, fields filter.Fields) (clause.OrderBy, error) {
+
+	sorted, err := filter.ParseSort(sort, fields)
+	if err != nil {
+		return clause.OrderBy{}, err
+	}
+	return orderBy(sorted, false), nil
+}
+
+// orderBy builds the ORDER BY for a parsed sort, optionally with every
+// direction flipped.
+func orderBy(fields []filter.SortField, flip bool) clause.OrderBy {
+
+	var order clause.OrderBy
+	for _, field := range fields {
+		order.Columns = append(order.Columns, clause.OrderByColumn{
+			Column: clause.Column{Name: field.Column, Raw: true},
+			Desc:   field.Desc != flip,
+		})
+	}
+	return order
+}
+
+// invalidFilter answers a request whose filter or sort could not be used.
+// Expression errors carry the position of the problem.
+func invalidFilter(ctx *gin.Context, err error) {
+
+	var filterErr *filter.Error
+	if errors.As(err, &filterErr) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid filter", "details": filterErr})
+		return
+	}
+	ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
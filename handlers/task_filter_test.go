@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTasks_FilterExpressionAndMultiSort(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.Create(&[]model.Task{
+		{Title: "Quarterly report", UserID: 1, Priority: 7, Status: "pending"},
+		{Title: "Report typo", UserID: 1, Priority: 7, Status: "in_progress"},
+		{Title: "Annual report", UserID: 1, Priority: 9, Status: "completed"},
+		{Title: "Groceries", UserID: 1, Priority: 8, Status: "pending"},
+		{Title: "Other user's report", UserID: 2, Priority: 9, Status: "pending"},
+	}).Error)
+
+	list := func(filter, sort string) (int, []string) {
+		query := url.Values{"filter": {filter}, "sort": {sort}}
+		c, w := setupContext(http.MethodGet, "/api/task?"+query.Encode(), "", 1)
+		GetTasks(db)(c)
+
+		var resp struct{ Tasks []model.Task }
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		var titles []string
+		for _, task := range resp.Tasks {
+			titles = append(titles, task.Title)
+		}
+		return w.Code, titles
+	}
+
+	code, titles := list(`priority>=5 AND status IN (pending,in_progress) AND title~"report"`, "priority:desc,title:asc")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"Quarterly report", "Report typo"}, titles)
+
+	_, titles = list(`status = completed OR title ~ "groc"`, "priority:asc")
+	assert.Equal(t, []string{"Groceries", "Annual report"}, titles)
+
+	code, titles = list(`user_id = 2 OR priority >= 9`, "")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"Annual report"}, titles, "the filter cannot widen the query to other users")
+
+	_, titles = list(`user_id = 2`, "")
+	assert.Empty(t, titles, "the filter cannot widen the query to other users")
+}
+
+func TestGetTasks_InvalidFilterReportsPosition(t *testing.T) {
+	db := setupTestDB(t)
+
+	for _, query := range []string{
+		"filter=" + url.QueryEscape(`priority >= 5 AND (status = pending`),
+		"filter=" + url.QueryEscape(`user_id = 1; DROP TABLE tasks`),
+		"sort=" + url.QueryEscape("priority:desc,password:asc"),
+	} {
+		c, w := setupContext(http.MethodGet, "/api/task?"+query, "", 1)
+		GetTasks(db)(c)
+		require.Equal(t, http.StatusBadRequest, w.Code, query)
+
+		var resp struct {
+			Error   string
+			Details struct {
+				Position *int
+				Message  string
+			}
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "Invalid filter", resp.Error)
+		assert.NotNil(t, resp.Details.Position, query)
+		assert.NotEmpty(t, resp.Details.Message, query)
+	}
+}
+
+func TestTaskFilters_RelativeDatesInUserTimeZone(t *testing.T) {
+	db := setupTestDB(t)
+	user := model.User{Name: "owner", Email: "owner@example.com", TimeZone: "Pacific/Kiritimati"}
+	require.NoError(t, db.Create(&user).Error)
+
+	// 14 hours ahead of UTC, tomorrow starts before it does in UTC
+	local := time.Now().In(userLocation(user))
+	endOfToday := time.Date(local.Year(), local.Month(), local.Day(), 23, 0, 0, 0, local.Location())
+	dueToday, dueLater := endOfToday.UTC(), endOfToday.Add(2*time.Hour).UTC()
+	require.NoError(t, db.Create(&[]model.Task{
+		{Title: "Due today", UserID: user.ID, DueAt: &dueToday},
+		{Title: "Due tomorrow", UserID: user.ID, DueAt: &dueLater},
+	}).Error)
+	query := url.Values{"filter": {"due_at < tomorrow"}}.Encode()
+
+	c, w := setupContext(http.MethodGet, "/api/task?"+query, "", user.ID)
+	GetTasks(db)(c)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var page struct{ Tasks []model.Task }
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	assert.Equal(t, []string{"Due today"}, taskTitles(page.Tasks))
+
+	c, w = setupContext(http.MethodGet, "/api/task/export?format=json&"+query, "", user.ID)
+	ExportTasks(db)(c)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var exported []ExportedTask
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &exported))
+	require.Len(t, exported, 1)
+	assert.Equal(t, "Due today", exported[0].Title)
+
+	c, w = setupContext(http.MethodPost, "/api/task/bulk", `{"action": "set_priority", "priority": 9, "filter": {"expression": "due_at < tomorrow"}}`, user.ID)
+	BulkTasks(db)(c)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var urgent []string
+	db.Model(&model.Task{}).Where("priority = 9").Pluck("title", &urgent)
+	assert.Equal(t, []string{"Due today"}, urgent)
+}
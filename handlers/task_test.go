@@ -36,3 +36,23 @@ func TestCreateTask_OwnershipContext(t *testing.T) {
 	assert.Equal(t, "Buy milk", task.Title)
 
 }
+
+func TestUpdateTask_KeepsPriorityWhenAbsent(t *testing.T) {
+
+	db := setupTestDB(t)
+	task := model.Task{Title: "Buy milk", Priority: 6, UserID: 42}
+	db.Create(&task)
+
+	c, w := setupContext(http.MethodPut, "/api/task/1", `{"title": "Buy oat milk"}`, 42)
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+
+	UpdateTask(db)(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var stored model.Task
+	db.First(&stored, task.ID)
+	assert.Equal(t, "Buy oat milk", stored.Title)
+	assert.Equal(t, 6, stored.Priority)
+
+}
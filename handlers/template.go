@@ -0,0 +1,479 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/Niraj1910/Task-REST-APIs/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+const (
+	// maxTemplateTasks bounds the tasks one template creates, the root
+	// included.
+	maxTemplateTasks = 100
+	// maxTemplateDepth is how deep subtasks may nest below the root.
+	maxTemplateDepth = 3
+)
+
+// templateVariable matches a {{name}} placeholder.
+var templateVariable = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+var errTemplateInput = errors.New("invalid template input")
+
+// TemplateTask is a task of a template with its subtasks. Title and
+// description may contain {{variable}} placeholders.
+type TemplateTask struct {
+	Title       string `json:"title" binding:"required,max=200"`
+	Description string `json:"description" binding:"max=1000"`
+	// Priority defaults to the parent's priority, or 0 for the root.
+	Priority *int     `json:"priority" binding:"omitempty,gte=0,lte=10"`
+	Labels   []string `json:"labels"`
+	// DueOffsetDays sets the due date this many days after the anchor.
+	DueOffsetDays *int           `json:"due_offset_days" binding:"omitempty,gte=-3650,lte=3650"`
+	Subtasks      []TemplateTask `json:"subtasks" binding:"omitempty,dive"`
+}
+
+type TaskTemplateBody struct {
+	Name *string       `json:"name" binding:"omitempty,max=100"`
+	Task *TemplateTask `json:"task"`
+}
+
+type InstantiateTemplateBody struct {
+	// Variables fill the {{name}} placeholders; all of them are required.
+	Variables map[string]string `json:"variables"`
+	// Anchor is what the due offsets count from: an RFC 3339 time, or a
+	// date (midnight in your time zone). Defaults to now.
+	Anchor string `json:"anchor"`
+}
+
+// CreateTemplate godoc
+// @Summary      Create a task template
+// @Description  Saves a task tree to create again and again, e.g. an onboarding checklist. Each task has a title,
+// @Description  description, priority, due_offset_days and subtasks (nested up to 3 levels, 100 tasks in all).
+// @Tags         Templates
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        body body handlers.TaskTemplateBody true "Template"
+// @Success      201 {object} map[string]interface{} "Template"
+// @Failure      400 {object} map[string]string "Invalid template"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Router       /api/templates [post]
+func CreateTemplate(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		var input TaskTemplateBody
+		err := ctx.ShouldBindJSON(&input)
+		if err == nil && (input.Name == nil || input.Task == nil) {
+			err = errors.New("name and task are required")
+		}
+		template := model.TaskTemplate{UserID: userID}
+		if err == nil {
+			err = input.applyTo(&template)
+		}
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid input", "details": err.Error()})
+			return
+		}
+
+		if err := db.Create(&template).Error; err != nil {
+			log.Error().Err(err).Uint("user_id", userID).Msg("Failed to create template")
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create template"})
+			return
+		}
+		ctx.JSON(http.StatusCreated, templateResponse(template))
+	}
+}
+
+// GetTemplates godoc
+// @Summary      List task templates
+// @Tags         Templates
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200 {object} map[string]interface{} "templates"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Router       /api/templates [get]
+func GetTemplates(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		var templates []model.TaskTemplate
+		if err := db.Where("user_id = ?", userID).Order("name, id").Find(&templates).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve templates"})
+			return
+		}
+
+		items := make([]gin.H, 0, len(templates))
+		for _, template := range templates {
+			items = append(items, templateResponse(template))
+		}
+		ctx.JSON(http.StatusOK, gin.H{"templates": items})
+	}
+}
+
+// GetTemplate godoc
+// @Summary      Get a task template
+// @Tags         Templates
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id path int true "Template ID"
+// @Success      200 {object} map[string]interface{} "Template with the variables it uses"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      404 {object} map[string]string "Template not found"
+// @Router       /api/templates/{id} [get]
+func GetTemplate(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		template, ok := findOwnTemplate(ctx, db)
+		if !ok {
+			return
+		}
+		ctx.JSON(http.StatusOK, templateResponse(template))
+	}
+}
+
+// UpdateTemplate godoc
+// @Summary      Update a task template
+// @Description  Renames the template and/or replaces its task tree. Tasks created from it earlier stay as they are.
+// @Tags         Templates
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id   path int true "Template ID"
+// @Param        body body handlers.TaskTemplateBody true "Fields to change"
+// @Success      200 {object} map[string]interface{} "Template"
+// @Failure      400 {object} map[string]string "Invalid template"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      404 {object} map[string]string "Template not found"
+// @Router       /api/templates/{id} [put]
+func UpdateTemplate(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		template, ok := findOwnTemplate(ctx, db)
+		if !ok {
+			return
+		}
+
+		var input TaskTemplateBody
+		err := ctx.ShouldBindJSON(&input)
+		if err == nil {
+			err = input.applyTo(&template)
+		}
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid input", "details": err.Error()})
+			return
+		}
+
+		if err := db.Model(&template).Select("name", "task").Updates(&template).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update template"})
+			return
+		}
+		ctx.JSON(http.StatusOK, templateResponse(template))
+	}
+}
+
+// DeleteTemplate godoc
+// @Summary      Delete a task template
+// @Tags         Templates
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id path int true "Template ID"
+// @Success      200 {object} map[string]string "Template deleted"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      404 {object} map[string]string "Template not found"
+// @Router       /api/templates/{id} [delete]
+func DeleteTemplate(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		template, ok := findOwnTemplate(ctx, db)
+		if !ok {
+			return
+		}
+		if err := db.Delete(&template).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete template"})
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{"message": "Template deleted"})
+	}
+}
+
+// InstantiateTemplate godoc
+// @Summary      Create tasks from a template
+// @Description  Creates the template's task tree in one transaction: all tasks or none. Placeholders are replaced by
+// @Description  the variables and due dates are set from the anchor plus each task's due_offset_days.
+// @Tags         Templates
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id   path int true "Template ID"
+// @Param        body body handlers.InstantiateTemplateBody true "Variables and anchor"
+// @Success      201 {object} map[string]interface{} "The root task and the IDs of all created tasks"
+// @Failure      400 {object} map[string]interface{} "Missing variables or invalid anchor"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      404 {object} map[string]string "Template not found"
+// @Router       /api/templates/{id}/instantiate [post]
+func InstantiateTemplate(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		template, ok := findOwnTemplate(ctx, db)
+		if !ok {
+			return
+		}
+
+		var input InstantiateTemplateBody
+		if err := ctx.ShouldBindJSON(&input); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid input", "details": err.Error()})
+			return
+		}
+
+		var root TemplateTask
+		if err := json.Unmarshal([]byte(template.Task), &root); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read template"})
+			return
+		}
+
+		var missing []string
+		for _, name := range root.variables() {
+			if _, ok := input.Variables[name]; !ok {
+				missing = append(missing, name)
+			}
+		}
+		if len(missing) > 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "missing variables", "details": missing})
+			return
+		}
+
+		var user model.User
+		db.Select("id", "time_zone").First(&user, template.UserID)
+		anchor, err := parseAnchor(input.Anchor, userLocation(user))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid input", "details": err.Error()})
+			return
+		}
+
+		var task model.Task
+		var ids []uint
+		err = db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			task, err = root.instantiate(tx, template.UserID, nil, 0, input.Variables, anchor, &ids)
+			return err
+		})
+		if errors.Is(err, errTemplateInput) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid input", "details": err.Error()})
+			return
+		}
+		if err != nil {
+			log.Error().Err(err).Uint("template_id", template.ID).Msg("Failed to instantiate template")
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create tasks from template"})
+			return
+		}
+
+		publishTaskEvents(db, eventTaskCreated, ids)
+
+		ctx.JSON(http.StatusCreated, gin.H{"task": taskResponse(task), "task_ids": ids})
+	}
+}
+
+// applyTo validates the given fields and copies them onto template.
+func (b TaskTemplateBody) applyTo(template *model.TaskTemplate) error {
+
+	if b.Name != nil {
+		name := strings.TrimSpace(*b.Name)
+		if name == "" {
+			return errors.New("name must not be empty")
+		}
+		template.Name = name
+	}
+	if b.Task != nil {
+		count := 0
+		if err := b.Task.validate(0, &count); err != nil {
+			return err
+		}
+		task, err := json.Marshal(b.Task)
+		if err != nil {
+			return err
+		}
+		template.Task = string(task)
+	}
+	return nil
+}
+
+// validate checks the tree below t, counting its tasks.
+func (t TemplateTask) validate(depth int, count *int) error {
+
+	*count++
+	if *count > maxTemplateTasks {
+		return fmt.Errorf("a template can hold at most %d tasks", maxTemplateTasks)
+	}
+	if depth > maxTemplateDepth {
+		return fmt.Errorf("subtasks can be nested at most %d levels deep", maxTemplateDepth)
+	}
+	if strings.TrimSpace(t.Title) == "" {
+		return errors.New("every task needs a title")
+	}
+	if len(t.Labels) > 0 {
+		return errors.New("labels are not available")
+	}
+	for _, sub := range t.Subtasks {
+		if err := sub.validate(depth+1, count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// variables lists the placeholder names used anywhere in the tree.
+func (t TemplateTask) variables() []string {
+
+	var names []string
+	var walk func(TemplateTask)
+	walk = func(t TemplateTask) {
+		for _, text := range []string{t.Title, t.Description} {
+			for _, match := range templateVariable.FindAllStringSubmatch(text, -1) {
+				if !slices.Contains(names, match[1]) {
+					names = append(names, match[1])
+				}
+			}
+		}
+		for _, sub := range t.Subtasks {
+			walk(sub)
+		}
+	}
+	walk(t)
+	slices.Sort(names)
+	return names
+}
+
+// instantiate creates the task and its subtasks inside tx, appending the
+// new IDs to ids in creation order.
+func (t TemplateTask) instantiate(tx *gorm.DB, userID uint, parentID *uint, priority int, vars map[string]string, anchor time.Time, ids *[]uint) (model.Task, error) {
+
+	if t.Priority != nil {
+		priority = *t.Priority
+	}
+	task := model.Task{
+		Title:       strings.TrimSpace(substituteVariables(t.Title, vars)),
+		Description: substituteVariables(t.Description, vars),
+		Priority:    priority,
+		Status:      "pending",
+		UserID:      userID,
+		ParentID:    parentID,
+	}
+	// the same bounds as CreateTask
+	if length := utf8.RuneCountInString(task.Title); length < 5 || length > 200 {
+		return task, fmt.Errorf("%w: the title %q must be 5 to 200 characters once filled in", errTemplateInput, t.Title)
+	}
+	if utf8.RuneCountInString(task.Description) > 1000 {
+		return task, fmt.Errorf("%w: the description of %q is longer than 1000 characters once filled in", errTemplateInput, t.Title)
+	}
+	if t.DueOffsetDays != nil {
+		due := anchor.AddDate(0, 0, *t.DueOffsetDays).UTC()
+		task.DueAt = &due
+	}
+
+	var err error
+	if task.BoardRank, err = bottomRank(tx, userID, task.Status); err != nil {
+		return task, err
+	}
+	if err := tx.Create(&task).Error; err != nil {
+		return task, err
+	}
+	if err := recordTaskEvent(tx, task.ID, userID, "created", "", nil, &task.Title); err != nil {
+		return task, err
+	}
+	if err := enqueueTaskWebhooks(tx, eventTaskCreated, task.ID); err != nil {
+		return task, err
+	}
+	*ids = append(*ids, task.ID)
+
+	for _, sub := range t.Subtasks {
+		if _, err := sub.instantiate(tx, userID, &task.ID, priority, vars, anchor, ids); err != nil {
+			return task, err
+		}
+	}
+	return task, nil
+}
+
+// substituteVariables fills the placeholders. Values are inserted as they
+// are, placeholders inside them are not expanded again.
+func substituteVariables(text string, vars map[string]string) string {
+	return templateVariable.ReplaceAllStringFunc(text, func(placeholder string) string {
+		return vars[templateVariable.FindStringSubmatch(placeholder)[1]]
+	})
+}
+
+// parseAnchor reads an RFC 3339 time or a date, which starts at midnight in
+// loc. Offsets are added in loc so they keep the time of day across DST.
+func parseAnchor(value string, loc *time.Location) (time.Time, error) {
+
+	if value == "" {
+		return time.Now().In(loc), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.In(loc), nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, value, loc); err == nil {
+		return t, nil
+	}
+	return time.Time{}, errors.New("anchor must be a date (YYYY-MM-DD) or an RFC 3339 time")
+}
+
+func findOwnTemplate(ctx *gin.Context, db *gorm.DB) (model.TaskTemplate, bool) {
+
+	var template model.TaskTemplate
+
+	templateID, ok := parseIDParam(ctx, "id", "Template ID")
+	if !ok {
+		return template, false
+	}
+	userID, ok := utils.UserIDFromContext(ctx)
+	if !ok {
+		return template, false
+	}
+
+	err := db.Where("id = ? AND user_id = ?", templateID, userID).First(&template).Error
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return template, false
+	}
+	return template, true
+}
+
+func templateResponse(template model.TaskTemplate) gin.H {
+
+	var task TemplateTask
+	json.Unmarshal([]byte(template.Task), &task)
+
+	variables := task.variables()
+	if variables == nil {
+		variables = []string{}
+	}
+	return gin.H{
+		"id":         template.ID,
+		"name":       template.Name,
+		"task":       task,
+		"variables":  variables,
+		"created_at": template.CreatedAt,
+		"updated_at": template.UpdatedAt,
+	}
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const onboardingTemplate = `{"name": "Onboarding", "task": {
+	"title": "Onboard {{name}}", "priority": 6, "due_offset_days": 14,
+	"subtasks": [
+		{"title": "Create accounts for {{ name }}", "due_offset_days": 0},
+		{"title": "First week", "priority": 2, "subtasks": [
+			{"title": "Meet the {{team}} team", "due_offset_days": 3}
+		]}
+	]}}`
+
+func TestTemplates_Validation(t *testing.T) {
+	db := setupTestDB(t)
+	user := model.User{Name: "owner", Email: "owner@example.com"}
+	require.NoError(t, db.Create(&user).Error)
+
+	deep := `{"title": "level 4"}`
+	for i := 3; i >= 0; i-- {
+		deep = fmt.Sprintf(`{"title": "level %d", "subtasks": [%s]}`, i, deep)
+	}
+	for _, body := range []string{
+		`{"task": {"title": "No name"}}`,
+		`{"name": "No task"}`,
+		`{"name": "Untitled", "task": {"title": "Root", "subtasks": [{"title": " "}]}}`,
+		`{"name": "Priority", "task": {"title": "Root", "subtasks": [{"title": "Sub", "priority": 11}]}}`,
+		`{"name": "Labels", "task": {"title": "Root", "labels": ["hr"]}}`,
+		`{"name": "Deep", "task": ` + deep + `}`,
+	} {
+		c, w := setupContext(http.MethodPost, "/api/templates", body, user.ID)
+		CreateTemplate(db)(c)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+
+	c, w := setupContext(http.MethodPost, "/api/templates", onboardingTemplate, user.ID)
+	CreateTemplate(db)(c)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var template gin.H
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &template))
+	assert.Equal(t, []any{"name", "team"}, template["variables"])
+
+	other := model.User{Name: "other", Email: "other@example.com"}
+	require.NoError(t, db.Create(&other).Error)
+	c, w = setupContext(http.MethodGet, "/", "", other.ID)
+	c.Params = gin.Params{{Key: "id", Value: fmt.Sprint(template["id"])}}
+	GetTemplate(db)(c)
+	assert.Equal(t, http.StatusNotFound, w.Code, "templates are private")
+}
+
+func TestTemplates_Instantiate(t *testing.T) {
+	db := setupTestDB(t)
+	user := model.User{Name: "owner", Email: "owner@example.com", TimeZone: "Europe/Berlin"}
+	require.NoError(t, db.Create(&user).Error)
+
+	c, w := setupContext(http.MethodPost, "/api/templates", onboardingTemplate, user.ID)
+	CreateTemplate(db)(c)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var template gin.H
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &template))
+
+	instantiate := func(body string) (gin.H, int) {
+		c, w := setupContext(http.MethodPost, "/", body, user.ID)
+		c.Params = gin.Params{{Key: "id", Value: fmt.Sprint(template["id"])}}
+		InstantiateTemplate(db)(c)
+		var result gin.H
+		json.Unmarshal(w.Body.Bytes(), &result)
+		return result, w.Code
+	}
+
+	result, code := instantiate(`{"variables": {"name": "Ada"}}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, []any{"team"}, result["details"])
+
+	_, code = instantiate(`{"variables": {"name": "Ada", "team": "x"}, "anchor": "next monday"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// the placeholder check runs per task, so a late failure must roll back the earlier ones
+	long := fmt.Sprintf("%0201d", 0)
+	_, code = instantiate(`{"variables": {"name": "Ada", "team": "` + long + `"}}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	var count int64
+	db.Model(&model.Task{}).Count(&count)
+	assert.Zero(t, count, "nothing is created when one task fails")
+
+	// filled-in titles follow the same 5 to 200 characters as CreateTask
+	c, w = setupContext(http.MethodPost, "/api/templates", `{"name": "Ticket", "task": {"title": "{{code}}"}}`, user.ID)
+	CreateTemplate(db)(c)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var ticket gin.H
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &ticket))
+	c, w = setupContext(http.MethodPost, "/", `{"variables": {"code": "T-1"}}`, user.ID)
+	c.Params = gin.Params{{Key: "id", Value: fmt.Sprint(ticket["id"])}}
+	InstantiateTemplate(db)(c)
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	// the anchor is a date in Berlin and the tasks straddle the DST change
+	result, code = instantiate(`{"variables": {"name": "Ada", "team": "{{name}}"}, "anchor": "2026-10-20"}`)
+	require.Equal(t, http.StatusCreated, code, result)
+	require.Len(t, result["task_ids"], 4)
+
+	var tasks []model.Task
+	require.NoError(t, db.Order("id").Find(&tasks).Error)
+	require.Len(t, tasks, 4)
+	root, accounts, week, meet := tasks[0], tasks[1], tasks[2], tasks[3]
+
+	assert.Equal(t, "Onboard Ada", root.Title)
+	assert.Nil(t, root.ParentID)
+	assert.Equal(t, "Create accounts for Ada", accounts.Title)
+	assert.Equal(t, "Meet the {{name}} team", meet.Title, "values are not expanded again")
+	assert.Equal(t, &root.ID, accounts.ParentID)
+	assert.Equal(t, &root.ID, week.ParentID)
+	assert.Equal(t, &week.ID, meet.ParentID)
+	assert.Equal(t, []int{6, 6, 2, 2}, []int{root.Priority, accounts.Priority, week.Priority, meet.Priority})
+
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	midnight := func(day int) time.Time { return time.Date(2026, 10, day, 0, 0, 0, 0, berlin) }
+	require.NotNil(t, root.DueAt)
+	assert.True(t, midnight(20).Add(14*24*time.Hour+time.Hour).Equal(*root.DueAt), "one more hour after the clocks go back")
+	assert.True(t, midnight(20).Equal(*accounts.DueAt))
+	assert.Nil(t, week.DueAt)
+	assert.True(t, midnight(23).Equal(*meet.DueAt))
+
+	var events int64
+	db.Model(&model.TaskEvent{}).Where("action = ?", "created").Count(&events)
+	assert.EqualValues(t, 4, events)
+}
@@ -0,0 +1,539 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/Niraj1910/Task-REST-APIs/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// maxTimeEntry is the longest single time entry.
+const maxTimeEntry = 24 * time.Hour
+
+// clockSkew is how far in the future an entry may end.
+const clockSkew = 5 * time.Minute
+
+// TimeEntryBody is a manual time entry. Give either ended_at or minutes.
+type TimeEntryBody struct {
+	StartedAt *time.Time `json:"started_at"`
+	EndedAt   *time.Time `json:"ended_at"`
+	Minutes   *int       `json:"minutes" binding:"omitempty,gte=1,lte=1440"`
+	Note      *string    `json:"note" binding:"omitempty,max=500"`
+}
+
+// StartTimerBody starts a timer. Switch stops a timer running on another
+// task first instead of answering 409.
+type StartTimerBody struct {
+	Note   string `json:"note" binding:"max=500"`
+	Switch bool   `json:"switch"`
+}
+
+// StartTimer godoc
+// @Summary      Start a timer on a task
+// @Description  Starts tracking time on a task the user created or is assigned to. A user has at most one running
+// @Description  timer: starting another answers 409 with the running entry unless "switch" is true, which stops it.
+// @Tags         Time
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id   path int true "Task ID"
+// @Param        body body handlers.StartTimerBody false "Optional note and switch"
+// @Success      201 {object} map[string]interface{} "Running time entry"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      404 {object} map[string]string "Task not found"
+// @Failure      409 {object} map[string]interface{} "Another timer is running"
+// @Router       /api/task/{id}/time/start [post]
+func StartTimer(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		taskID, ok := parseIDParam(ctx, "id", "Task ID")
+		if !ok {
+			return
+		}
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		// the body is optional
+		var input StartTimerBody
+		if err := ctx.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid input", "details": err.Error()})
+			return
+		}
+
+		if _, err := findVisibleTask(db, taskID, userID); err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found or not visible to you"})
+			return
+		}
+
+		now := time.Now()
+		entry := model.TimeEntry{TaskID: taskID, UserID: userID, StartedAt: now, Note: input.Note}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			running, err := runningTimer(tx, userID)
+			if err != nil {
+				return err
+			}
+			if running != nil {
+				if !input.Switch {
+					return errTimerRunning
+				}
+				err = tx.Model(running).Update("ended_at", now).Error
+				if err != nil {
+					return err
+				}
+			}
+			return tx.Create(&entry).Error
+		})
+
+		if err != nil {
+			// the unique index catches a timer started concurrently
+			if running, _ := runningTimer(db, userID); running != nil {
+				ctx.JSON(http.StatusConflict, gin.H{
+					"error":   "Another timer is running",
+					"running": timeEntryResponse(*running, now),
+					"details": "stop it first or send \"switch\": true",
+				})
+				return
+			}
+			log.Error().Err(err).Uint("task_id", taskID).Msg("Failed to start timer")
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start timer"})
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, timeEntryResponse(entry, now))
+	}
+}
+
+// StopTimer godoc
+// @Summary      Stop the timer on a task
+// @Description  Stops the user's running timer on the task
+// @Tags         Time
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id path int true "Task ID"
+// @Success      200 {object} map[string]interface{} "Stopped time entry"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      409 {object} map[string]string "No timer running on this task"
+// @Router       /api/task/{id}/time/stop [post]
+func StopTimer(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		taskID, ok := parseIDParam(ctx, "id", "Task ID")
+		if !ok {
+			return
+		}
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		running, err := runningTimer(db, userID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to stop timer"})
+			return
+		}
+		if running == nil || running.TaskID != taskID {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "No timer running on this task"})
+			return
+		}
+
+		now := time.Now()
+		// the condition keeps a concurrent stop from moving the end
+		result := db.Model(running).Where("ended_at IS NULL").Update("ended_at", now)
+		if result.Error != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to stop timer"})
+			return
+		}
+		if result.RowsAffected == 0 {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "No timer running on this task"})
+			return
+		}
+		running.EndedAt = &now
+
+		ctx.JSON(http.StatusOK, timeEntryResponse(*running, now))
+	}
+}
+
+// GetRunningTimer godoc
+// @Summary      Running timer
+// @Description  Returns the user's running time entry, or null
+// @Tags         Time
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200 {object} map[string]interface{} "running: the entry or null"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Router       /api/time/running [get]
+func GetRunningTimer(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		running, err := runningTimer(db, userID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load the running timer"})
+			return
+		}
+		if running == nil {
+			ctx.JSON(http.StatusOK, gin.H{"running": nil})
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{"running": timeEntryResponse(*running, time.Now())})
+	}
+}
+
+// CreateTimeEntry godoc
+// @Summary      Log time on a task
+// @Description  Adds a finished time entry: started_at plus either ended_at or minutes. Entries are at most 24 hours.
+// @Tags         Time
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id   path int true "Task ID"
+// @Param        body body handlers.TimeEntryBody true "Time entry"
+// @Success      201 {object} map[string]interface{} "Created time entry"
+// @Failure      400 {object} map[string]string "Invalid input"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      404 {object} map[string]string "Task not found"
+// @Router       /api/task/{id}/time [post]
+func CreateTimeEntry(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		taskID, ok := parseIDParam(ctx, "id", "Task ID")
+		if !ok {
+			return
+		}
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		var input TimeEntryBody
+		if err := ctx.ShouldBindJSON(&input); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid input", "details": err.Error()})
+			return
+		}
+		if input.StartedAt == nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid input", "details": "started_at is required"})
+			return
+		}
+		if input.EndedAt == nil && input.Minutes == nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid input", "details": "ended_at or minutes is required"})
+			return
+		}
+
+		if _, err := findVisibleTask(db, taskID, userID); err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found or not visible to you"})
+			return
+		}
+
+		entry := model.TimeEntry{TaskID: taskID, UserID: userID}
+		if err := input.applyTo(&entry); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid input", "details": err.Error()})
+			return
+		}
+
+		if err := db.Create(&entry).Error; err != nil {
+			log.Error().Err(err).Uint("task_id", taskID).Msg("Failed to log time")
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log time"})
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, timeEntryResponse(entry, time.Now()))
+	}
+}
+
+// GetTimeEntries godoc
+// @Summary      Time logged on a task
+// @Description  Lists the time entries of a task, newest first, with totals: logged time (running timers count
+// @Description  up to now), the caller's share, the estimate and what remains of it (negative when over).
+// @Description  from and to (YYYY-MM-DD, inclusive, in tz) restrict entries and totals to a date range.
+// @Tags         Time
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id    path  int    true  "Task ID"
+// @Param        from  query string false "First day"
+// @Param        to    query string false "Last day"
+// @Param        tz    query string false "IANA time zone for from/to (default UTC)"
+// @Param        page  query int    false "Page number"    default(1)
+// @Param        limit query int    false "Items per page" default(10)
+// @Success      200 {object} map[string]interface{} "Entries, totals and pagination meta"
+// @Failure      400 {object} map[string]string "Invalid date range"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      404 {object} map[string]string "Task not found"
+// @Router       /api/task/{id}/time [get]
+func GetTimeEntries(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		taskID, ok := parseIDParam(ctx, "id", "Task ID")
+		if !ok {
+			return
+		}
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		task, err := findVisibleTask(db, taskID, userID)
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found or not visible to you"})
+			return
+		}
+
+		query := db.Model(&model.TimeEntry{}).Where("task_id = ?", taskID)
+		if ctx.Query("from") != "" || ctx.Query("to") != "" {
+			period, err := dateRangeFromQuery(ctx, false, time.UTC)
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			query = query.Where("started_at >= ? AND started_at < ?", period.start.UTC(), period.end.UTC())
+		}
+
+		// totals need every entry; only the id, user and times are loaded
+		var all []model.TimeEntry
+		if err := query.Session(&gorm.Session{}).Select("id", "user_id", "started_at", "ended_at").Find(&all).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve time entries"})
+			return
+		}
+
+		now := time.Now()
+		var logged, mine time.Duration
+		running := false
+		for _, entry := range all {
+			d := entryDuration(entry, now)
+			logged += d
+			if entry.UserID == userID {
+				mine += d
+			}
+			running = running || entry.EndedAt == nil
+		}
+
+		totals := gin.H{
+			"logged_minutes":    roundMinutes(logged),
+			"my_minutes":        roundMinutes(mine),
+			"estimated_minutes": task.EstimatedMinutes,
+			"remaining_minutes": nil,
+			"running":           running,
+		}
+		if task.EstimatedMinutes != nil {
+			totals["remaining_minutes"] = *task.EstimatedMinutes - roundMinutes(logged)
+		}
+
+		page, limit, offset := pageParams(ctx)
+		var entries []model.TimeEntry
+		err = query.Order("started_at DESC, id DESC").Limit(limit).Offset(offset).Find(&entries).Error
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve time entries"})
+			return
+		}
+
+		items := make([]gin.H, 0, len(entries))
+		for _, entry := range entries {
+			items = append(items, timeEntryResponse(entry, now))
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"entries": items,
+			"totals":  totals,
+			"meta": gin.H{
+				"total": len(all),
+				"page":  page,
+				"limit": limit,
+			},
+		})
+	}
+}
+
+// UpdateTimeEntry godoc
+// @Summary      Edit a time entry
+// @Description  Changes the start, end, duration or note of an entry the user logged. Setting ended_at or minutes on a
+// @Description  running entry stops it.
+// @Tags         Time
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id      path int true "Task ID"
+// @Param        entryId path int true "Time entry ID"
+// @Param        body    body handlers.TimeEntryBody true "Fields to change"
+// @Success      200 {object} map[string]interface{} "Updated time entry"
+// @Failure      400 {object} map[string]string "Invalid input"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      404 {object} map[string]string "Time entry not found or not yours"
+// @Router       /api/task/{id}/time/{entryId} [put]
+func UpdateTimeEntry(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		entry, ok := findOwnTimeEntry(ctx, db)
+		if !ok {
+			return
+		}
+
+		var input TimeEntryBody
+		if err := ctx.ShouldBindJSON(&input); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid input", "details": err.Error()})
+			return
+		}
+
+		if err := input.applyTo(&entry); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid input", "details": err.Error()})
+			return
+		}
+
+		err := db.Model(&entry).Select("started_at", "ended_at", "note").Updates(&entry).Error
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update time entry"})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, timeEntryResponse(entry, time.Now()))
+	}
+}
+
+// DeleteTimeEntry godoc
+// @Summary      Delete a time entry
+// @Description  Deletes an entry the user logged, including a running timer
+// @Tags         Time
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id      path int true "Task ID"
+// @Param        entryId path int true "Time entry ID"
+// @Success      200 {object} map[string]string "Time entry deleted"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      404 {object} map[string]string "Time entry not found or not yours"
+// @Router       /api/task/{id}/time/{entryId} [delete]
+func DeleteTimeEntry(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		entry, ok := findOwnTimeEntry(ctx, db)
+		if !ok {
+			return
+		}
+
+		if err := db.Delete(&entry).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete time entry"})
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{"message": "Time entry deleted"})
+	}
+}
+
+var errTimerRunning = errors.New("another timer is running")
+
+// runningTimer returns the user's running time entry, or nil.
+func runningTimer(db *gorm.DB, userID uint) (*model.TimeEntry, error) {
+
+	var entries []model.TimeEntry
+	err := db.Where("user_id = ? AND ended_at IS NULL", userID).Limit(1).Find(&entries).Error
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+	return &entries[0], nil
+}
+
+// findOwnTimeEntry loads the entry of the path parameters if the user logged
+// it, answering 404 otherwise.
+func findOwnTimeEntry(ctx *gin.Context, db *gorm.DB) (model.TimeEntry, bool) {
+
+	var entry model.TimeEntry
+
+	taskID, ok := parseIDParam(ctx, "id", "Task ID")
+	if !ok {
+		return entry, false
+	}
+	entryID, ok := parseIDParam(ctx, "entryId", "Time entry ID")
+	if !ok {
+		return entry, false
+	}
+	userID, ok := utils.UserIDFromContext(ctx)
+	if !ok {
+		return entry, false
+	}
+
+	err := db.Where("id = ? AND task_id = ? AND user_id = ?", entryID, taskID, userID).First(&entry).Error
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Time entry not found or not yours"})
+		return entry, false
+	}
+	return entry, true
+}
+
+// applyTo copies the given fields onto an entry and checks the result.
+func (b TimeEntryBody) applyTo(entry *model.TimeEntry) error {
+
+	if b.StartedAt != nil {
+		entry.StartedAt = *b.StartedAt
+	}
+	if b.EndedAt != nil {
+		end := *b.EndedAt
+		entry.EndedAt = &end
+	}
+	if b.Minutes != nil {
+		end := entry.StartedAt.Add(time.Duration(*b.Minutes) * time.Minute)
+		entry.EndedAt = &end
+	}
+	if b.Note != nil {
+		entry.Note = *b.Note
+	}
+
+	if b.EndedAt != nil && b.Minutes != nil {
+		return errors.New("give either ended_at or minutes")
+	}
+	if entry.EndedAt == nil {
+		if entry.StartedAt.After(time.Now().Add(clockSkew)) {
+			return errors.New("a running timer cannot start in the future")
+		}
+		return nil
+	}
+	if !entry.EndedAt.After(entry.StartedAt) {
+		return errors.New("ended_at must be after started_at")
+	}
+	if entry.EndedAt.Sub(entry.StartedAt) > maxTimeEntry {
+		return errors.New("a time entry can be at most 24 hours")
+	}
+	if entry.EndedAt.After(time.Now().Add(clockSkew)) {
+		return errors.New("ended_at cannot be in the future")
+	}
+	return nil
+}
+
+// entryDuration is the length of an entry; running entries count up to now.
+func entryDuration(entry model.TimeEntry, now time.Time) time.Duration {
+	end := now
+	if entry.EndedAt != nil {
+		end = *entry.EndedAt
+	}
+	return max(end.Sub(entry.StartedAt), 0)
+}
+
+func roundMinutes(d time.Duration) int {
+	return int(math.Round(d.Minutes()))
+}
+
+func timeEntryResponse(entry model.TimeEntry, now time.Time) gin.H {
+	return gin.H{
+		"id":         entry.ID,
+		"task_id":    entry.TaskID,
+		"user_id":    entry.UserID,
+		"started_at": entry.StartedAt,
+		"ended_at":   entry.EndedAt,
+		"minutes":    roundMinutes(entryDuration(entry, now)),
+		"running":    entry.EndedAt == nil,
+		"note":       entry.Note,
+	}
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func timeRequest(db *gorm.DB, handler func(*gorm.DB) gin.HandlerFunc, method, path, body string, params gin.Params) *httptest.ResponseRecorder {
+	c, w := setupContext(method, path, body, 1)
+	c.Params = params
+	handler(db)(c)
+	return w
+}
+
+func taskParam(id uint) gin.Params {
+	return gin.Params{{Key: "id", Value: fmt.Sprint(id)}}
+}
+
+func TestTimer_OneRunningTimerPerUser(t *testing.T) {
+	db := setupTestDB(t)
+	estimate := 90
+	tasks := []model.Task{
+		{Title: "Write the proposal", UserID: 1, EstimatedMinutes: &estimate},
+		{Title: "Review the contract", UserID: 1},
+	}
+	require.NoError(t, db.Create(&tasks).Error)
+
+	w := timeRequest(db, StartTimer, http.MethodPost, "/", "", taskParam(tasks[0].ID))
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	w = timeRequest(db, StartTimer, http.MethodPost, "/", "", taskParam(tasks[1].ID))
+	require.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"running"`)
+
+	w = timeRequest(db, StartTimer, http.MethodPost, "/", `{"switch":true}`, taskParam(tasks[1].ID))
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var running []model.TimeEntry
+	require.NoError(t, db.Where("ended_at IS NULL").Find(&running).Error)
+	require.Len(t, running, 1)
+	assert.Equal(t, tasks[1].ID, running[0].TaskID)
+	assert.Error(t, db.Create(&model.TimeEntry{TaskID: tasks[0].ID, UserID: 1, StartedAt: time.Now()}).Error,
+		"the unique index backs up the check")
+
+	w = timeRequest(db, StopTimer, http.MethodPost, "/", "", taskParam(tasks[0].ID))
+	assert.Equal(t, http.StatusConflict, w.Code, "the timer runs on the other task")
+	w = timeRequest(db, StopTimer, http.MethodPost, "/", "", taskParam(tasks[1].ID))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = timeRequest(db, GetRunningTimer, http.MethodGet, "/", "", nil)
+	assert.JSONEq(t, `{"running":null}`, w.Body.String())
+}
+
+func TestTimeEntries_ManualEntriesAndTotals(t *testing.T) {
+	db := setupTestDB(t)
+	estimate := 60
+	task := model.Task{Title: "Write the proposal", UserID: 1, EstimatedMinutes: &estimate}
+	require.NoError(t, db.Create(&task).Error)
+
+	start := time.Now().Add(-3 * time.Hour).UTC().Truncate(time.Second)
+	body := fmt.Sprintf(`{"started_at":%q,"minutes":45,"note":"outline"}`, start.Format(time.RFC3339))
+	w := timeRequest(db, CreateTimeEntry, http.MethodPost, "/", body, taskParam(task.ID))
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &entry))
+	entryParams := append(taskParam(task.ID), gin.Param{Key: "entryId", Value: fmt.Sprint(entry["id"])})
+
+	body = fmt.Sprintf(`{"started_at":%q,"ended_at":%q}`, start.Format(time.RFC3339), start.Add(-time.Minute).Format(time.RFC3339))
+	w = timeRequest(db, CreateTimeEntry, http.MethodPost, "/", body, taskParam(task.ID))
+	assert.Equal(t, http.StatusBadRequest, w.Code, "ends before it starts")
+
+	w = timeRequest(db, CreateTimeEntry, http.MethodPost, "/", fmt.Sprintf(`{"started_at":%q}`, start.Format(time.RFC3339)), taskParam(task.ID))
+	assert.Equal(t, http.StatusBadRequest, w.Code, "needs an end or a duration")
+
+	w = timeRequest(db, UpdateTimeEntry, http.MethodPut, "/", `{"minutes":75}`, entryParams)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = timeRequest(db, GetTimeEntries, http.MethodGet, "/api/task/1/time", "", taskParam(task.ID))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp struct {
+		Entries []map[string]any
+		Totals  struct {
+			LoggedMinutes    int  `json:"logged_minutes"`
+			EstimatedMinutes *int `json:"estimated_minutes"`
+			RemainingMinutes *int `json:"remaining_minutes"`
+		}
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Entries, 1)
+	assert.Equal(t, 75, resp.Totals.LoggedMinutes)
+	require.NotNil(t, resp.Totals.RemainingMinutes)
+	assert.Equal(t, -15, *resp.Totals.RemainingMinutes, "over the estimate")
+
+	c, w := setupContext(http.MethodDelete, "/", "", 2)
+	c.Params = entryParams
+	DeleteTimeEntry(db)(c)
+	assert.Equal(t, http.StatusNotFound, w.Code, "only the author may delete an entry")
+}
+
+func TestTimesheet_GroupsByDayAndWeek(t *testing.T) {
+	db := setupTestDB(t)
+	tasks := []model.Task{{Title: "Client A work", UserID: 1}, {Title: "=Client B work", UserID: 1}}
+	require.NoError(t, db.Create(&tasks).Error)
+
+	at := func(day, hour, minutes int) (time.Time, *time.Time) {
+		start := time.Date(2026, 3, day, hour, 0, 0, 0, time.UTC)
+		end := start.Add(time.Duration(minutes) * time.Minute)
+		return start, &end
+	}
+	var entries []model.TimeEntry
+	for _, e := range []struct{ task, day, hour, minutes int }{
+		{0, 9, 9, 60}, {0, 9, 14, 30}, {1, 9, 16, 20}, // Monday
+		{0, 11, 10, 45}, // Wednesday
+		{1, 16, 10, 15}, // next Monday
+		{0, 20, 10, 99}, // outside the range
+	} {
+		start, end := at(e.day, e.hour, e.minutes)
+		entries = append(entries, model.TimeEntry{TaskID: tasks[e.task].ID, UserID: 1, StartedAt: start, EndedAt: end})
+	}
+	entries = append(entries, model.TimeEntry{TaskID: tasks[0].ID, UserID: 2, StartedAt: entries[0].StartedAt, EndedAt: entries[0].EndedAt})
+	require.NoError(t, db.Create(&entries).Error)
+
+	w := timeRequest(db, GetTimesheet, http.MethodGet, "/api/time/report?from=2026-03-09&to=2026-03-16", "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var sheet Timesheet
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &sheet))
+	assert.Equal(t, 170, sheet.TotalMinutes, "other users' entries are not included")
+	require.Len(t, sheet.Periods, 3)
+	assert.Equal(t, "2026-03-09", sheet.Periods[0].Period)
+	assert.Equal(t, []TimesheetTask{
+		{TaskID: tasks[0].ID, Title: "Client A work", Minutes: 90},
+		{TaskID: tasks[1].ID, Title: "=Client B work", Minutes: 20},
+	}, sheet.Periods[0].Tasks)
+
+	w = timeRequest(db, GetTimesheet, http.MethodGet, "/api/time/report?from=2026-03-09&to=2026-03-16&group=week&format=csv", "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	records, err := csv.NewReader(strings.NewReader(w.Body.String())).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"week", "task_id", "title", "minutes", "hours"},
+		{"2026-03-09", fmt.Sprint(tasks[0].ID), "Client A work", "135", "2.25"},
+		{"2026-03-09", fmt.Sprint(tasks[1].ID), "'=Client B work", "20", "0.33"},
+		{"2026-03-16", fmt.Sprint(tasks[1].ID), "'=Client B work", "15", "0.25"},
+		{"total", "", "", "170", "2.83"},
+	}, records)
+
+	w = timeRequest(db, GetTimesheet, http.MethodGet, "/api/time/report?from=2026-03-16&to=2026-03-09", "", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
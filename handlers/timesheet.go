@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/Niraj1910/Task-REST-APIs/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxTimesheetDays bounds the date range of a timesheet.
+const maxTimesheetDays = 366
+
+// TimesheetTask is the time logged on one task in a period.
+type TimesheetTask struct {
+	TaskID  uint   `json:"task_id"`
+	Title   string `json:"title"`
+	Minutes int    `json:"minutes"`
+}
+
+// TimesheetPeriod is one day or week of a timesheet.
+type TimesheetPeriod struct {
+	// Period is the day, or the Monday starting the week, as YYYY-MM-DD.
+	Period  string          `json:"period"`
+	Minutes int             `json:"minutes"`
+	Tasks   []TimesheetTask `json:"tasks"`
+}
+
+// Timesheet is the time a user logged in a date range.
+type Timesheet struct {
+	From         string            `json:"from"`
+	To           string            `json:"to"`
+	Group        string            `json:"group"`
+	TimeZone     string            `json:"tz"`
+	TotalMinutes int               `json:"total_minutes"`
+	Periods      []TimesheetPeriod `json:"periods"`
+}
+
+// dateRange is a range of whole days in a time zone; end is exclusive.
+type dateRange struct {
+	from, to   string
+	start, end time.Time
+	loc        *time.Location
+}
+
+// GetTimesheet godoc
+// @Summary      Timesheet report
+// @Description  Sums the time the user logged between from and to (inclusive) per day or week and task. Entries count
+// @Description  towards the day they started; running timers count up to now. format=csv downloads the same rows.
+// @Tags         Time
+// @Security     BearerAuth
+// @Produce      json
+// @Produce      text/csv
+// @Param        from   query string false "First day, YYYY-MM-DD (default: 6 days before to)"
+// @Param        to     query string false "Last day, YYYY-MM-DD (default: today)"
+// @Param        group  query string false "day (default) or week"
+// @Param        tz     query string false "IANA time zone of the days (default UTC)"
+// @Param        format query string false "json (default) or csv"
+// @Success      200 {object} handlers.Timesheet
+// @Failure      400 {object} map[string]string "Invalid range, group, time zone or format"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Router       /api/time/report [get]
+func GetTimesheet(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		group := ctx.DefaultQuery("group", "day")
+		if group != "day" && group != "week" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "group must be day or week"})
+			return
+		}
+		format := ctx.DefaultQuery("format", "json")
+		if format != "json" && format != "csv" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or csv"})
+			return
+		}
+
+		period, err := dateRangeFromQuery(ctx, true, time.UTC)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		sheet, err := buildTimesheet(db, userID, period, group)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build the timesheet"})
+			return
+		}
+
+		if format == "json" {
+			ctx.JSON(http.StatusOK, sheet)
+			return
+		}
+
+		filename := fmt.Sprintf("timesheet-%s-%s.csv", sheet.From, sheet.To)
+		ctx.Header("Content-Type", "text/csv; charset=utf-8")
+		ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+		ctx.Status(http.StatusOK)
+
+		w := csv.NewWriter(ctx.Writer)
+		w.Write([]string{group, "task_id", "title", "minutes", "hours"})
+		for _, p := range sheet.Periods {
+			for _, task := range p.Tasks {
+				w.Write([]string{
+					p.Period,
+					strconv.FormatUint(uint64(task.TaskID), 10),
+					csvSafe(task.Title),
+					strconv.Itoa(task.Minutes),
+					strconv.FormatFloat(float64(task.Minutes)/60, 'f', 2, 64),
+				})
+			}
+		}
+		w.Write([]string{"total", "", "", strconv.Itoa(sheet.TotalMinutes), strconv.FormatFloat(float64(sheet.TotalMinutes)/60, 'f', 2, 64)})
+		w.Flush()
+	}
+}
+
+// buildTimesheet groups the user's entries in the range by period and task.
+// Minutes are rounded per task and period, and the totals add those up, so
+// the rows of a report always sum to its total.
+func buildTimesheet(db *gorm.DB, userID uint, period dateRange, group string) (Timesheet, error) {
+
+	var rows []struct {
+		TaskID    uint
+		Title     string
+		StartedAt time.Time
+		EndedAt   *time.Time
+	}
+	// deleted tasks keep their title: the time was still spent
+	err := db.Model(&model.TimeEntry{}).
+		Select("time_entries.task_id, tasks.title, time_entries.started_at, time_entries.ended_at").
+		Joins("JOIN tasks ON tasks.id = time_entries.task_id").
+		Where("time_entries.user_id = ? AND time_entries.started_at >= ? AND time_entries.started_at < ?", userID, period.start.UTC(), period.end.UTC()).
+		Scan(&rows).Error
+	if err != nil {
+		return Timesheet{}, err
+	}
+
+	type key struct {
+		period string
+		taskID uint
+	}
+	now := time.Now()
+	durations := map[key]time.Duration{}
+	titles := map[uint]string{}
+	for _, row := range rows {
+		day := row.StartedAt.In(period.loc)
+		if group == "week" {
+			// weeks start on Monday
+			day = day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+		}
+		k := key{day.Format(time.DateOnly), row.TaskID}
+		durations[k] += entryDuration(model.TimeEntry{StartedAt: row.StartedAt, EndedAt: row.EndedAt}, now)
+		titles[row.TaskID] = row.Title
+	}
+
+	byPeriod := map[string]*TimesheetPeriod{}
+	for k, d := range durations {
+		p := byPeriod[k.period]
+		if p == nil {
+			p = &TimesheetPeriod{Period: k.period}
+			byPeriod[k.period] = p
+		}
+		minutes := roundMinutes(d)
+		p.Tasks = append(p.Tasks, TimesheetTask{TaskID: k.taskID, Title: titles[k.taskID], Minutes: minutes})
+		p.Minutes += minutes
+	}
+
+	sheet := Timesheet{
+		From:     period.from,
+		To:       period.to,
+		Group:    group,
+		TimeZone: period.loc.String(),
+		Periods:  []TimesheetPeriod{},
+	}
+	for _, p := range byPeriod {
+		sort.Slice(p.Tasks, func(i, j int) bool { return p.Tasks[i].TaskID < p.Tasks[j].TaskID })
+		sheet.Periods = append(sheet.Periods, *p)
+		sheet.TotalMinutes += p.Minutes
+	}
+	sort.Slice(sheet.Periods, func(i, j int) bool { return sheet.Periods[i].Period < sheet.Periods[j].Period })
+	return sheet, nil
+}
+
+// dateRangeFromQuery reads the from, to and tz query parameters; without tz
+// the days are those of zone. With defaults, a missing to is today, a
+// missing from the 6 days before it and the range is limited to
+// maxTimesheetDays; otherwise a missing bound is open.
+func dateRangeFromQuery(ctx *gin.Context, defaults bool, zone *time.Location) (dateRange, error) {
+
+	loc := zone
+	if tz := ctx.Query("tz"); tz != "" {
+		var err error
+		loc, err = time.LoadLocation(tz)
+		if err != nil {
+			return dateRange{}, errors.New("tz must be an IANA time zone, e.g. Europe/Berlin")
+		}
+	}
+	r := dateRange{loc: loc}
+
+	parse := func(name string) (time.Time, bool, error) {
+		value := ctx.Query(name)
+		if value == "" {
+			return time.Time{}, false, nil
+		}
+		day, err := time.ParseInLocation(time.DateOnly, value, loc)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("%s must be a date (YYYY-MM-DD)", name)
+		}
+		return day, true, nil
+	}
+
+	from, hasFrom, err := parse("from")
+	if err != nil {
+		return r, err
+	}
+	to, hasTo, err := parse("to")
+	if err != nil {
+		return r, err
+	}
+
+	if !defaults {
+		// a missing bound leaves that side of the range open
+		r.start, r.end = from, time.Date(9999, 1, 1, 0, 0, 0, 0, loc)
+		if hasTo {
+			r.end = to.AddDate(0, 0, 1)
+		}
+		if hasFrom && hasTo && to.Before(from) {
+			return r, errors.New("to must not be before from")
+		}
+		return r, nil
+	}
+
+	if !hasTo {
+		now := time.Now().In(loc)
+		to = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	}
+	if !hasFrom {
+		from = to.AddDate(0, 0, -6)
+	}
+	if to.Before(from) {
+		return r, errors.New("to must not be before from")
+	}
+	if to.Sub(from) >= maxTimesheetDays*24*time.Hour {
+		return r, fmt.Errorf("the range can span at most %d days", maxTimesheetDays)
+	}
+
+	r.from, r.to = from.Format(time.DateOnly), to.Format(time.DateOnly)
+	r.start, r.end = from, to.AddDate(0, 0, 1)
+	return r, nil
+}
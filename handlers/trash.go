@@ -0,0 +1,308 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/Niraj1910/Task-REST-APIs/storage"
+	"github.com/Niraj1910/Task-REST-APIs/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+const defaultTrashRetentionDays = 30
+
+// TrashRetention is how long deleted tasks stay restorable before they are
+// purged. TRASH_RETENTION_DAYS overrides the default of 30 days.
+func TrashRetention() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("TRASH_RETENTION_DAYS"))
+	if err != nil || days < 1 {
+		days = defaultTrashRetentionDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// GetTrash godoc
+// @Summary      List trashed tasks
+// @Description  Returns the authenticated user's deleted tasks that can still be restored, most recently deleted first
+// @Tags         Trash
+// @Security     BearerAuth
+// @Produce      json
+// @Param        page  query int false "Page number"    default(1)
+// @Param        limit query int false "Items per page" default(10)
+// @Success      200 {object} map[string]interface{} "Trashed tasks with pagination meta"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      500 {object} map[string]string "Server error"
+// @Router       /api/task/trash [get]
+func GetTrash(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		page, limit, offset := pageParams(ctx)
+
+		query := db.Unscoped().Model(&model.Task{}).Where("user_id = ? AND deleted_at IS NOT NULL", userID)
+
+		var total int64
+		query.Count(&total)
+
+		var tasks []model.Task
+		err := query.Order("deleted_at DESC").Limit(limit).Offset(offset).Find(&tasks).Error
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve trash", "details": err.Error()})
+			return
+		}
+
+		retention := TrashRetention()
+		items := make([]gin.H, 0, len(tasks))
+		for _, task := range tasks {
+			items = append(items, gin.H{
+				"id":         task.ID,
+				"title":      task.Title,
+				"status":     task.Status,
+				"priority":   task.Priority,
+				"deleted_at": task.DeletedAt.Time,
+				"purge_at":   task.DeletedAt.Time.Add(retention),
+			})
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"tasks": items,
+			"meta": gin.H{
+				"total": total,
+				"page":  page,
+				"limit": limit,
+			},
+		})
+	}
+}
+
+// RestoreTask godoc
+// @Summary      Restore a trashed task
+// @Description  Brings a deleted task back together with the comments and attachments that were deleted with it
+// @Tags         Trash
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id path int true "Task ID"
+// @Success      200 {object} map[string]interface{} "Task restored"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      404 {object} map[string]string "Task not in trash or not owned"
+// @Failure      500 {object} map[string]string "Server error"
+// @Router       /api/task/{id}/restore [post]
+func RestoreTask(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		taskID, ok := parseIDParam(ctx, "id", "Task ID")
+		if !ok {
+			return
+		}
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			return restoreTask(tx, taskID, userID)
+		})
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not in trash or not owned by you"})
+				return
+			}
+			log.Error().Err(err).Uint("task_id", taskID).Msg("Failed to restore task")
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore task"})
+			return
+		}
+
+		// to the clients the task is back in the list
+		publishTaskEvents(db, eventTaskCreated, []uint{taskID})
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Successfully restored task", "task_id": taskID})
+	}
+}
+
+// PurgeTask godoc
+// @Summary      Permanently delete a trashed task
+// @Description  Removes a task from the trash for good, including its comments, attachments, dependencies and history
+// @Tags         Trash
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id path int true "Task ID"
+// @Success      200 {object} map[string]interface{} "Task purged"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      404 {object} map[string]string "Task not in trash or not owned"
+// @Failure      500 {object} map[string]string "Server error"
+// @Router       /api/task/{id}/purge [delete]
+func PurgeTask(db *gorm.DB, store storage.Storage) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		taskID, ok := parseIDParam(ctx, "id", "Task ID")
+		if !ok {
+			return
+		}
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		var count int64
+		db.Unscoped().Model(&model.Task{}).Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", taskID, userID).Count(&count)
+		if count == 0 {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not in trash or not owned by you"})
+			return
+		}
+
+		err := purgeTasks(db, store, []uint{taskID})
+		if err != nil {
+			log.Error().Err(err).Uint("task_id", taskID).Msg("Failed to purge task")
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to purge task"})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Task permanently deleted", "task_id": taskID})
+	}
+}
+
+// PurgeExpiredTasks permanently removes tasks that have been in the trash
+// longer than retention. It returns how many tasks were purged.
+func PurgeExpiredTasks(db *gorm.DB, store storage.Storage, retention time.Duration) (int, error) {
+
+	cutoff := time.Now().Add(-retention)
+	purged := 0
+
+	for {
+		var ids []uint
+		err := db.Unscoped().Model(&model.Task{}).
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+			Limit(100).
+			Pluck("id", &ids).Error
+		if err != nil {
+			return purged, err
+		}
+		if len(ids) == 0 {
+			return purged, nil
+		}
+
+		err = purgeTasks(db, store, ids)
+		if err != nil {
+			return purged, err
+		}
+		purged += len(ids)
+	}
+}
+
+// trashTaskChildren soft-deletes a task's comments and attachments with the
+// task's own deletion time, so a restore can tell them apart from children
+// that were deleted on their own earlier.
+func trashTaskChildren(tx *gorm.DB, taskID uint) error {
+
+	var task model.Task
+	err := tx.Unscoped().Select("id", "deleted_at").First(&task, taskID).Error
+	if err != nil {
+		return err
+	}
+
+	err = tx.Model(&model.Comment{}).Where("task_id = ?", taskID).Update("deleted_at", task.DeletedAt).Error
+	if err != nil {
+		return err
+	}
+	return tx.Model(&model.Attachment{}).Where("task_id = ?", taskID).Update("deleted_at", task.DeletedAt).Error
+}
+
+// restoreTask undeletes an owned task and the children trashed with it.
+func restoreTask(tx *gorm.DB, taskID, userID uint) error {
+
+	var task model.Task
+	err := tx.Unscoped().Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", taskID, userID).First(&task).Error
+	if err != nil {
+		return err
+	}
+
+	deletedAt := task.DeletedAt.Time
+
+	err = tx.Unscoped().Model(&task).Updates(map[string]interface{}{"deleted_at": nil, "version": nextVersion}).Error
+	if err != nil {
+		return err
+	}
+
+	err = tx.Unscoped().Model(&model.Comment{}).Where("task_id = ? AND deleted_at >= ?", taskID, deletedAt).Update("deleted_at", nil).Error
+	if err != nil {
+		return err
+	}
+
+	err = tx.Unscoped().Model(&model.Attachment{}).Where("task_id = ? AND deleted_at >= ?", taskID, deletedAt).Update("deleted_at", nil).Error
+	if err != nil {
+		return err
+	}
+
+	err = enqueueTaskWebhooks(tx, eventTaskCreated, taskID)
+	if err != nil {
+		return err
+	}
+	return recordTaskEvent(tx, taskID, userID, "restored", "", nil, nil)
+}
+
+// purgeTasks hard-deletes tasks with everything hanging off them, then
+// removes the attachment files once the rows are gone.
+func purgeTasks(db *gorm.DB, store storage.Storage, taskIDs []uint) error {
+
+	var attachments []model.Attachment
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Where("task_id IN ?", taskIDs).Find(&attachments).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Unscoped().Where("task_id IN ?", taskIDs).Delete(&model.Attachment{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Unscoped().Where("task_id IN ?", taskIDs).Delete(&model.Comment{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Unscoped().Where("task_id IN ?", taskIDs).Delete(&model.TimeEntry{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Unscoped().Where("task_id IN ?", taskIDs).Delete(&model.TaskReminder{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Unscoped().Where("task_id IN ?", taskIDs).Delete(&model.Notification{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Where("task_id IN ?", taskIDs).Delete(&model.TaskEvent{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Where("task_id IN ? OR blocked_by_id IN ?", taskIDs, taskIDs).Delete(&model.TaskDependency{}).Error
+		if err != nil {
+			return err
+		}
+		// subtasks outlive their parent
+		err = tx.Unscoped().Model(&model.Task{}).Where("parent_id IN ?", taskIDs).Update("parent_id", nil).Error
+		if err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("id IN ?", taskIDs).Delete(&model.Task{}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	deleteBlobs(store, attachments)
+	return nil
+}
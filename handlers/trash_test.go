@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/Niraj1910/Task-REST-APIs/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestTrash_DeleteListRestore(t *testing.T) {
+	db := setupTestDB(t)
+	task := model.Task{Title: "Plan offsite", UserID: 1}
+	require.NoError(t, db.Create(&task).Error)
+	idParam := gin.Params{{Key: "id", Value: fmt.Sprint(task.ID)}}
+
+	kept := model.Comment{TaskID: task.ID, UserID: 1, Body: "keep me"}
+	removed := model.Comment{TaskID: task.ID, UserID: 1, Body: "deleted on its own"}
+	require.NoError(t, db.Create(&[]*model.Comment{&kept, &removed}).Error)
+	require.NoError(t, db.Delete(&removed).Error)
+
+	time.Sleep(5 * time.Millisecond)
+
+	c, w := setupContext(http.MethodDelete, "/api/task/1", "", 1)
+	c.Params = idParam
+	DeleteTask(db)(c)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	c, w = setupContext(http.MethodGet, "/api/task/trash", "", 1)
+	GetTrash(db)(c)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var trash struct {
+		Tasks []map[string]interface{} `json:"tasks"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &trash)
+	require.Len(t, trash.Tasks, 1)
+	assert.Equal(t, "Plan offsite", trash.Tasks[0]["title"])
+	assert.NotEmpty(t, trash.Tasks[0]["purge_at"])
+
+	// another user can neither see nor restore it
+	c, w = setupContext(http.MethodPost, "/api/task/1/restore", "", 2)
+	c.Params = idParam
+	RestoreTask(db)(c)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	c, w = setupContext(http.MethodPost, "/api/task/1/restore", "", 1)
+	c.Params = idParam
+	RestoreTask(db)(c)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var restored model.Task
+	assert.NoError(t, db.First(&restored, task.ID).Error)
+
+	// only the comment deleted together with the task comes back
+	var comments []model.Comment
+	db.Where("task_id = ?", task.ID).Find(&comments)
+	require.Len(t, comments, 1)
+	assert.Equal(t, "keep me", comments[0].Body)
+
+	var events []model.TaskEvent
+	db.Where("task_id = ?", task.ID).Order("id").Find(&events)
+	require.Len(t, events, 2)
+	assert.Equal(t, "deleted", events[0].Action)
+	assert.Equal(t, "restored", events[1].Action)
+}
+
+func TestPurgeExpiredTasks(t *testing.T) {
+	db := setupTestDB(t)
+	store, err := storage.NewLocal(t.TempDir())
+	require.NoError(t, err)
+
+	old := model.Task{Title: "Old trash", UserID: 1}
+	recent := model.Task{Title: "Recent trash", UserID: 1}
+	active := model.Task{Title: "Still active", UserID: 1}
+	require.NoError(t, db.Create(&[]*model.Task{&old, &recent, &active}).Error)
+	require.NoError(t, db.Create(&model.Comment{TaskID: old.ID, UserID: 1, Body: "bye"}).Error)
+
+	db.Unscoped().Model(&old).Update("deleted_at", gorm.DeletedAt{Time: time.Now().Add(-40 * 24 * time.Hour), Valid: true})
+	db.Delete(&recent)
+
+	purged, err := PurgeExpiredTasks(db, store, 30*24*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	var count int64
+	db.Unscoped().Model(&model.Task{}).Count(&count)
+	assert.Equal(t, int64(2), count)
+	db.Unscoped().Model(&model.Comment{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
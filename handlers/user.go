@@ -4,6 +4,7 @@ import (
 	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/Niraj1910/Task-REST-APIs/model"
 	_ "github.com/Niraj1910/Task-REST-APIs/types"
@@ -17,6 +18,10 @@ type UpdateUserBody struct {
 	Name     *string `json:"name" binding:"omitempty,min=5,max=100"`
 	Email    *string `json:"email" binding:"omitempty,max=255"`
 	Password *string `json:"password" binding:"omitempty,min=5,max=255"`
+	// TimeZone is an IANA zone name such as Europe/Berlin.
+	TimeZone        *string `json:"time_zone" binding:"omitempty,max=64"`
+	DigestFrequency *string `json:"digest_frequency" binding:"omitempty,oneof=off daily weekly"`
+	DigestHour      *int    `json:"digest_hour" binding:"omitempty,gte=0,lte=23"`
 }
 
 // GetUserProfile godoc
@@ -51,20 +56,29 @@ func GetUserProfile(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		ctx.JSON(http.StatusOK, gin.H{
+		profile := gin.H{
 			"id":         user.ID,
 			"username":   user.Name,
 			"email":      user.Email,
 			"role":       user.Role,
 			"created_at": user.CreatedAt,
-		})
+			"time_zone":  user.TimeZone,
+			"digest": gin.H{
+				"frequency": user.DigestFrequency,
+				"hour":      user.DigestHour,
+			},
+		}
+		if user.CalendarToken != nil {
+			profile["calendar_url"] = calendarURL(*user.CalendarToken)
+		}
+		ctx.JSON(http.StatusOK, profile)
 
 	}
 }
 
 // UpdateUser godoc
 // @Summary      Update current user profile
-// @Description  Partially updates user profile (name, email, password)
+// @Description  Partially updates user profile (name, email, password, time zone and digest email settings)
 // @Tags         Users
 // @Security     BearerAuth
 // @Accept       json
@@ -118,6 +132,22 @@ func UpdateUser(db *gorm.DB) gin.HandlerFunc {
 			updates["password"] = utils.HashPassword(*userBody.Password)
 		}
 
+		if userBody.TimeZone != nil {
+			if _, err := time.LoadLocation(*userBody.TimeZone); err != nil || *userBody.TimeZone == "" {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid input", "details": "time_zone must be an IANA time zone, e.g. Europe/Berlin"})
+				return
+			}
+			updates["time_zone"] = *userBody.TimeZone
+		}
+		if userBody.DigestFrequency != nil {
+			updates["digest_frequency"] = *userBody.DigestFrequency
+			// the first digest goes out at the next digest hour, not right away
+			updates["digest_sent_at"] = time.Now().UTC()
+		}
+		if userBody.DigestHour != nil {
+			updates["digest_hour"] = *userBody.DigestHour
+		}
+
 		if len(updates) == 0 {
 			ctx.JSON(http.StatusBadRequest, gin.H{"error": "No fields provided to update"})
 			return
@@ -157,12 +187,16 @@ type TaskListResponse struct {
 
 // GetUserTasks godoc
 // @Summary      Get tasks of the current user
-// @Description  Returns list of tasks belonging to the authenticated user
+// @Description  Returns list of tasks created by or assigned to the authenticated user
 // @Tags         Users
 // @Security     BearerAuth
 // @Accept       json
 // @Produce      json
-// @Success      200 {object} types.SwaggerTaskListResponse
+// @Param        assigned_to query string false "Only tasks assigned to this user ('me' or a user ID)"
+// @Param        created_by  query string false "Only tasks created by this user ('me' or a user ID)"
+// @Param        cursor      query string false "Switches to cursor pagination; empty for the first page, then next_cursor or prev_cursor from the previous response"
+// @Param        include_total query bool false "In cursor mode, also count all matching tasks"
+// @Success      200 {object} types.SwaggerTaskListResponse "Offset mode; cursor mode returns handlers.TaskCursorPage with a Link header"
 // @Failure      401 {object} map[string]string "Unauthorized"
 // @Router       /api/user/task [get]
 func GetUserTasks(db *gorm.DB) gin.HandlerFunc {
@@ -173,29 +207,40 @@ func GetUserTasks(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		pageStr := ctx.DefaultQuery("page", "1")
-		limitStr := ctx.DefaultQuery("limit", "10")
 		status := ctx.Query("status")
 		sort := ctx.DefaultQuery("sort", "created_at:desc")
 
-		page, _ := strconv.Atoi(pageStr)
-		limit, _ := strconv.Atoi(limitStr)
+		page, limit, offset := pageParams(ctx)
 
-		if page < 1 {
-			page = 1
-		}
-		if limit < 1 || limit > 100 {
-			limit = 10
-		}
+		query := db.Scopes(visibleTasks(userID))
 
-		offset := (page - 1) * limit
+		if assignedTo := ctx.Query("assigned_to"); assignedTo != "" {
+			assigneeID, ok := userFilterValue(assignedTo, userID)
+			if !ok {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "assigned_to must be 'me' or a user ID"})
+				return
+			}
+			query = query.Where("assignee_id = ?", assigneeID)
+		}
 
-		query := db.Where("user_id = ?", userID)
+		if createdBy := ctx.Query("created_by"); createdBy != "" {
+			creatorID, ok := userFilterValue(createdBy, userID)
+			if !ok {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "created_by must be 'me' or a user ID"})
+				return
+			}
+			query = query.Where("user_id = ?", creatorID)
+		}
 
 		if status != "" {
 			query = query.Where("status = ?", status)
 		}
 
+		if cursorRequested(ctx) {
+			paginateTasksByCursor(ctx, query, sort, limit, taskFields)
+			return
+		}
+
 		switch sort {
 		case "created_at:asc":
 			query = query.Order("created_at ASC")
@@ -241,3 +286,15 @@ func GetUserTasks(db *gorm.DB) gin.HandlerFunc {
 		})
 	}
 }
+
+// userFilterValue resolves a user filter query value: "me" or a numeric ID.
+func userFilterValue(value string, userID uint) (uint, bool) {
+	if value == "me" {
+		return userID, true
+	}
+	id, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/Niraj1910/Task-REST-APIs/config"
 	"github.com/Niraj1910/Task-REST-APIs/model"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -17,9 +18,10 @@ import (
 )
 
 func setupTestDB(t *testing.T) *gorm.DB {
-	db, err := gorm.Open(sqlite.Open("file::memory:?cache=private"), &gorm.Config{})
+	db, err := gorm.Open(sqlite.New(sqlite.Config{DriverName: config.SQLiteDriver, DSN: "file::memory:?cache=private"}), &gorm.Config{})
 	require.NoError(t, err)
-	require.NoError(t, db.AutoMigrate(&model.User{}, &model.Task{}, &model.EmailVerification{}))
+	require.NoError(t, db.AutoMigrate(&model.User{}, &model.Task{}, &model.EmailVerification{}, &model.TaskEvent{}, &model.Comment{}, &model.Attachment{}, &model.TaskDependency{}, &model.ImportJob{}, &model.TimeEntry{}, &model.TaskReminder{}, &model.Notification{}, &model.NotificationPreference{}, &model.Webhook{}, &model.WebhookDelivery{}, &model.SavedView{}, &model.TaskTemplate{}, &model.CustomField{}))
+	require.NoError(t, config.SetupTaskSearch(db))
 	return db
 }
 
@@ -148,3 +150,15 @@ func TestGetUserTasks_Success_WithPagination(t *testing.T) {
 	assert.Equal(t, 2, resp.Meta.Page)
 	assert.Equal(t, 5, resp.Meta.Limit)
 }
+
+func TestGetUserTasks_ClampsLimit(t *testing.T) {
+	db := setupTestDB(t)
+
+	c, w := setupContext(http.MethodGet, "/tasks?limit=500", "", 1)
+	GetUserTasks(db)(c)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp TaskListResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, 100, resp.Meta.Limit, "capped like GET /api/task")
+}
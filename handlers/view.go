@@ -0,0 +1,465 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Niraj1910/Task-REST-APIs/filter"
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/Niraj1910/Task-REST-APIs/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// maxViewsPerUser caps how many views one user saves.
+const maxViewsPerUser = 50
+
+// ViewDisplay holds how a client shows a view. The API only stores it.
+type ViewDisplay struct {
+	Layout string `json:"layout" binding:"omitempty,oneof=list board"`
+	// Columns are task fields, e.g. ["title", "due_at", "priority"].
+	Columns []string `json:"columns"`
+	GroupBy string   `json:"group_by" binding:"omitempty,oneof=status priority assignee_id"`
+}
+
+type SavedViewBody struct {
+	Name   *string     `json:"name" binding:"omitempty,max=100"`
+	Filter *TaskFilter `json:"filter"`
+	// Sort is a GetTasks sort, e.g. priority:desc,due_at:asc, or topological.
+	Sort    *string      `json:"sort" binding:"omitempty,max=200"`
+	Limit   *int         `json:"limit" binding:"omitempty,gte=1,lte=100"`
+	Display *ViewDisplay `json:"display"`
+	// ProjectID would share the view with a project's members; projects do
+	// not exist yet.
+	ProjectID *uint `json:"project_id"`
+}
+
+// smartList is a built-in view every user has, addressed by its key.
+type smartList struct {
+	Key  string
+	View model.SavedView
+}
+
+var blockedOnly = true
+
+var smartLists = []smartList{
+	{"today", model.SavedView{Name: "Today", Expression: "status != completed AND due_at < tomorrow", Sort: "due_at:asc", PageSize: 50}},
+	{"high-priority", model.SavedView{Name: "High priority", Expression: "status != completed AND priority >= 8", Sort: "priority:desc,due_at:asc", PageSize: 50}},
+	{"waiting", model.SavedView{Name: "Waiting", Blocked: &blockedOnly, Expression: "status != completed", Sort: "created_at:asc", PageSize: 50}},
+}
+
+// CreateView godoc
+// @Summary      Save a view
+// @Description  Saves a named task query: the filters of GET /api/task, a sort, a page size and display options. The
+// @Description  filter expression may use relative dates (now, today, tomorrow, today+7d), resolved in the user's time
+// @Description  zone when the view runs.
+// @Tags         Views
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        body body handlers.SavedViewBody true "View"
+// @Success      201 {object} map[string]interface{} "Saved view"
+// @Failure      400 {object} map[string]interface{} "Invalid filter, sort or display options"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      409 {object} map[string]string "A view with this name exists"
+// @Router       /api/views [post]
+func CreateView(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		var input SavedViewBody
+		if err := ctx.ShouldBindJSON(&input); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid input", "details": err.Error()})
+			return
+		}
+		if input.Name == nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid input", "details": "name is required"})
+			return
+		}
+
+		fields, err := taskFieldsFor(db, userID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve custom fields"})
+			return
+		}
+		view := model.SavedView{UserID: userID, PageSize: 10}
+		if err := input.applyTo(&view, fields); err != nil {
+			invalidFilter(ctx, err)
+			return
+		}
+
+		var count int64
+		db.Model(&model.SavedView{}).Where("user_id = ?", userID).Count(&count)
+		if count >= maxViewsPerUser {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("You can save at most %d views", maxViewsPerUser)})
+			return
+		}
+		if viewNameTaken(db, view) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "A view with this name exists"})
+			return
+		}
+
+		if err := db.Create(&view).Error; err != nil {
+			log.Error().Err(err).Uint("user_id", userID).Msg("Failed to save view")
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save view"})
+			return
+		}
+		ctx.JSON(http.StatusCreated, viewResponse(view, ""))
+	}
+}
+
+// GetViews godoc
+// @Summary      List views
+// @Description  Returns the user's saved views and the built-in smart lists (today, high-priority, waiting)
+// @Tags         Views
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200 {object} map[string]interface{} "views and smart_lists"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Router       /api/views [get]
+func GetViews(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		var views []model.SavedView
+		if err := db.Where("user_id = ?", userID).Order("name, id").Find(&views).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve views"})
+			return
+		}
+
+		items := make([]gin.H, 0, len(views))
+		for _, view := range views {
+			items = append(items, viewResponse(view, ""))
+		}
+		lists := make([]gin.H, 0, len(smartLists))
+		for _, list := range smartLists {
+			lists = append(lists, viewResponse(list.View, list.Key))
+		}
+		ctx.JSON(http.StatusOK, gin.H{"views": items, "smart_lists": lists})
+	}
+}
+
+// GetView godoc
+// @Summary      Get a view
+// @Tags         Views
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id path string true "View ID or smart list key"
+// @Success      200 {object} map[string]interface{} "View"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      404 {object} map[string]string "View not found"
+// @Router       /api/views/{id} [get]
+func GetView(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		view, key, ok := findView(ctx, db)
+		if !ok {
+			return
+		}
+		ctx.JSON(http.StatusOK, viewResponse(view, key))
+	}
+}
+
+// UpdateView godoc
+// @Summary      Update a view
+// @Description  Changes the given fields of a saved view. Smart lists cannot be changed.
+// @Tags         Views
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id   path int true "View ID"
+// @Param        body body handlers.SavedViewBody true "Fields to change"
+// @Success      200 {object} map[string]interface{} "Saved view"
+// @Failure      400 {object} map[string]interface{} "Invalid filter, sort or display options"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      404 {object} map[string]string "View not found"
+// @Failure      409 {object} map[string]string "A view with this name exists"
+// @Router       /api/views/{id} [put]
+func UpdateView(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		view, ok := findOwnView(ctx, db)
+		if !ok {
+			return
+		}
+
+		var input SavedViewBody
+		if err := ctx.ShouldBindJSON(&input); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid input", "details": err.Error()})
+			return
+		}
+		fields, err := taskFieldsFor(db, view.UserID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve custom fields"})
+			return
+		}
+		if err := input.applyTo(&view, fields); err != nil {
+			invalidFilter(ctx, err)
+			return
+		}
+		if viewNameTaken(db, view) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "A view with this name exists"})
+			return
+		}
+
+		err = db.Model(&view).Select("name", "status", "blocked", "expression", "sort", "page_size", "display").Updates(&view).Error
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update view"})
+			return
+		}
+		ctx.JSON(http.StatusOK, viewResponse(view, ""))
+	}
+}
+
+// DeleteView godoc
+// @Summary      Delete a view
+// @Tags         Views
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id path int true "View ID"
+// @Success      200 {object} map[string]string "View deleted"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      404 {object} map[string]string "View not found"
+// @Router       /api/views/{id} [delete]
+func DeleteView(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		view, ok := findOwnView(ctx, db)
+		if !ok {
+			return
+		}
+		if err := db.Delete(&view).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete view"})
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{"message": "View deleted"})
+	}
+}
+
+// GetViewTasks godoc
+// @Summary      Run a view
+// @Description  Lists the tasks you created or are assigned to that match the view, in its sort order, like GET /api/task.
+// @Description  A view whose filter or sort no longer validates (e.g. it names a field that was removed) answers 422.
+// @Tags         Views
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id    path  string true  "View ID or smart list key"
+// @Param        page  query int    false "Page number" default(1)
+// @Param        limit query int    false "Items per page (default: the view's limit)"
+// @Success      200 {object} map[string]interface{} "Total, Page, Tasks and the view"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      404 {object} map[string]string "View not found"
+// @Failure      422 {object} map[string]interface{} "The view's filter or sort is no longer valid"
+// @Router       /api/views/{id}/tasks [get]
+func GetViewTasks(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		view, key, ok := findView(ctx, db)
+		if !ok {
+			return
+		}
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+		page = max(page, 1)
+		limit := view.PageSize
+		if value, err := strconv.Atoi(ctx.Query("limit")); err == nil && value >= 1 && value <= 100 {
+			limit = value
+		}
+
+		now := userNow(db, userID)
+
+		fields, err := taskFieldsFor(db, userID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve custom fields"})
+			return
+		}
+		f := TaskFilter{Status: view.Status, Blocked: view.Blocked, Expression: view.Expression}
+		query, err := f.applyAt(db.Scopes(visibleTasks(userID)), fields, now)
+		if err != nil {
+			invalidView(ctx, err)
+			return
+		}
+
+		sort := view.Sort
+		if sort == "" {
+			sort = "created_at:desc"
+		}
+		if sort == "topological" {
+			getTasksTopological(ctx, db, query, page, limit)
+			return
+		}
+		order, err := taskOrder(sort, fields)
+		if err != nil {
+			invalidView(ctx, err)
+			return
+		}
+
+		var total int64
+		query.Model(&model.Task{}).Count(&total)
+
+		var tasks []model.Task
+		err = query.Clauses(order).Limit(limit).Offset((page - 1) * limit).Find(&tasks).Error
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve tasks", "details": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"Total": total,
+			"Page":  page,
+			"Tasks": tasks,
+			"view":  viewResponse(view, key),
+		})
+	}
+}
+
+// applyTo validates the given fields and copies them onto view, so a view
+// that is saved can also be run. fields are the task fields of the owner.
+func (b SavedViewBody) applyTo(view *model.SavedView, fields filter.Fields) error {
+
+	if b.ProjectID != nil {
+		return errors.New("projects are not available, views cannot be shared yet")
+	}
+	if b.Name != nil {
+		name := strings.TrimSpace(*b.Name)
+		if name == "" {
+			return errors.New("name must not be empty")
+		}
+		view.Name = name
+	}
+	if b.Filter != nil {
+		if b.Filter.Expression != "" {
+			if _, _, err := filter.ParseAndCompile(b.Filter.Expression, fields); err != nil {
+				return err
+			}
+		}
+		view.Status, view.Blocked, view.Expression = b.Filter.Status, b.Filter.Blocked, b.Filter.Expression
+	}
+	if b.Sort != nil {
+		if *b.Sort != "" && *b.Sort != "topological" {
+			if _, err := taskOrder(*b.Sort, fields); err != nil {
+				return err
+			}
+		}
+		view.Sort = *b.Sort
+	}
+	if b.Limit != nil {
+		view.PageSize = *b.Limit
+	}
+	if b.Display != nil {
+		for _, column := range b.Display.Columns {
+			if _, ok := fields[column]; !ok {
+				return fmt.Errorf("unknown display column %q", column)
+			}
+		}
+		display, err := json.Marshal(b.Display)
+		if err != nil {
+			return err
+		}
+		view.Display = string(display)
+	}
+	return nil
+}
+
+// findView loads the view of the id parameter: a smart list key or one of
+// the user's saved views.
+func findView(ctx *gin.Context, db *gorm.DB) (model.SavedView, string, bool) {
+
+	key := ctx.Param("id")
+	for _, list := range smartLists {
+		if list.Key == key {
+			return list.View, key, true
+		}
+	}
+	view, ok := findOwnView(ctx, db)
+	return view, "", ok
+}
+
+func findOwnView(ctx *gin.Context, db *gorm.DB) (model.SavedView, bool) {
+
+	var view model.SavedView
+
+	viewID, ok := parseIDParam(ctx, "id", "View ID")
+	if !ok {
+		return view, false
+	}
+	userID, ok := utils.UserIDFromContext(ctx)
+	if !ok {
+		return view, false
+	}
+
+	err := db.Where("id = ? AND user_id = ?", viewID, userID).First(&view).Error
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "View not found"})
+		return view, false
+	}
+	return view, true
+}
+
+func viewNameTaken(db *gorm.DB, view model.SavedView) bool {
+	var count int64
+	db.Model(&model.SavedView{}).Where("user_id = ? AND LOWER(name) = LOWER(?) AND id <> ?", view.UserID, view.Name, view.ID).Count(&count)
+	return count > 0 || slices.ContainsFunc(smartLists, func(list smartList) bool {
+		return strings.EqualFold(list.View.Name, view.Name)
+	})
+}
+
+// invalidView answers a run of a view that was valid when saved but no
+// longer is, e.g. because a field it filters on was removed.
+func invalidView(ctx *gin.Context, err error) {
+
+	var filterErr *filter.Error
+	if errors.As(err, &filterErr) {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": "The view's filter is no longer valid, edit the view", "details": filterErr})
+		return
+	}
+	ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": "The view's sort is no longer valid, edit the view", "details": err.Error()})
+}
+
+// viewResponse shows a saved view, or a smart list when key is set.
+func viewResponse(view model.SavedView, key string) gin.H {
+
+	var display ViewDisplay
+	if view.Display != "" {
+		json.Unmarshal([]byte(view.Display), &display)
+	}
+
+	resp := gin.H{
+		"id":   view.ID,
+		"name": view.Name,
+		"filter": gin.H{
+			"status":     view.Status,
+			"blocked":    view.Blocked,
+			"expression": view.Expression,
+		},
+		"sort":       view.Sort,
+		"limit":      view.PageSize,
+		"display":    display,
+		"smart_list": key != "",
+	}
+	if key != "" {
+		resp["id"] = key
+	} else {
+		resp["created_at"] = view.CreatedAt
+		resp["updated_at"] = view.UpdatedAt
+	}
+	return resp
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type viewTaskList struct {
+	Total int64
+	Tasks []model.Task
+	View  gin.H `json:"view"`
+}
+
+func runView(t *testing.T, db *gorm.DB, userID uint, id any, query string) (viewTaskList, int) {
+	t.Helper()
+	c, w := setupContext(http.MethodGet, "/"+query, "", userID)
+	c.Params = gin.Params{{Key: "id", Value: fmt.Sprint(id)}}
+	GetViewTasks(db)(c)
+
+	var list viewTaskList
+	if w.Code == http.StatusOK {
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	}
+	return list, w.Code
+}
+
+func taskTitles(tasks []model.Task) []string {
+	titles := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		titles = append(titles, task.Title)
+	}
+	return titles
+}
+
+func TestSavedViews_SaveValidateAndRun(t *testing.T) {
+	db := setupTestDB(t)
+	users := []model.User{{Name: "owner", Email: "owner@example.com"}, {Name: "other", Email: "other@example.com"}}
+	require.NoError(t, db.Create(&users).Error)
+	tasks := []model.Task{
+		{Title: "Urgent report", Priority: 9, UserID: users[0].ID},
+		{Title: "Urgent but done", Priority: 9, Status: "completed", UserID: users[0].ID},
+		{Title: "Important review", Priority: 7, UserID: users[0].ID},
+		{Title: "Minor chore", Priority: 1, UserID: users[0].ID},
+		{Title: "Assigned report", Priority: 8, UserID: users[1].ID, AssigneeID: &users[0].ID},
+	}
+	require.NoError(t, db.Create(&tasks).Error)
+
+	for _, body := range []string{
+		`{"filter": {"expression": "priority >= 5"}}`,
+		`{"name": "Bad", "filter": {"expression": "label = urgent"}}`,
+		`{"name": "Bad", "sort": "secret:asc"}`,
+		`{"name": "Bad", "display": {"columns": ["title", "password"]}}`,
+		`{"name": "Bad", "display": {"layout": "gallery"}}`,
+		`{"name": "Bad", "project_id": 3}`,
+	} {
+		c, w := setupContext(http.MethodPost, "/api/views", body, users[0].ID)
+		CreateView(db)(c)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+
+	body := `{"name": "Important", "filter": {"expression": "priority >= 7 AND status != completed"}, "sort": "priority:desc,title:asc", "limit": 2, "display": {"layout": "list", "columns": ["title", "priority"]}}`
+	c, w := setupContext(http.MethodPost, "/api/views", body, users[0].ID)
+	CreateView(db)(c)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var view gin.H
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &view))
+	assert.Equal(t, map[string]any{"layout": "list", "columns": []any{"title", "priority"}, "group_by": ""}, view["display"])
+
+	c, w = setupContext(http.MethodPost, "/api/views", `{"name": "important"}`, users[0].ID)
+	CreateView(db)(c)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	list, code := runView(t, db, users[0].ID, view["id"], "")
+	require.Equal(t, http.StatusOK, code)
+	assert.EqualValues(t, 3, list.Total, "assigned tasks count too")
+	assert.Equal(t, []string{"Urgent report", "Assigned report"}, taskTitles(list.Tasks))
+	list, _ = runView(t, db, users[0].ID, view["id"], "?page=2")
+	assert.Equal(t, []string{"Important review"}, taskTitles(list.Tasks))
+
+	_, code = runView(t, db, users[1].ID, view["id"], "")
+	assert.Equal(t, http.StatusNotFound, code, "views are private")
+
+	c, w = setupContext(http.MethodPut, "/", `{"filter": {"status": "completed"}, "sort": ""}`, users[0].ID)
+	c.Params = gin.Params{{Key: "id", Value: fmt.Sprint(view["id"])}}
+	UpdateView(db)(c)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	list, _ = runView(t, db, users[0].ID, view["id"], "")
+	assert.Equal(t, []string{"Urgent but done"}, taskTitles(list.Tasks))
+	assert.Equal(t, "Important", list.View["name"])
+}
+
+func TestSavedViews_SmartListsAndStaleFilters(t *testing.T) {
+	db := setupTestDB(t)
+	user := model.User{Name: "owner", Email: "owner@example.com", TimeZone: "Pacific/Kiritimati"}
+	require.NoError(t, db.Create(&user).Error)
+
+	// 14 hours ahead of UTC it is already the next day there
+	local := time.Now().In(userLocation(user))
+	endOfToday := time.Date(local.Year(), local.Month(), local.Day(), 23, 0, 0, 0, local.Location())
+	dueToday, dueLater := endOfToday.UTC(), endOfToday.Add(2*time.Hour).UTC()
+	tasks := []model.Task{
+		{Title: "Due today", UserID: user.ID, DueAt: &dueToday},
+		{Title: "Due tomorrow", UserID: user.ID, DueAt: &dueLater},
+		{Title: "Blocked", UserID: user.ID},
+		{Title: "Blocker", UserID: user.ID},
+	}
+	require.NoError(t, db.Create(&tasks).Error)
+	require.NoError(t, db.Create(&model.TaskDependency{TaskID: tasks[2].ID, BlockedByID: tasks[3].ID}).Error)
+
+	c, w := setupContext(http.MethodGet, "/api/views", "", user.ID)
+	GetViews(db)(c)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"id":"today"`)
+
+	list, code := runView(t, db, user.ID, "today", "")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"Due today"}, taskTitles(list.Tasks))
+	assert.Equal(t, true, list.View["smart_list"])
+
+	list, _ = runView(t, db, user.ID, "waiting", "")
+	assert.Equal(t, []string{"Blocked"}, taskTitles(list.Tasks))
+
+	// a view saved when its filter was valid, e.g. before a field was removed
+	stale := model.SavedView{UserID: user.ID, Name: "By label", Expression: "label = urgent", PageSize: 10}
+	require.NoError(t, db.Create(&stale).Error)
+	_, code = runView(t, db, user.ID, stale.ID, "")
+	assert.Equal(t, http.StatusUnprocessableEntity, code)
+
+	c, w = setupContext(http.MethodPut, "/", `{"name": "Today"}`, user.ID)
+	c.Params = gin.Params{{Key: "id", Value: fmt.Sprint(stale.ID)}}
+	UpdateView(db)(c)
+	assert.Equal(t, http.StatusConflict, w.Code, "smart list names are taken")
+}
@@ -0,0 +1,429 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/Niraj1910/Task-REST-APIs/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// maxWebhooksPerUser caps how many webhooks one user configures.
+const maxWebhooksPerUser = 10
+
+// webhookEventTypes are the events a webhook can subscribe to.
+var webhookEventTypes = []string{eventTaskCreated, eventTaskUpdated, eventTaskDeleted}
+
+type WebhookBody struct {
+	URL *string `json:"url" binding:"omitempty,max=2000"`
+	// Events to send: task.created, task.updated and/or task.deleted.
+	Events []string `json:"events"`
+	// Secret signs the payloads; generated when creating without one.
+	Secret *string `json:"secret" binding:"omitempty,min=16,max=100"`
+	// Active re-enables a webhook that was switched off after failures.
+	Active *bool `json:"active"`
+}
+
+// CreateWebhook godoc
+// @Summary      Add a webhook
+// @Description  Posts the chosen task events to url, signed with HMAC-SHA256. The secret is only returned here; without
+// @Description  one a random secret is generated.
+// @Tags         Webhooks
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        body body handlers.WebhookBody true "Webhook"
+// @Success      201 {object} map[string]interface{} "Created webhook, including the secret"
+// @Failure      400 {object} map[string]string "Invalid URL, events or secret"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Router       /api/webhooks [post]
+func CreateWebhook(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		var input WebhookBody
+		err := ctx.ShouldBindJSON(&input)
+		if err == nil && input.URL == nil {
+			err = errors.New("url is required")
+		}
+		if err == nil && input.Events == nil {
+			err = errors.New("events is required")
+		}
+		webhook := model.Webhook{UserID: userID, Active: true}
+		if err == nil {
+			err = input.applyTo(&webhook)
+		}
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid input", "details": err.Error()})
+			return
+		}
+
+		var count int64
+		db.Model(&model.Webhook{}).Where("user_id = ?", userID).Count(&count)
+		if count >= maxWebhooksPerUser {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("You can have at most %d webhooks", maxWebhooksPerUser)})
+			return
+		}
+
+		if webhook.Secret == "" {
+			secret := make([]byte, 24)
+			if _, err := rand.Read(secret); err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate secret"})
+				return
+			}
+			webhook.Secret = "whsec_" + hex.EncodeToString(secret)
+		}
+
+		if err := db.Create(&webhook).Error; err != nil {
+			log.Error().Err(err).Uint("user_id", userID).Msg("Failed to create webhook")
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook"})
+			return
+		}
+
+		resp := webhookResponse(webhook)
+		resp["secret"] = webhook.Secret
+		ctx.JSON(http.StatusCreated, resp)
+	}
+}
+
+// GetWebhooks godoc
+// @Summary      List webhooks
+// @Tags         Webhooks
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200 {object} map[string]interface{} "webhooks"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Router       /api/webhooks [get]
+func GetWebhooks(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		var webhooks []model.Webhook
+		if err := db.Where("user_id = ?", userID).Order("id").Find(&webhooks).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve webhooks"})
+			return
+		}
+
+		items := make([]gin.H, 0, len(webhooks))
+		for _, webhook := range webhooks {
+			items = append(items, webhookResponse(webhook))
+		}
+		ctx.JSON(http.StatusOK, gin.H{"webhooks": items})
+	}
+}
+
+// GetWebhook godoc
+// @Summary      Get a webhook
+// @Tags         Webhooks
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id path int true "Webhook ID"
+// @Success      200 {object} map[string]interface{} "Webhook"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      404 {object} map[string]string "Webhook not found"
+// @Router       /api/webhooks/{id} [get]
+func GetWebhook(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		webhook, ok := findOwnWebhook(ctx, db)
+		if !ok {
+			return
+		}
+		ctx.JSON(http.StatusOK, webhookResponse(webhook))
+	}
+}
+
+// UpdateWebhook godoc
+// @Summary      Update a webhook
+// @Description  Changes the URL, events or secret. "active": true re-enables a webhook that was switched off after
+// @Description  repeated failures; its pending deliveries are then sent again.
+// @Tags         Webhooks
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id   path int true "Webhook ID"
+// @Param        body body handlers.WebhookBody true "Fields to change"
+// @Success      200 {object} map[string]interface{} "Webhook"
+// @Failure      400 {object} map[string]string "Invalid input"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      404 {object} map[string]string "Webhook not found"
+// @Router       /api/webhooks/{id} [put]
+func UpdateWebhook(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		webhook, ok := findOwnWebhook(ctx, db)
+		if !ok {
+			return
+		}
+
+		var input WebhookBody
+		err := ctx.ShouldBindJSON(&input)
+		if err == nil {
+			err = input.applyTo(&webhook)
+		}
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid input", "details": err.Error()})
+			return
+		}
+
+		updates := map[string]interface{}{
+			"url":    webhook.URL,
+			"events": webhook.Events,
+			"secret": webhook.Secret,
+		}
+		if input.Active != nil {
+			updates["active"] = *input.Active
+			if *input.Active {
+				updates["failure_count"] = 0
+				updates["disabled_at"] = nil
+				updates["disabled_reason"] = ""
+			}
+		}
+		if err := db.Model(&webhook).Updates(updates).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update webhook"})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, webhookResponse(webhook))
+	}
+}
+
+// DeleteWebhook godoc
+// @Summary      Delete a webhook
+// @Description  Stops all deliveries of the webhook, including pending ones
+// @Tags         Webhooks
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id path int true "Webhook ID"
+// @Success      200 {object} map[string]string "Webhook deleted"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      404 {object} map[string]string "Webhook not found"
+// @Router       /api/webhooks/{id} [delete]
+func DeleteWebhook(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		webhook, ok := findOwnWebhook(ctx, db)
+		if !ok {
+			return
+		}
+		if err := db.Delete(&webhook).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete webhook"})
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{"message": "Webhook deleted"})
+	}
+}
+
+// GetWebhookDeliveries godoc
+// @Summary      Webhook delivery log
+// @Description  Lists the deliveries of a webhook, newest first, with the outcome of the last attempt
+// @Tags         Webhooks
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id     path  int    true  "Webhook ID"
+// @Param        status query string false "pending, succeeded or failed"
+// @Param        page   query int    false "Page number" default(1)
+// @Param        limit  query int    false "Items per page" default(10)
+// @Success      200 {object} map[string]interface{} "deliveries and meta"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      404 {object} map[string]string "Webhook not found"
+// @Router       /api/webhooks/{id}/deliveries [get]
+func GetWebhookDeliveries(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		webhook, ok := findOwnWebhook(ctx, db)
+		if !ok {
+			return
+		}
+
+		page, limit, offset := pageParams(ctx)
+
+		query := db.Model(&model.WebhookDelivery{}).Where("webhook_id = ?", webhook.ID)
+		if status := ctx.Query("status"); status != "" {
+			query = query.Where("status = ?", status)
+		}
+
+		var total int64
+		query.Count(&total)
+
+		var deliveries []model.WebhookDelivery
+		err := query.Order("id DESC").Limit(limit).Offset(offset).Find(&deliveries).Error
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve deliveries"})
+			return
+		}
+
+		items := make([]gin.H, 0, len(deliveries))
+		for _, delivery := range deliveries {
+			items = append(items, webhookDeliveryResponse(delivery))
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"deliveries": items,
+			"meta": gin.H{
+				"total": total,
+				"page":  page,
+				"limit": limit,
+			},
+		})
+	}
+}
+
+// RedeliverWebhook godoc
+// @Summary      Redeliver a webhook event
+// @Description  Queues the delivery's event again, with the same event id so receivers can tell it is a repeat
+// @Tags         Webhooks
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id         path int true "Webhook ID"
+// @Param        deliveryId path int true "Delivery ID"
+// @Success      202 {object} map[string]interface{} "Queued delivery"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      404 {object} map[string]string "Webhook or delivery not found"
+// @Failure      409 {object} map[string]string "Webhook is disabled"
+// @Router       /api/webhooks/{id}/deliveries/{deliveryId}/redeliver [post]
+func RedeliverWebhook(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		webhook, ok := findOwnWebhook(ctx, db)
+		if !ok {
+			return
+		}
+		deliveryID, ok := parseIDParam(ctx, "deliveryId", "Delivery ID")
+		if !ok {
+			return
+		}
+
+		var original model.WebhookDelivery
+		err := db.Where("id = ? AND webhook_id = ?", deliveryID, webhook.ID).First(&original).Error
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Delivery not found"})
+			return
+		}
+		if !webhook.Active {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "Webhook is disabled, re-enable it first"})
+			return
+		}
+
+		delivery := model.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			EventID:       original.EventID,
+			EventType:     original.EventType,
+			Payload:       original.Payload,
+			Status:        "pending",
+			NextAttemptAt: time.Now().UTC(),
+		}
+		if err := db.Create(&delivery).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to queue delivery"})
+			return
+		}
+
+		ctx.JSON(http.StatusAccepted, webhookDeliveryResponse(delivery))
+	}
+}
+
+// applyTo validates the given fields and copies them onto webhook.
+func (b WebhookBody) applyTo(webhook *model.Webhook) error {
+
+	if b.URL != nil {
+		u, err := url.Parse(*b.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.New("url must be an absolute http or https URL")
+		}
+		if err := checkWebhookHost(u.Hostname()); err != nil {
+			return err
+		}
+		webhook.URL = *b.URL
+	}
+	if b.Events != nil {
+		if len(b.Events) == 0 {
+			return errors.New("events must not be empty")
+		}
+		var events []string
+		for _, event := range b.Events {
+			if !slices.Contains(webhookEventTypes, event) {
+				return fmt.Errorf("unknown event %q, use %s", event, strings.Join(webhookEventTypes, ", "))
+			}
+			if !slices.Contains(events, event) {
+				events = append(events, event)
+			}
+		}
+		webhook.Events = strings.Join(events, ",")
+	}
+	if b.Secret != nil {
+		webhook.Secret = *b.Secret
+	}
+	return nil
+}
+
+func findOwnWebhook(ctx *gin.Context, db *gorm.DB) (model.Webhook, bool) {
+
+	var webhook model.Webhook
+
+	webhookID, ok := parseIDParam(ctx, "id", "Webhook ID")
+	if !ok {
+		return webhook, false
+	}
+	userID, ok := utils.UserIDFromContext(ctx)
+	if !ok {
+		return webhook, false
+	}
+
+	err := db.Where("id = ? AND user_id = ?", webhookID, userID).First(&webhook).Error
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return webhook, false
+	}
+	return webhook, true
+}
+
+// webhookResponse leaves out the secret.
+func webhookResponse(webhook model.Webhook) gin.H {
+	return gin.H{
+		"id":              webhook.ID,
+		"url":             webhook.URL,
+		"events":          strings.Split(webhook.Events, ","),
+		"active":          webhook.Active,
+		"failure_count":   webhook.FailureCount,
+		"disabled_at":     webhook.DisabledAt,
+		"disabled_reason": webhook.DisabledReason,
+		"created_at":      webhook.CreatedAt,
+	}
+}
+
+func webhookDeliveryResponse(delivery model.WebhookDelivery) gin.H {
+	return gin.H{
+		"id":              delivery.ID,
+		"event_id":        delivery.EventID,
+		"event_type":      delivery.EventType,
+		"payload":         json.RawMessage(delivery.Payload),
+		"status":          delivery.Status,
+		"attempts":        delivery.Attempts,
+		"next_attempt_at": delivery.NextAttemptAt,
+		"last_attempt_at": delivery.LastAttemptAt,
+		"response_status": delivery.ResponseStatus,
+		"response_body":   delivery.ResponseBody,
+		"error":           delivery.Error,
+		"delivered_at":    delivery.DeliveredAt,
+		"created_at":      delivery.CreatedAt,
+	}
+}
@@ -0,0 +1,402 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+const (
+	// webhookMaxAttempts is how often a delivery is tried before it is
+	// marked failed. With the backoff below that spans about an hour.
+	webhookMaxAttempts = 8
+	webhookBackoff     = 30 * time.Second
+	// webhookDisableAfter consecutive failed attempts switch a webhook off.
+	webhookDisableAfter = 20
+	webhookBatchSize    = 50
+	// webhookLease keeps other instances away from a delivery in flight.
+	webhookLease = 2 * time.Minute
+	// webhookLogBytes is how much of a response body the log keeps.
+	webhookLogBytes = 1000
+)
+
+var errWebhookAddress = errors.New("webhooks cannot be sent to loopback, private or link-local addresses")
+
+// webhookDeniedPrefixes are internal ranges the netip predicates below do
+// not cover. IPv4-mapped IPv6 literals are refused outright rather than
+// judged by the IPv4 address they carry.
+var webhookDeniedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("100.64.0.0/10"), // shared address space (carrier-grade NAT)
+	netip.MustParsePrefix("::ffff:0:0/96"),
+}
+
+// webhookAddressAllowed reports whether a webhook may connect to addr.
+// Ranges listed in WEBHOOK_ALLOWED_CIDRS (comma separated, e.g.
+// "10.20.0.0/16,fd00::/8") are allowed even if they are internal.
+func webhookAddressAllowed(addr netip.Addr) bool {
+
+	for _, entry := range envList("WEBHOOK_ALLOWED_CIDRS") {
+		prefix, err := netip.ParsePrefix(entry)
+		if err == nil && prefix.Contains(addr) {
+			return true
+		}
+	}
+	for _, prefix := range webhookDeniedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return !addr.IsLoopback() && !addr.IsPrivate() && !addr.IsUnspecified() &&
+		!addr.IsLinkLocalUnicast() && !addr.IsLinkLocalMulticast() && !addr.IsMulticast()
+}
+
+// webhookHostAllowed reports whether host is listed in
+// WEBHOOK_ALLOWED_HOSTS (comma separated), which lets webhooks reach it
+// whatever it resolves to.
+func webhookHostAllowed(host string) bool {
+	for _, entry := range envList("WEBHOOK_ALLOWED_HOSTS") {
+		if strings.EqualFold(entry, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// envList splits a comma separated environment variable, dropping blanks.
+func envList(name string) []string {
+	var list []string
+	for _, entry := range strings.Split(os.Getenv(name), ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			list = append(list, entry)
+		}
+	}
+	return list
+}
+
+// webhookClient does not follow redirects: a 3xx counts as a failure. Its
+// dialer checks the address it actually connects to, so a host that was
+// public when the webhook was saved cannot rebind to an internal address.
+// There is no proxy, which would hide that address.
+var webhookClient = &http.Client{
+	Timeout: 10 * time.Second,
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+	Transport: &http.Transport{
+		DialContext:         dialWebhook,
+		ForceAttemptHTTP2:   true,
+		MaxIdleConns:        100,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: 5 * time.Second,
+	},
+}
+
+var (
+	webhookDialer        = &net.Dialer{Timeout: 5 * time.Second, Control: webhookDialControl}
+	allowedWebhookDialer = &net.Dialer{Timeout: 5 * time.Second}
+)
+
+// dialWebhook skips the address check for hosts in WEBHOOK_ALLOWED_HOSTS;
+// every other connection goes through webhookDialControl.
+func dialWebhook(ctx context.Context, network, address string) (net.Conn, error) {
+
+	host, _, err := net.SplitHostPort(address)
+	if err == nil && webhookHostAllowed(host) {
+		return allowedWebhookDialer.DialContext(ctx, network, address)
+	}
+	return webhookDialer.DialContext(ctx, network, address)
+}
+
+func webhookDialControl(network, address string, _ syscall.RawConn) error {
+
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if addr, err := netip.ParseAddr(host); err != nil || !webhookAddressAllowed(addr) {
+		return errWebhookAddress
+	}
+	return nil
+}
+
+// checkWebhookHost resolves the host of a webhook URL and refuses it if
+// any of its addresses is internal and not allowed.
+func checkWebhookHost(host string) error {
+
+	if webhookHostAllowed(host) {
+		return nil
+	}
+	// IP literals are checked as written, so a mapped IPv6 form stays one
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if !webhookAddressAllowed(addr) {
+			return errWebhookAddress
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil || len(addrs) == 0 {
+		return fmt.Errorf("cannot resolve host %q", host)
+	}
+	for _, addr := range addrs {
+		// the resolver returns IPv4 addresses in their mapped form
+		if !webhookAddressAllowed(addr.Unmap()) {
+			return errWebhookAddress
+		}
+	}
+	return nil
+}
+
+// webhookPayload is the body posted to webhooks.
+type webhookPayload struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// enqueueTaskWebhooks writes the outbox rows for a task change inside the
+// transaction making it, so an event is sent if and only if the change
+// commits. The owner's and assignee's subscribed webhooks each get one.
+func enqueueTaskWebhooks(tx *gorm.DB, eventType string, taskID uint) error {
+
+	var task model.Task
+	if err := tx.Unscoped().First(&task, taskID).Error; err != nil {
+		return err
+	}
+
+	users := []uint{task.UserID}
+	if task.AssigneeID != nil && *task.AssigneeID != task.UserID {
+		users = append(users, *task.AssigneeID)
+	}
+
+	var webhooks []model.Webhook
+	if err := tx.Where("user_id IN ? AND active = ?", users, true).Find(&webhooks).Error; err != nil {
+		return err
+	}
+	webhooks = slices.DeleteFunc(webhooks, func(w model.Webhook) bool {
+		return !slices.Contains(strings.Split(w.Events, ","), eventType)
+	})
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	var data any = taskResponse(task)
+	if eventType == eventTaskDeleted {
+		data = gin.H{"id": task.ID}
+	}
+	payload := webhookPayload{ID: uuid.NewString(), Type: eventType, CreatedAt: now, Data: data}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	deliveries := make([]model.WebhookDelivery, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		deliveries = append(deliveries, model.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			EventID:       payload.ID,
+			EventType:     eventType,
+			Payload:       string(body),
+			Status:        "pending",
+			NextAttemptAt: now,
+		})
+	}
+	return tx.Create(&deliveries).Error
+}
+
+// DeliverWebhooks sends the outbox deliveries that are due and returns how
+// many succeeded. Each delivery is leased with a conditional update first,
+// so several instances can run it side by side. clock is read again for
+// every delivery: a batch can take minutes, and both the lease and the
+// signed timestamp must be fresh when a delivery is sent.
+func DeliverWebhooks(db *gorm.DB, clock func() time.Time) (int, error) {
+
+	now := clock()
+	var due []model.WebhookDelivery
+	err := db.Joins("JOIN webhooks ON webhooks.id = webhook_deliveries.webhook_id").
+		Where("webhooks.deleted_at IS NULL AND webhooks.active = ?", true).
+		Where("webhook_deliveries.status = ? AND webhook_deliveries.next_attempt_at <= ?", "pending", now.UTC()).
+		Order("webhook_deliveries.next_attempt_at, webhook_deliveries.id").
+		Limit(webhookBatchSize).
+		Find(&due).Error
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for _, delivery := range due {
+		// checked per row: an earlier one in the batch may have disabled it
+		var webhook model.Webhook
+		if err := db.Where("active = ?", true).First(&webhook, delivery.WebhookID).Error; err != nil {
+			continue
+		}
+
+		// attempts doubles as the version of the row
+		now := clock()
+		result := db.Model(&model.WebhookDelivery{}).
+			Where("id = ? AND status = ? AND attempts = ?", delivery.ID, "pending", delivery.Attempts).
+			Updates(map[string]interface{}{
+				"attempts":        delivery.Attempts + 1,
+				"next_attempt_at": now.Add(webhookLease).UTC(),
+			})
+		if result.Error != nil {
+			return delivered, result.Error
+		}
+		if result.RowsAffected != 1 {
+			continue
+		}
+		delivery.Attempts++
+
+		ok, err := attemptWebhook(db, webhook, delivery, clock)
+		if err != nil {
+			return delivered, err
+		}
+		if ok {
+			delivered++
+		}
+	}
+	return delivered, nil
+}
+
+// attemptWebhook posts one delivery and records the outcome.
+func attemptWebhook(db *gorm.DB, webhook model.Webhook, delivery model.WebhookDelivery, clock func() time.Time) (bool, error) {
+
+	updates := map[string]interface{}{
+		"last_attempt_at": clock().UTC(),
+		"response_status": nil,
+		"response_body":   "",
+		"error":           "",
+	}
+
+	status, body, err := postWebhook(webhook, delivery, clock())
+	now := clock()
+	ok := err == nil && status >= 200 && status < 300
+	if err != nil {
+		updates["error"] = truncate(err.Error(), webhookLogBytes)
+	} else {
+		updates["response_status"] = status
+		updates["response_body"] = body
+		if !ok {
+			updates["error"] = fmt.Sprintf("unexpected status %d", status)
+		}
+	}
+
+	switch {
+	case ok:
+		updates["status"] = "succeeded"
+		updates["delivered_at"] = now.UTC()
+	case delivery.Attempts >= webhookMaxAttempts:
+		updates["status"] = "failed"
+	default:
+		updates["next_attempt_at"] = now.Add(webhookRetryDelay(delivery.Attempts)).UTC()
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.WebhookDelivery{}).Where("id = ?", delivery.ID).Updates(updates).Error
+		if err != nil {
+			return err
+		}
+		if ok {
+			return tx.Model(&model.Webhook{}).Where("id = ? AND failure_count > 0", webhook.ID).Update("failure_count", 0).Error
+		}
+
+		err = tx.Model(&model.Webhook{}).Where("id = ?", webhook.ID).Update("failure_count", gorm.Expr("failure_count + 1")).Error
+		if err != nil {
+			return err
+		}
+		result := tx.Model(&model.Webhook{}).
+			Where("id = ? AND active = ? AND failure_count >= ?", webhook.ID, true, webhookDisableAfter).
+			Updates(map[string]interface{}{
+				"active":          false,
+				"disabled_at":     now.UTC(),
+				"disabled_reason": fmt.Sprintf("%d failed delivery attempts in a row", webhookDisableAfter),
+			})
+		if result.RowsAffected == 1 {
+			log.Warn().Uint("webhook_id", webhook.ID).Msg("Disabled failing webhook")
+		}
+		return result.Error
+	})
+	return ok, err
+}
+
+// postWebhook sends the payload, signed as described in the README, and
+// returns the response status and the start of its body.
+func postWebhook(webhook model.Webhook, delivery model.WebhookDelivery, now time.Time) (int, string, error) {
+
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, strings.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Task-REST-APIs-Webhooks")
+	req.Header.Set("X-Webhook-Id", delivery.EventID)
+	req.Header.Set("X-Webhook-Event", delivery.EventType)
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", webhookSignature(webhook.Secret, timestamp, []byte(delivery.Payload)))
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookLogBytes))
+	return resp.StatusCode, string(bytes.ToValidUTF8(body, nil)), nil
+}
+
+// webhookSignature signs "<timestamp>.<body>" so a captured request cannot
+// be replayed later with a new timestamp.
+func webhookSignature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookRetryDelay doubles the wait after each failed attempt.
+func webhookRetryDelay(attempts int) time.Duration {
+	return webhookBackoff << (attempts - 1)
+}
+
+// PruneWebhookDeliveries removes finished deliveries older than the cutoff.
+func PruneWebhookDeliveries(db *gorm.DB, before time.Time) (int64, error) {
+	result := db.Unscoped().
+		Where("status <> ? AND created_at < ?", "pending", before.UTC()).
+		Delete(&model.WebhookDelivery{})
+	return result.RowsAffected, result.Error
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
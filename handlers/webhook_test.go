@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// webhookReceiver records the requests whose signature checks out and
+// answers with the given status.
+type webhookReceiver struct {
+	mu       sync.Mutex
+	secret   string
+	status   int
+	received []map[string]any
+	ids      []string
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	mac := hmac.New(sha256.New, []byte(r.secret))
+	mac.Write([]byte(req.Header.Get("X-Webhook-Timestamp") + "."))
+	mac.Write(body)
+	if req.Header.Get("X-Webhook-Signature") != "sha256="+hex.EncodeToString(mac.Sum(nil)) {
+		http.Error(w, "bad signature", http.StatusUnauthorized)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var payload map[string]any
+	json.Unmarshal(body, &payload)
+	r.received = append(r.received, payload)
+	r.ids = append(r.ids, req.Header.Get("X-Webhook-Id"))
+	w.WriteHeader(r.status)
+	fmt.Fprint(w, "received")
+}
+
+func createWebhook(t *testing.T, db *gorm.DB, userID uint, body string) gin.H {
+	t.Helper()
+	c, w := setupContext(http.MethodPost, "/api/webhooks", body, userID)
+	CreateWebhook(db)(c)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var webhook gin.H
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &webhook))
+	return webhook
+}
+
+func fixedClock(now time.Time) func() time.Time {
+	return func() time.Time { return now }
+}
+
+// allowLoopbackWebhooks lets webhooks reach httptest servers for one test.
+func allowLoopbackWebhooks(t *testing.T) {
+	t.Setenv("WEBHOOK_ALLOWED_CIDRS", "127.0.0.0/8, ::1/128")
+}
+
+func webhookParams(webhookID any, more ...gin.Param) gin.Params {
+	return append(gin.Params{{Key: "id", Value: fmt.Sprint(webhookID)}}, more...)
+}
+
+func TestWebhooks_SignedDeliveriesFromTaskChanges(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.Create(&model.User{Name: "owner", Email: "owner@example.com"}).Error)
+
+	receiver := &webhookReceiver{status: http.StatusOK}
+	server := httptest.NewServer(receiver)
+	t.Cleanup(server.Close)
+
+	for _, body := range []string{
+		fmt.Sprintf(`{"url": %q, "events": ["task.created"]}`, server.URL),
+		`{"url": "http://169.254.169.254/latest/meta-data", "events": ["task.created"]}`,
+		`{"url": "http://[::1]:8080/hook", "events": ["task.created"]}`,
+		`{"url": "http://100.64.1.2/hook", "events": ["task.created"]}`,
+		`{"url": "http://[::ffff:8.8.8.8]/hook", "events": ["task.created"]}`,
+	} {
+		c, w := setupContext(http.MethodPost, "/api/webhooks", body, 1)
+		CreateWebhook(db)(c)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+		assert.Contains(t, w.Body.String(), "loopback, private or link-local", body)
+	}
+	require.ErrorIs(t, webhookDialControl("tcp4", "10.0.0.8:443", nil), errWebhookAddress, "checked again when dialing")
+
+	t.Setenv("WEBHOOK_ALLOWED_CIDRS", "10.20.0.0/16")
+	t.Setenv("WEBHOOK_ALLOWED_HOSTS", "hooks.internal")
+	assert.NoError(t, checkWebhookHost("10.20.3.4"))
+	assert.NoError(t, webhookDialControl("tcp4", "10.20.3.4:443", nil))
+	assert.ErrorIs(t, webhookDialControl("tcp4", "10.21.3.4:443", nil), errWebhookAddress)
+	assert.NoError(t, checkWebhookHost("HOOKS.internal"), "allowed hosts are not resolved")
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	_, err := dialWebhook(context.Background(), "tcp4", "localhost:"+port)
+	assert.ErrorIs(t, err, errWebhookAddress)
+	t.Setenv("WEBHOOK_ALLOWED_HOSTS", "hooks.internal,localhost")
+	conn, err := dialWebhook(context.Background(), "tcp4", "localhost:"+port)
+	require.NoError(t, err, "allowed hosts are not checked when dialing either")
+	conn.Close()
+
+	allowLoopbackWebhooks(t)
+	for _, body := range []string{
+		`{"url": "ftp://example.com", "events": ["task.created"]}`,
+		fmt.Sprintf(`{"url": %q, "events": ["task.archived"]}`, server.URL),
+		fmt.Sprintf(`{"url": %q}`, server.URL),
+	} {
+		c, w := setupContext(http.MethodPost, "/api/webhooks", body, 1)
+		CreateWebhook(db)(c)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+
+	webhook := createWebhook(t, db, 1, fmt.Sprintf(`{"url": %q, "events": ["task.created", "task.deleted"]}`, server.URL))
+	receiver.secret = webhook["secret"].(string)
+	assert.Regexp(t, "^whsec_[0-9a-f]{48}$", receiver.secret)
+
+	c, w := setupContext(http.MethodGet, "/api/webhooks", "", 1)
+	GetWebhooks(db)(c)
+	assert.NotContains(t, w.Body.String(), receiver.secret, "the secret is only shown once")
+
+	c, w = setupContext(http.MethodPost, "/api/task/new", `{"title":"Write report","description":"quarterly"}`, 1)
+	CreateTask(db)(c)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	c, w = setupContext(http.MethodPut, "/", `{"title":"Write the report"}`, 1)
+	c.Params = taskParam(1)
+	UpdateTask(db)(c)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	c, w = setupContext(http.MethodDelete, "/", "", 1)
+	c.Params = taskParam(1)
+	DeleteTask(db)(c)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// a bulk dry run rolls its outbox rows back with everything else
+	c, w = setupContext(http.MethodPost, "/", `{"ids":[1],"action":"restore","dry_run":true}`, 1)
+	BulkTasks(db)(c)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	now := time.Now()
+	sent, err := DeliverWebhooks(db, fixedClock(now))
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent, "updates are not subscribed")
+
+	require.Len(t, receiver.received, 2)
+	assert.Equal(t, "task.created", receiver.received[0]["type"])
+	assert.Equal(t, "Write report", receiver.received[0]["data"].(map[string]any)["title"])
+	assert.Equal(t, receiver.ids[0], receiver.received[0]["id"])
+	assert.Equal(t, "task.deleted", receiver.received[1]["type"])
+	assert.Equal(t, map[string]any{"id": float64(1)}, receiver.received[1]["data"])
+
+	sent, err = DeliverWebhooks(db, fixedClock(now.Add(time.Hour)))
+	require.NoError(t, err)
+	assert.Zero(t, sent, "each event is delivered once")
+
+	c, w = setupContext(http.MethodGet, "/?status=succeeded", "", 1)
+	c.Params = webhookParams(webhook["id"])
+	GetWebhookDeliveries(db)(c)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var log struct {
+		Deliveries []struct {
+			EventType      string `json:"event_type"`
+			Attempts       int
+			ResponseStatus int    `json:"response_status"`
+			ResponseBody   string `json:"response_body"`
+		}
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &log))
+	require.Len(t, log.Deliveries, 2)
+	assert.Equal(t, "task.deleted", log.Deliveries[0].EventType)
+	assert.Equal(t, 1, log.Deliveries[0].Attempts)
+	assert.Equal(t, http.StatusOK, log.Deliveries[0].ResponseStatus)
+	assert.Equal(t, "received", log.Deliveries[0].ResponseBody)
+}
+
+func TestWebhooks_RetriesDisablesAndRedelivers(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.Create(&model.User{Name: "owner", Email: "owner@example.com"}).Error)
+
+	receiver := &webhookReceiver{status: http.StatusInternalServerError}
+	server := httptest.NewServer(receiver)
+	t.Cleanup(server.Close)
+	allowLoopbackWebhooks(t)
+	webhook := createWebhook(t, db, 1, fmt.Sprintf(`{"url": %q, "events": ["task.created"], "secret": "a-shared-secret-value"}`, server.URL))
+	receiver.secret = "a-shared-secret-value"
+
+	for _, title := range []string{"First", "Second", "Third"} {
+		c, w := setupContext(http.MethodPost, "/api/task/new", fmt.Sprintf(`{"title":%q,"description":"x"}`, title), 1)
+		CreateTask(db)(c)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	now := time.Now()
+	sent, err := DeliverWebhooks(db, fixedClock(now))
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+
+	var first model.WebhookDelivery
+	require.NoError(t, db.First(&first).Error)
+	assert.Equal(t, "pending", first.Status)
+	assert.Equal(t, 1, first.Attempts)
+	assert.Equal(t, http.StatusInternalServerError, *first.ResponseStatus)
+	assert.WithinDuration(t, now.Add(30*time.Second), first.NextAttemptAt, time.Second)
+
+	// not due yet
+	_, err = DeliverWebhooks(db, fixedClock(now.Add(29*time.Second)))
+	require.NoError(t, err)
+	require.NoError(t, db.First(&first).Error)
+	assert.Equal(t, 1, first.Attempts)
+
+	_, err = DeliverWebhooks(db, fixedClock(now.Add(31*time.Second)))
+	require.NoError(t, err)
+	require.NoError(t, db.First(&first).Error)
+	assert.Equal(t, 2, first.Attempts)
+	assert.WithinDuration(t, now.Add(91*time.Second), first.NextAttemptAt, time.Second, "the wait doubles")
+
+	// three deliveries failing up to eight times each trip the breaker
+	for day := 1; day <= 10; day++ {
+		_, err = DeliverWebhooks(db, fixedClock(now.AddDate(0, 0, day)))
+		require.NoError(t, err)
+	}
+	var hook model.Webhook
+	require.NoError(t, db.First(&hook).Error)
+	assert.False(t, hook.Active)
+	assert.Equal(t, webhookDisableAfter, hook.FailureCount)
+	assert.NotNil(t, hook.DisabledAt)
+
+	var attempts int64
+	db.Model(&model.WebhookDelivery{}).Select("SUM(attempts)").Scan(&attempts)
+	assert.EqualValues(t, webhookDisableAfter, attempts, "nothing is sent to a disabled webhook")
+
+	redeliver := func() *httptest.ResponseRecorder {
+		c, w := setupContext(http.MethodPost, "/", "", 1)
+		c.Params = webhookParams(webhook["id"], gin.Param{Key: "deliveryId", Value: fmt.Sprint(first.ID)})
+		RedeliverWebhook(db)(c)
+		return w
+	}
+	assert.Equal(t, http.StatusConflict, redeliver().Code)
+
+	receiver.status = http.StatusNoContent
+	c, w := setupContext(http.MethodPut, "/", `{"active": true}`, 1)
+	c.Params = webhookParams(webhook["id"])
+	UpdateWebhook(db)(c)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"failure_count":0`)
+
+	w = redeliver()
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+
+	// the pending deliveries resume too, the repeat keeps its event id
+	receiver.ids = nil
+	sent, err = DeliverWebhooks(db, fixedClock(now.AddDate(0, 0, 11)))
+	require.NoError(t, err)
+	assert.Equal(t, 4, sent)
+	assert.Equal(t, receiver.ids[0], first.EventID)
+
+	c, w = setupContext(http.MethodGet, "/", "", 2)
+	c.Params = webhookParams(webhook["id"])
+	GetWebhookDeliveries(db)(c)
+	assert.Equal(t, http.StatusNotFound, w.Code, "other users cannot see the log")
+}
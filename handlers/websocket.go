@@ -0,0 +1,538 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Niraj1910/Task-REST-APIs/events"
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/Niraj1910/Task-REST-APIs/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/net/websocket"
+	"gorm.io/gorm"
+)
+
+const (
+	// wsSendBuffer is how many messages a client may fall behind before it
+	// is disconnected; it then resubscribes and reloads.
+	wsSendBuffer       = 64
+	wsWriteTimeout     = 10 * time.Second
+	wsMaxMessageBytes  = 4096
+	wsMaxSubscriptions = 100
+)
+
+// wsMessage is a message from the client. ID is echoed in the reply.
+type wsMessage struct {
+	Type  string `json:"type"`
+	ID    string `json:"id,omitempty"`
+	Topic string `json:"topic,omitempty"`
+}
+
+// wsViewer is a user in a presence list.
+type wsViewer struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+// wsClient is one WebSocket connection.
+type wsClient struct {
+	user wsViewer
+	conn *websocket.Conn
+	send chan []byte
+	done chan struct{}
+	once sync.Once
+
+	mu     sync.Mutex
+	topics map[string]bool
+}
+
+// BoardSocket godoc
+// @Summary      WebSocket for live boards
+// @Description  Upgrades to a WebSocket authenticated like the rest of the API (token cookie or Bearer header). Send
+// @Description  {"type":"subscribe","topic":"task:12"} or the topic "board" for all your tasks, "unsubscribe" to stop
+// @Description  and "ping" to get a "pong". The server sends "subscribed", "event" (task.created, task.updated,
+// @Description  task.deleted with the task as data), "presence" (who is viewing a task, on any instance) and "error"
+// @Description  messages as JSON. Access is checked on every subscription. Clients that fall behind are disconnected.
+// @Tags         Events
+// @Security     BearerAuth
+// @Success      101 "Switching protocols"
+// @Failure      401 {object} map[string]string "Unauthorized"
+// @Failure      403 "Origin not allowed"
+// @Router       /ws [get]
+func BoardSocket(db *gorm.DB, allowedOrigins []string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		userID, ok := utils.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		var user model.User
+		if err := db.Select("id", "name").First(&user, userID).Error; err != nil {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			return
+		}
+
+		server := websocket.Server{
+			Handshake: func(config *websocket.Config, r *http.Request) error {
+				return checkSocketOrigin(r, allowedOrigins)
+			},
+			Handler: func(conn *websocket.Conn) {
+				conn.MaxPayloadBytes = wsMaxMessageBytes
+				client := &wsClient{
+					user:   wsViewer{ID: user.ID, Name: user.Name},
+					conn:   conn,
+					send:   make(chan []byte, wsSendBuffer),
+					done:   make(chan struct{}),
+					topics: map[string]bool{},
+				}
+				client.run(db)
+			},
+		}
+		server.ServeHTTP(ctx.Writer, ctx.Request)
+	}
+}
+
+// checkSocketOrigin refuses browser connections from other sites, which
+// would otherwise ride on the user's cookie. Clients without an Origin
+// header are not browsers and bring their own token.
+func checkSocketOrigin(r *http.Request, allowedOrigins []string) error {
+
+	origin := r.Header.Get("Origin")
+	if origin == "" || slices.Contains(allowedOrigins, origin) {
+		return nil
+	}
+	u, err := url.Parse(origin)
+	if err == nil && u.Host == r.Host {
+		return nil
+	}
+	return fmt.Errorf("origin %q not allowed", origin)
+}
+
+// run serves the connection until either side closes it.
+func (c *wsClient) run(db *gorm.DB) {
+
+	sub := eventBroker().Subscribe(c.user.ID, "")
+	defer sub.Close()
+	defer c.leaveAll()
+	defer c.close()
+
+	go c.writeLoop()
+	go func() {
+		for event := range sub.Events {
+			c.forward(event.ID, event.Type, event.Data)
+		}
+		// the broker dropped us for being slow
+		c.close()
+	}()
+
+	for {
+		var raw []byte
+		if err := websocket.Message.Receive(c.conn, &raw); err != nil {
+			return
+		}
+
+		var msg wsMessage
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			c.reply(gin.H{"type": "error", "error": "messages must be JSON objects"})
+			continue
+		}
+
+		switch msg.Type {
+		case "subscribe":
+			c.subscribe(db, msg)
+		case "unsubscribe":
+			c.unsubscribe(msg.Topic)
+			c.reply(gin.H{"type": "unsubscribed", "id": msg.ID, "topic": msg.Topic})
+		case "ping":
+			c.reply(gin.H{"type": "pong", "id": msg.ID})
+		default:
+			c.reply(gin.H{"type": "error", "id": msg.ID, "error": fmt.Sprintf("unknown message type %q", msg.Type)})
+		}
+	}
+}
+
+// subscribe checks that the user may see the topic before adding it.
+func (c *wsClient) subscribe(db *gorm.DB, msg wsMessage) {
+
+	fail := func(text string) {
+		c.reply(gin.H{"type": "error", "id": msg.ID, "topic": msg.Topic, "error": text})
+	}
+
+	taskID, isTask, err := parseTaskTopic(msg.Topic)
+	var audience []uint
+	switch {
+	case err != nil:
+		fail(err.Error())
+		return
+	case isTask:
+		task, err := findVisibleTask(db, taskID, c.user.ID)
+		if err != nil {
+			fail("Task not found or not visible to you")
+			return
+		}
+		audience = []uint{task.UserID}
+		if task.AssigneeID != nil {
+			audience = append(audience, *task.AssigneeID)
+		}
+	}
+
+	c.mu.Lock()
+	if len(c.topics) >= wsMaxSubscriptions && !c.topics[msg.Topic] {
+		c.mu.Unlock()
+		fail(fmt.Sprintf("at most %d subscriptions per connection", wsMaxSubscriptions))
+		return
+	}
+	c.topics[msg.Topic] = true
+	c.mu.Unlock()
+
+	c.reply(gin.H{"type": "subscribed", "id": msg.ID, "topic": msg.Topic})
+	if isTask {
+		viewers.join(msg.Topic, c, audience)
+	}
+}
+
+func (c *wsClient) unsubscribe(topic string) {
+	c.mu.Lock()
+	delete(c.topics, topic)
+	c.mu.Unlock()
+	viewers.leave(topic, c)
+}
+
+// parseTaskTopic validates a topic: "board" or "task:<id>". Shared projects
+// do not exist yet.
+func parseTaskTopic(topic string) (uint, bool, error) {
+
+	if topic == "board" {
+		return 0, false, nil
+	}
+	if strings.HasPrefix(topic, "project:") {
+		return 0, false, errors.New("projects are not available")
+	}
+	idStr, ok := strings.CutPrefix(topic, "task:")
+	if !ok {
+		return 0, false, errors.New(`topic must be "board" or "task:<id>"`)
+	}
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil || id == 0 {
+		return 0, false, errors.New("invalid task id in topic")
+	}
+	return uint(id), true, nil
+}
+
+// forward passes a task event on if the client subscribed to the task or
+// the board. The broker only hands out events of tasks the user can see.
+func (c *wsClient) forward(eventID, eventType string, data json.RawMessage) {
+
+	if eventType == eventPresence {
+		viewers.receive(data)
+		return
+	}
+
+	var task struct {
+		ID uint `json:"id"`
+	}
+	json.Unmarshal(data, &task)
+	topic := fmt.Sprintf("task:%d", task.ID)
+
+	c.mu.Lock()
+	onTask, onBoard := c.topics[topic], c.topics["board"]
+	if eventType == eventTaskDeleted {
+		delete(c.topics, topic)
+	}
+	c.mu.Unlock()
+
+	if !onTask && !onBoard {
+		return
+	}
+	if !onTask {
+		topic = "board"
+	}
+	c.reply(gin.H{"type": "event", "topic": topic, "event": eventType, "event_id": eventID, "data": data})
+
+	// deleted, or no longer visible to this user
+	if onTask && eventType == eventTaskDeleted {
+		viewers.leave(topic, c)
+	}
+}
+
+// reply queues a message. A client whose queue is full is disconnected
+// rather than slowing everyone else down.
+func (c *wsClient) reply(msg gin.H) {
+
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	select {
+	case <-c.done:
+	case c.send <- payload:
+	default:
+		log.Warn().Uint("user_id", c.user.ID).Msg("Closing slow WebSocket client")
+		c.close()
+	}
+}
+
+func (c *wsClient) writeLoop() {
+	for {
+		select {
+		case <-c.done:
+			return
+		case payload := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := websocket.Message.Send(c.conn, string(payload)); err != nil {
+				c.close()
+				return
+			}
+		}
+	}
+}
+
+func (c *wsClient) close() {
+	c.once.Do(func() {
+		close(c.done)
+		c.conn.Close()
+	})
+}
+
+func (c *wsClient) leaveAll() {
+	c.mu.Lock()
+	topics := make([]string, 0, len(c.topics))
+	for topic := range c.topics {
+		topics = append(topics, topic)
+	}
+	c.mu.Unlock()
+
+	for _, topic := range topics {
+		viewers.leave(topic, c)
+	}
+}
+
+// eventPresence carries one instance's viewers of a task to the others.
+const eventPresence = "presence"
+
+// instanceID identifies this process in presence announcements.
+var instanceID = uuid.NewString()
+
+const (
+	// presenceRefresh is how often an instance announces its viewers again.
+	presenceRefresh = 30 * time.Second
+	// presenceTTL without an announcement, an instance's viewers are dropped,
+	// e.g. after it crashed.
+	presenceTTL = 3 * presenceRefresh
+)
+
+// presenceAnnouncement is the data of an eventPresence event: the full list
+// of an instance's viewers of a topic. Seq orders the announcements of one
+// instance, which every client of the instance receives a copy of.
+type presenceAnnouncement struct {
+	Instance string     `json:"instance"`
+	Seq      uint64     `json:"seq"`
+	Topic    string     `json:"topic"`
+	Viewers  []wsViewer `json:"viewers"`
+	// Hello asks the other instances to announce their viewers of the topic.
+	Hello bool `json:"hello,omitempty"`
+
+	users []uint
+}
+
+// remoteViewers is the latest announcement of another instance for a topic.
+type remoteViewers struct {
+	seq     uint64
+	viewers []wsViewer
+	seen    time.Time
+}
+
+// presence tracks who is viewing each task. Viewers on this instance are its
+// clients; those on other instances are announced through the broker to the
+// users who can see the task.
+type presence struct {
+	mu       sync.Mutex
+	clients  map[string]map[*wsClient]bool
+	audience map[string][]uint
+	remote   map[string]map[string]remoteViewers
+	seq      uint64
+	refresh  sync.Once
+}
+
+var viewers = &presence{
+	clients:  map[string]map[*wsClient]bool{},
+	audience: map[string][]uint{},
+	remote:   map[string]map[string]remoteViewers{},
+}
+
+// join adds a viewer of topic, which the users in audience may see.
+func (p *presence) join(topic string, c *wsClient, audience []uint) {
+	p.refresh.Do(func() { go p.refreshLoop() })
+
+	p.mu.Lock()
+	first := len(p.clients[topic]) == 0
+	if first {
+		p.clients[topic] = map[*wsClient]bool{}
+	}
+	p.clients[topic][c] = true
+	p.audience[topic] = audience
+	p.broadcastLocked(topic)
+	announcement := p.announcementLocked(topic, first)
+	p.mu.Unlock()
+
+	p.announce(announcement)
+}
+
+func (p *presence) leave(topic string, c *wsClient) {
+	p.mu.Lock()
+	if !p.clients[topic][c] {
+		p.mu.Unlock()
+		return
+	}
+	delete(p.clients[topic], c)
+	p.broadcastLocked(topic)
+	announcement := p.announcementLocked(topic, false)
+	if len(p.clients[topic]) == 0 {
+		delete(p.clients, topic)
+		delete(p.audience, topic)
+	}
+	p.mu.Unlock()
+
+	p.announce(announcement)
+}
+
+// receive takes in another instance's announcement.
+func (p *presence) receive(data json.RawMessage) {
+
+	var a presenceAnnouncement
+	if err := json.Unmarshal(data, &a); err != nil || a.Instance == instanceID {
+		return
+	}
+
+	p.mu.Lock()
+	known, ok := p.remote[a.Topic][a.Instance]
+	if ok && known.seq >= a.Seq {
+		// another client's copy, or overtaken by a newer one
+		p.mu.Unlock()
+		return
+	}
+	if p.remote[a.Topic] == nil {
+		p.remote[a.Topic] = map[string]remoteViewers{}
+	}
+	p.remote[a.Topic][a.Instance] = remoteViewers{seq: a.Seq, viewers: a.Viewers, seen: time.Now()}
+	if !slices.Equal(known.viewers, a.Viewers) {
+		p.broadcastLocked(a.Topic)
+	}
+
+	var reply presenceAnnouncement
+	if a.Hello && len(p.clients[a.Topic]) > 0 {
+		reply = p.announcementLocked(a.Topic, false)
+	}
+	p.mu.Unlock()
+
+	p.announce(reply)
+}
+
+// refreshLoop announces this instance's viewers again so that the others
+// keep them, and drops remote viewers that are no longer announced.
+func (p *presence) refreshLoop() {
+	for range time.Tick(presenceRefresh) {
+		p.mu.Lock()
+		var pending []presenceAnnouncement
+		for topic := range p.clients {
+			pending = append(pending, p.announcementLocked(topic, false))
+		}
+		for topic, instances := range p.remote {
+			for instance, known := range instances {
+				if time.Since(known.seen) > presenceTTL {
+					delete(instances, instance)
+					p.broadcastLocked(topic)
+				}
+			}
+			if len(instances) == 0 {
+				delete(p.remote, topic)
+			}
+		}
+		p.mu.Unlock()
+
+		for _, announcement := range pending {
+			p.announce(announcement)
+		}
+	}
+}
+
+// announcementLocked describes this instance's viewers of topic.
+func (p *presence) announcementLocked(topic string, hello bool) presenceAnnouncement {
+	p.seq++
+	return presenceAnnouncement{
+		Instance: instanceID,
+		Seq:      p.seq,
+		Topic:    topic,
+		Viewers:  p.localViewersLocked(topic),
+		Hello:    hello,
+		users:    p.audience[topic],
+	}
+}
+
+// announce publishes an announcement; the zero value is skipped.
+func (p *presence) announce(a presenceAnnouncement) {
+
+	if a.Topic == "" {
+		return
+	}
+	payload, err := json.Marshal(a)
+	if err != nil {
+		return
+	}
+
+	publishCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err = eventBroker().Publish(publishCtx, events.Event{Type: eventPresence, UserIDs: a.users, Data: payload, Volatile: true})
+	if err != nil {
+		log.Error().Err(err).Str("topic", a.Topic).Msg("Failed to announce presence")
+	}
+}
+
+// localViewersLocked lists the users viewing topic on this instance, each
+// once.
+func (p *presence) localViewersLocked(topic string) []wsViewer {
+
+	var list []wsViewer
+	for c := range p.clients[topic] {
+		if !slices.Contains(list, c.user) {
+			list = append(list, c.user)
+		}
+	}
+	slices.SortFunc(list, func(a, b wsViewer) int { return int(a.ID) - int(b.ID) })
+	return list
+}
+
+// broadcastLocked sends the topic's viewers on every instance, each user
+// once, to everyone viewing it here.
+func (p *presence) broadcastLocked(topic string) {
+
+	if len(p.clients[topic]) == 0 {
+		return
+	}
+
+	list := p.localViewersLocked(topic)
+	for _, known := range p.remote[topic] {
+		for _, viewer := range known.viewers {
+			if !slices.Contains(list, viewer) {
+				list = append(list, viewer)
+			}
+		}
+	}
+	slices.SortFunc(list, func(a, b wsViewer) int { return int(a.ID) - int(b.ID) })
+
+	for c := range p.clients[topic] {
+		c.reply(gin.H{"type": "presence", "topic": topic, "viewers": list})
+	}
+}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Niraj1910/Task-REST-APIs/auth"
+	"github.com/Niraj1910/Task-REST-APIs/events"
+	"github.com/Niraj1910/Task-REST-APIs/middlewares"
+	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+	"gorm.io/gorm"
+)
+
+func socketServer(t *testing.T, db *gorm.DB) string {
+	t.Helper()
+	t.Setenv("JWT_SECRET", "test-secret")
+	SetEventBroker(events.NewHub(10))
+	t.Cleanup(func() { SetEventBroker(events.NewHub(events.DefaultReplaySize)) })
+
+	router := gin.New()
+	router.GET("/ws", middlewares.AuthMiddleware, BoardSocket(db, nil))
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func dialSocket(serverURL, origin string, user model.User) (*websocket.Conn, error) {
+	config, err := websocket.NewConfig("ws"+strings.TrimPrefix(serverURL, "http")+"/ws", origin)
+	if err != nil {
+		return nil, err
+	}
+	if user.ID != 0 {
+		token, err := auth.CreateToken(user.Name, user.Email, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		config.Header.Set("Authorization", "Bearer "+token)
+	}
+	return websocket.DialConfig(config)
+}
+
+func connectSocket(t *testing.T, serverURL string, user model.User) *websocket.Conn {
+	t.Helper()
+	conn, err := dialSocket(serverURL, serverURL, user)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func sendSocket(t *testing.T, conn *websocket.Conn, msg string) {
+	t.Helper()
+	require.NoError(t, websocket.Message.Send(conn, msg))
+}
+
+func receiveSocket(t *testing.T, conn *websocket.Conn) map[string]any {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var msg map[string]any
+	require.NoError(t, websocket.JSON.Receive(conn, &msg))
+	return msg
+}
+
+func viewerNames(msg map[string]any) []string {
+	var names []string
+	for _, viewer := range msg["viewers"].([]any) {
+		names = append(names, viewer.(map[string]any)["name"].(string))
+	}
+	return names
+}
+
+func TestBoardSocket_AuthAndOrigin(t *testing.T) {
+	db := setupTestDB(t)
+	user := model.User{Name: "owner", Email: "owner@example.com"}
+	require.NoError(t, db.Create(&user).Error)
+	url := socketServer(t, db)
+
+	_, err := dialSocket(url, url, model.User{})
+	assert.Error(t, err, "no token")
+
+	_, err = dialSocket(url, "https://evil.example", user)
+	assert.Error(t, err, "cross-site browser connection")
+
+	conn := connectSocket(t, url, user)
+	sendSocket(t, conn, `{"type":"ping","id":"p1"}`)
+	assert.Equal(t, map[string]any{"type": "pong", "id": "p1"}, receiveSocket(t, conn))
+}
+
+func TestBoardSocket_SubscriptionsEventsAndPresence(t *testing.T) {
+	db := setupTestDB(t)
+	users := []model.User{
+		{Name: "owner", Email: "owner@example.com"},
+		{Name: "assignee", Email: "assignee@example.com"},
+		{Name: "stranger", Email: "stranger@example.com"},
+	}
+	require.NoError(t, db.Create(&users).Error)
+	task := model.Task{Title: "Plan the sprint", UserID: users[0].ID, AssigneeID: &users[1].ID}
+	require.NoError(t, db.Create(&task).Error)
+	url := socketServer(t, db)
+	topic := fmt.Sprintf("task:%d", task.ID)
+
+	stranger := connectSocket(t, url, users[2])
+	sendSocket(t, stranger, fmt.Sprintf(`{"type":"subscribe","id":"s1","topic":%q}`, topic))
+	msg := receiveSocket(t, stranger)
+	assert.Equal(t, "error", msg["type"])
+	assert.Equal(t, "s1", msg["id"])
+	sendSocket(t, stranger, `{"type":"subscribe","topic":"project:1"}`)
+	assert.Equal(t, "projects are not available", receiveSocket(t, stranger)["error"])
+
+	owner := connectSocket(t, url, users[0])
+	sendSocket(t, owner, fmt.Sprintf(`{"type":"subscribe","topic":%q}`, topic))
+	assert.Equal(t, "subscribed", receiveSocket(t, owner)["type"])
+	assert.Equal(t, []string{"owner"}, viewerNames(receiveSocket(t, owner)))
+
+	assignee := connectSocket(t, url, users[1])
+	sendSocket(t, assignee, fmt.Sprintf(`{"type":"subscribe","topic":%q}`, topic))
+	assert.Equal(t, "subscribed", receiveSocket(t, assignee)["type"])
+	assert.Equal(t, []string{"owner", "assignee"}, viewerNames(receiveSocket(t, assignee)))
+	assert.Equal(t, []string{"owner", "assignee"}, viewerNames(receiveSocket(t, owner)))
+
+	c, w := setupContext(http.MethodPut, "/", `{"status":"in_progress"}`, users[0].ID)
+	c.Params = taskParam(task.ID)
+	UpdateTask(db)(c)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	for _, conn := range []*websocket.Conn{owner, assignee} {
+		msg := receiveSocket(t, conn)
+		assert.Equal(t, "event", msg["type"])
+		assert.Equal(t, "task.updated", msg["event"])
+		assert.Equal(t, topic, msg["topic"])
+		assert.Equal(t, "in_progress", msg["data"].(map[string]any)["status"])
+	}
+
+	assignee.Close()
+	assert.Equal(t, []string{"owner"}, viewerNames(receiveSocket(t, owner)), "the assignee left")
+}
+
+func TestBoardSocket_DisconnectsSlowClients(t *testing.T) {
+	db := setupTestDB(t)
+	user := model.User{Name: "owner", Email: "owner@example.com"}
+	require.NoError(t, db.Create(&user).Error)
+	url := socketServer(t, db)
+
+	conn := connectSocket(t, url, user)
+	sendSocket(t, conn, `{"type":"subscribe","topic":"board"}`)
+	assert.Equal(t, "subscribed", receiveSocket(t, conn)["type"])
+
+	// the client stops reading while large events keep coming
+	data, _ := json.Marshal(gin.H{"id": 1, "description": strings.Repeat("x", 64<<10)})
+	const published = 500
+	for i := 0; i < published; i++ {
+		require.NoError(t, eventBroker().Publish(context.Background(), events.Event{Type: eventTaskUpdated, UserIDs: []uint{user.ID}, Data: data}))
+	}
+
+	received := 0
+	for {
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		var msg map[string]any
+		if err := websocket.JSON.Receive(conn, &msg); err != nil {
+			break
+		}
+		received++
+	}
+	assert.Less(t, received, published, "the server hung up instead of buffering everything")
+}
+
+func TestBoardSocket_PresenceAcrossInstances(t *testing.T) {
+	db := setupTestDB(t)
+	users := []model.User{
+		{Name: "owner", Email: "owner@example.com"},
+		{Name: "assignee", Email: "assignee@example.com"},
+	}
+	require.NoError(t, db.Create(&users).Error)
+	task := model.Task{Title: "Plan the sprint", UserID: users[0].ID, AssigneeID: &users[1].ID}
+	require.NoError(t, db.Create(&task).Error)
+	url := socketServer(t, db)
+	topic := fmt.Sprintf("task:%d", task.ID)
+	t.Cleanup(func() {
+		viewers.mu.Lock()
+		defer viewers.mu.Unlock()
+		viewers.remote = map[string]map[string]remoteViewers{}
+	})
+
+	// what this instance tells the others
+	sub := eventBroker().Subscribe(users[1].ID, "")
+	defer sub.Close()
+
+	owner := connectSocket(t, url, users[0])
+	sendSocket(t, owner, fmt.Sprintf(`{"type":"subscribe","topic":%q}`, topic))
+	assert.Equal(t, "subscribed", receiveSocket(t, owner)["type"])
+	assert.Equal(t, []string{"owner"}, viewerNames(receiveSocket(t, owner)))
+
+	var hello presenceAnnouncement
+	select {
+	case event := <-sub.Events:
+		require.Equal(t, eventPresence, event.Type)
+		require.NoError(t, json.Unmarshal(event.Data, &hello))
+	case <-time.After(2 * time.Second):
+		t.Fatal("joining was not announced")
+	}
+	assert.True(t, hello.Hello)
+	assert.Equal(t, []wsViewer{{ID: users[0].ID, Name: "owner"}}, hello.Viewers)
+
+	// another instance answers with its viewers, then they leave
+	announce := func(seq uint64, viewers ...wsViewer) {
+		data, _ := json.Marshal(presenceAnnouncement{Instance: "other", Seq: seq, Topic: topic, Viewers: viewers})
+		require.NoError(t, eventBroker().Publish(context.Background(), events.Event{Type: eventPresence, UserIDs: []uint{users[0].ID}, Data: data, Volatile: true}))
+	}
+	announce(1, wsViewer{ID: users[1].ID, Name: "assignee"})
+	assert.Equal(t, []string{"owner", "assignee"}, viewerNames(receiveSocket(t, owner)))
+	announce(1)
+	announce(2)
+	assert.Equal(t, []string{"owner"}, viewerNames(receiveSocket(t, owner)), "the stale copy was ignored")
+
+	sendSocket(t, owner, `{"type":"ping","id":"p1"}`)
+	assert.Equal(t, "pong", receiveSocket(t, owner)["type"], "presence is not replayed as a task event")
+}
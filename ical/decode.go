@@ -0,0 +1,237 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	dateTimeUTC   = "20060102T150405Z"
+	dateTimeLocal = "20060102T150405"
+	dateOnly      = "20060102"
+)
+
+// maxComponentDepth bounds the nesting of BEGIN blocks.
+const maxComponentDepth = 10
+
+// Component is a BEGIN/END block with its properties and sub-components.
+type Component struct {
+	Name       string
+	Properties []Property
+	Components []*Component
+}
+
+// Property is one content line. Parameter names are upper case.
+type Property struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// Get returns the first property with the name, if any.
+func (c *Component) Get(name string) (Property, bool) {
+	for _, p := range c.Properties {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Property{}, false
+}
+
+// Find returns all nested components with the name, depth first.
+func (c *Component) Find(name string) []*Component {
+	var found []*Component
+	for _, child := range c.Components {
+		if child.Name == name {
+			found = append(found, child)
+		}
+		found = append(found, child.Find(name)...)
+	}
+	return found
+}
+
+// Text returns the unescaped value of a TEXT property.
+func (p Property) Text() string {
+
+	var b strings.Builder
+	for i := 0; i < len(p.Value); i++ {
+		c := p.Value[i]
+		if c == '\\' && i+1 < len(p.Value) {
+			i++
+			switch p.Value[i] {
+			case 'n', 'N':
+				b.WriteByte('\n')
+			default:
+				b.WriteByte(p.Value[i])
+			}
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// Time parses a DATE or DATE-TIME value. Times with a TZID parameter are
+// read in that zone (UTC if the zone is unknown), floating times in UTC.
+// allDay is true for DATE values.
+func (p Property) Time() (t time.Time, allDay bool, err error) {
+
+	if p.Params["VALUE"] == "DATE" || len(p.Value) == len(dateOnly) {
+		t, err = time.Parse(dateOnly, p.Value)
+		return t, true, err
+	}
+
+	if strings.HasSuffix(p.Value, "Z") {
+		t, err = time.Parse(dateTimeUTC, p.Value)
+		return t, false, err
+	}
+
+	loc := time.UTC
+	if tzid := p.Params["TZID"]; tzid != "" {
+		if zone, zoneErr := time.LoadLocation(tzid); zoneErr == nil {
+			loc = zone
+		}
+	}
+	t, err = time.ParseInLocation(dateTimeLocal, p.Value, loc)
+	return t, false, err
+}
+
+// Parse reads an iCalendar stream and returns its top-level component,
+// normally VCALENDAR.
+func Parse(r io.Reader) (*Component, error) {
+
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var root *Component
+	var stack []*Component
+	for n, line := range lines {
+		if line == "" {
+			continue
+		}
+		prop, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
+
+		switch prop.Name {
+		case "BEGIN":
+			if len(stack) == maxComponentDepth {
+				return nil, fmt.Errorf("line %d: components nested too deeply", n+1)
+			}
+			component := &Component{Name: strings.ToUpper(prop.Value)}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Components = append(parent.Components, component)
+			} else if root == nil {
+				root = component
+			} else {
+				return nil, fmt.Errorf("line %d: more than one top-level component", n+1)
+			}
+			stack = append(stack, component)
+
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].Name != strings.ToUpper(prop.Value) {
+				return nil, fmt.Errorf("line %d: unexpected END:%s", n+1, prop.Value)
+			}
+			stack = stack[:len(stack)-1]
+
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("line %d: property outside of a component", n+1)
+			}
+			current := stack[len(stack)-1]
+			current.Properties = append(current.Properties, prop)
+		}
+	}
+
+	if root == nil {
+		return nil, errors.New("no calendar found")
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("missing END:%s", stack[len(stack)-1].Name)
+	}
+	return root, nil
+}
+
+// unfold joins continuation lines (starting with a space or tab) to the
+// line before them.
+func unfold(r io.Reader) ([]string, error) {
+
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// parseLine splits "NAME;PARAM=value;PARAM=\"quoted\":value".
+func parseLine(line string) (Property, error) {
+
+	prop := Property{Params: map[string]string{}}
+
+	i := strings.IndexAny(line, ";:")
+	if i <= 0 {
+		return prop, fmt.Errorf("malformed content line %q", truncate(line))
+	}
+	prop.Name = strings.ToUpper(line[:i])
+
+	for line[i] == ';' {
+		rest := line[i+1:]
+		eq := strings.IndexByte(rest, '=')
+		if eq <= 0 {
+			return prop, fmt.Errorf("malformed parameter in %q", truncate(line))
+		}
+		name := strings.ToUpper(rest[:eq])
+		rest = rest[eq+1:]
+
+		var value string
+		var consumed int
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				return prop, fmt.Errorf("unterminated quoted parameter in %q", truncate(line))
+			}
+			value = rest[1 : end+1]
+			consumed = end + 2
+		} else {
+			consumed = strings.IndexAny(rest, ";:")
+			if consumed < 0 {
+				return prop, fmt.Errorf("missing value in %q", truncate(line))
+			}
+			value = rest[:consumed]
+		}
+		prop.Params[name] = value
+
+		i += 1 + eq + 1 + consumed
+		if i >= len(line) {
+			return prop, fmt.Errorf("missing value in %q", truncate(line))
+		}
+	}
+
+	if line[i] != ':' {
+		return prop, fmt.Errorf("malformed content line %q", truncate(line))
+	}
+	prop.Value = line[i+1:]
+	return prop, nil
+}
+
+func truncate(s string) string {
+	if len(s) > 40 {
+		return s[:40] + "..."
+	}
+	return s
+}
@@ -0,0 +1,102 @@
+// Package ical reads and writes the parts of iCalendar (RFC 5545) needed to
+// exchange tasks: components, properties with parameters, text escaping,
+// line folding and DATE / DATE-TIME values.
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxLineOctets is the longest content line allowed before folding.
+const maxLineOctets = 75
+
+// Encoder writes content lines, folding and escaping as needed. Errors are
+// sticky: once a write fails, Close reports it.
+type Encoder struct {
+	w   *bufio.Writer
+	err error
+}
+
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: bufio.NewWriter(w)}
+}
+
+// Begin opens a component, e.g. VCALENDAR or VTODO.
+func (e *Encoder) Begin(component string) {
+	e.line("BEGIN:" + component)
+}
+
+// End closes a component.
+func (e *Encoder) End(component string) {
+	e.line("END:" + component)
+}
+
+// Raw writes a property whose value needs no escaping, such as an integer
+// or an enumerated value. params are "NAME=value" pairs.
+func (e *Encoder) Raw(name, value string, params ...string) {
+	e.line(contentLine(name, params) + value)
+}
+
+// Text writes a TEXT property, escaping backslashes, semicolons, commas
+// and newlines.
+func (e *Encoder) Text(name, value string, params ...string) {
+	e.Raw(name, EscapeText(value), params...)
+}
+
+// Time writes a DATE-TIME property in UTC.
+func (e *Encoder) Time(name string, t time.Time) {
+	e.Raw(name, t.UTC().Format(dateTimeUTC))
+}
+
+// Date writes a DATE property.
+func (e *Encoder) Date(name string, t time.Time) {
+	e.Raw(name, t.Format(dateOnly), "VALUE=DATE")
+}
+
+// Close flushes the output and returns the first error.
+func (e *Encoder) Close() error {
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+// line writes a content line folded at 75 octets without splitting a UTF-8
+// sequence; continuation lines start with a space.
+func (e *Encoder) line(s string) {
+
+	if e.err != nil {
+		return
+	}
+
+	limit := maxLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		_, e.err = e.w.WriteString(s[:cut] + "\r\n ")
+		if e.err != nil {
+			return
+		}
+		s = s[cut:]
+		limit = maxLineOctets - 1 // the leading space counts
+	}
+	_, e.err = e.w.WriteString(s + "\r\n")
+}
+
+func contentLine(name string, params []string) string {
+	if len(params) == 0 {
+		return name + ":"
+	}
+	return name + ";" + strings.Join(params, ";") + ":"
+}
+
+// EscapeText escapes a TEXT value.
+func EscapeText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`).Replace(s)
+}
@@ -0,0 +1,89 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncoder_FoldsAndEscapes(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.Begin("VTODO")
+	enc.Text("SUMMARY", "Buy milk, eggs; and ünïcödé\n"+strings.Repeat("ä", 60))
+	enc.Time("DUE", time.Date(2024, 5, 1, 12, 30, 0, 0, time.FixedZone("CEST", 2*3600)))
+	enc.Date("DTSTART", time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC))
+	enc.End("VTODO")
+	require.NoError(t, enc.Close())
+
+	out := buf.String()
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), 75, line)
+	}
+	assert.Contains(t, out, `SUMMARY:Buy milk\, eggs\; and ünïcödé\n`)
+	assert.Contains(t, out, "DUE:20240501T103000Z\r\n")
+	assert.Contains(t, out, "DTSTART;VALUE=DATE:20240502\r\n")
+
+	cal, err := Parse(strings.NewReader(out))
+	require.NoError(t, err)
+	summary, ok := cal.Get("SUMMARY")
+	require.True(t, ok)
+	assert.Equal(t, "Buy milk, eggs; and ünïcödé\n"+strings.Repeat("ä", 60), summary.Text(), "folding round-trips")
+}
+
+func TestParse(t *testing.T) {
+	input := "BEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"BEGIN:VTODO\r\n" +
+		"SUMMARY:Write\r\n  report\r\n" +
+		"DUE;TZID=\"Europe/Berlin\":20240501T100000\r\n" +
+		"X-NOTE;ALTREP=\"cid:a;b:c\":value: with colon\r\n" +
+		"BEGIN:VALARM\r\nTRIGGER:-PT15M\r\nEND:VALARM\r\n" +
+		"END:VTODO\r\n" +
+		"BEGIN:VTODO\r\nSUMMARY:Second\r\nDUE;VALUE=DATE:20240502\r\nEND:VTODO\r\n" +
+		"END:VCALENDAR\r\n"
+
+	cal, err := Parse(strings.NewReader(input))
+	require.NoError(t, err)
+	assert.Equal(t, "VCALENDAR", cal.Name)
+
+	todos := cal.Find("VTODO")
+	require.Len(t, todos, 2)
+
+	summary, _ := todos[0].Get("SUMMARY")
+	assert.Equal(t, "Write report", summary.Text())
+
+	note, _ := todos[0].Get("X-NOTE")
+	assert.Equal(t, "cid:a;b:c", note.Params["ALTREP"])
+	assert.Equal(t, "value: with colon", note.Value)
+
+	due, _ := todos[0].Get("DUE")
+	at, allDay, err := due.Time()
+	require.NoError(t, err)
+	assert.False(t, allDay)
+	assert.Equal(t, time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC), at.UTC())
+
+	due, _ = todos[1].Get("DUE")
+	at, allDay, err = due.Time()
+	require.NoError(t, err)
+	assert.True(t, allDay)
+	assert.Equal(t, time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC), at)
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nEND:VCALENDAR\r\n",
+		"BEGIN:VCALENDAR\r\n",
+		"SUMMARY:outside\r\n",
+		"BEGIN:VCALENDAR\r\nno colon here\r\nEND:VCALENDAR\r\n",
+		"BEGIN:VCALENDAR\r\nX;P=\"open:v\r\nEND:VCALENDAR\r\n",
+	} {
+		_, err := Parse(strings.NewReader(input))
+		assert.Error(t, err, input)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"net/http"
 	"os"
 	"time"
@@ -10,9 +11,11 @@ import (
 	"github.com/robfig/cron/v3"
 
 	"github.com/Niraj1910/Task-REST-APIs/config"
+	"github.com/Niraj1910/Task-REST-APIs/events"
 	"github.com/Niraj1910/Task-REST-APIs/handlers"
 	"github.com/Niraj1910/Task-REST-APIs/middlewares"
 	"github.com/Niraj1910/Task-REST-APIs/model"
+	"github.com/Niraj1910/Task-REST-APIs/storage"
 	"github.com/Niraj1910/Task-REST-APIs/utils"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -53,14 +56,88 @@ func main() {
 	// connect to DB
 	db := config.ConnectDB()
 
+	// attachment files
+	storageDir := os.Getenv("STORAGE_DIR")
+	if storageDir == "" {
+		storageDir = "uploads"
+	}
+	store, err := storage.NewLocal(storageDir)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to set up attachment storage")
+	}
+
+	// task events reach the clients of every instance through LISTEN/NOTIFY;
+	// without it each instance only streams its own changes
+	sqlDB, err := db.DB()
+	if err == nil {
+		var broker *events.Postgres
+		broker, err = events.NewPostgres(context.Background(), sqlDB, config.DSN(), events.DefaultReplaySize)
+		if err == nil {
+			handlers.SetEventBroker(broker)
+		}
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to set up the Postgres event broker, streaming local events only")
+	}
+
+	// imports run in goroutines, so jobs whose instance stopped are dead
+	failInterruptedImports := func() {
+		if failed, err := handlers.FailInterruptedImports(db, time.Now()); err != nil {
+			log.Error().Err(err).Msg("Failed to mark interrupted import jobs")
+		} else if failed > 0 {
+			log.Warn().Int64("jobs", failed).Msg("Marked interrupted import jobs as failed")
+		}
+	}
+	failInterruptedImports()
+
+	// a run still going when its next turn comes is skipped, so slow
+	// webhook batches or digests never overlap with themselves
+	c := cron.New(cron.WithChain(cron.SkipIfStillRunning(cron.DefaultLogger)))
 	// clean up the registered user's email temp data
-	c := cron.New()
 	c.AddFunc("@hourly", func() {
 		err = db.Where("expires_at < ?", time.Now()).Delete(&model.EmailVerification{}).Error
 		if err != nil {
 			log.Error().Err(err).Msg("Failed to delete tempData @hourly from verification table ")
 		}
 	})
+	// permanently remove tasks that have been in the trash past the retention period
+	c.AddFunc("@daily", func() {
+		purged, err := handlers.PurgeExpiredTasks(db, store, handlers.TrashRetention())
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to purge expired trash @daily")
+			return
+		}
+		log.Info().Int("tasks", purged).Msg("Purged expired tasks from trash")
+	})
+	// due-date reminders and digests are claimed per row, so every instance may run this
+	c.AddFunc("@every 1m", func() {
+		failInterruptedImports()
+		now := time.Now()
+		if sent, err := handlers.SendDueReminders(db, now); err != nil {
+			log.Error().Err(err).Msg("Failed to send due reminders")
+		} else if sent > 0 {
+			log.Info().Int("reminders", sent).Msg("Sent due reminders")
+		}
+		if sent, err := handlers.SendDigests(db, now); err != nil {
+			log.Error().Err(err).Msg("Failed to send digests")
+		} else if sent > 0 {
+			log.Info().Int("digests", sent).Msg("Sent task digests")
+		}
+	})
+	// webhook deliveries are leased per row as well
+	c.AddFunc("@every 10s", func() {
+		if sent, err := handlers.DeliverWebhooks(db, time.Now); err != nil {
+			log.Error().Err(err).Msg("Failed to deliver webhooks")
+		} else if sent > 0 {
+			log.Info().Int("deliveries", sent).Msg("Delivered webhooks")
+		}
+	})
+	// keep the webhook delivery log for 30 days
+	c.AddFunc("@daily", func() {
+		if _, err := handlers.PruneWebhookDeliveries(db, time.Now().AddDate(0, 0, -30)); err != nil {
+			log.Error().Err(err).Msg("Failed to prune webhook deliveries @daily")
+		}
+	})
 	c.Start()
 
 	router := gin.Default()
@@ -78,8 +155,8 @@ func main() {
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     orgins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "If-Match", "If-None-Match"},
+		ExposeHeaders:    []string{"Content-Length", "ETag", "Link"},
 		AllowCredentials: true,
 	}))
 
@@ -104,22 +181,119 @@ func main() {
 	router.GET("/verify", handlers.VerifyEmailAndRegisterUser(db))
 	router.POST("/login", handlers.LoginUser(db))
 	router.POST("/logout", handlers.LogoutUser)
+	router.GET("/files/attachments/:attachmentId", handlers.DownloadAttachment(db, store))
+	router.GET("/cal/:token", handlers.CalendarFeed(db))
 
 	protectedTaskRoute := router.Group("/api/task", middlewares.AuthMiddleware)
 	{
 		protectedTaskRoute.GET("/", handlers.GetTasks(db))
+		protectedTaskRoute.GET("/trash", handlers.GetTrash(db))
+		protectedTaskRoute.POST("/bulk", handlers.BulkTasks(db))
+		protectedTaskRoute.GET("/search", handlers.SearchTasks(db))
+		protectedTaskRoute.GET("/export", handlers.ExportTasks(db))
+		protectedTaskRoute.POST("/import", handlers.ImportTasks(db))
+		protectedTaskRoute.GET("/import/:jobId", handlers.GetImportJob(db))
 		protectedTaskRoute.POST("/new", handlers.CreateTask(db))
 		protectedTaskRoute.PUT("/:id", handlers.UpdateTask(db))
+		protectedTaskRoute.PATCH("/:id", handlers.PatchTask(db))
 		protectedTaskRoute.GET("/:id", handlers.GetTaskByID(db))
 		protectedTaskRoute.DELETE("/:id", handlers.DeleteTask(db))
+		protectedTaskRoute.POST("/:id/move", handlers.MoveTask(db))
+		protectedTaskRoute.PUT("/:id/assignee", handlers.AssignTask(db))
+		protectedTaskRoute.DELETE("/:id/assignee", handlers.UnassignTask(db))
+		protectedTaskRoute.GET("/:id/history", handlers.GetTaskHistory(db))
+		protectedTaskRoute.POST("/:id/restore", handlers.RestoreTask(db))
+		protectedTaskRoute.DELETE("/:id/purge", handlers.PurgeTask(db, store))
+		protectedTaskRoute.GET("/:id/comments", handlers.GetComments(db))
+		protectedTaskRoute.POST("/:id/comments", handlers.CreateComment(db))
+		protectedTaskRoute.PUT("/:id/comments/:commentId", handlers.UpdateComment(db))
+		protectedTaskRoute.DELETE("/:id/comments/:commentId", handlers.DeleteComment(db))
+		protectedTaskRoute.GET("/:id/attachments", handlers.GetAttachments(db))
+		protectedTaskRoute.POST("/:id/attachments", handlers.UploadAttachment(db, store))
+		protectedTaskRoute.DELETE("/:id/attachments/:attachmentId", handlers.DeleteAttachment(db, store))
+		protectedTaskRoute.GET("/:id/dependencies", handlers.GetDependencies(db))
+		protectedTaskRoute.POST("/:id/dependencies", handlers.AddDependency(db))
+		protectedTaskRoute.DELETE("/:id/dependencies/:blockerId", handlers.RemoveDependency(db))
+		protectedTaskRoute.GET("/:id/time", handlers.GetTimeEntries(db))
+		protectedTaskRoute.POST("/:id/time", handlers.CreateTimeEntry(db))
+		protectedTaskRoute.POST("/:id/time/start", handlers.StartTimer(db))
+		protectedTaskRoute.POST("/:id/time/stop", handlers.StopTimer(db))
+		protectedTaskRoute.PUT("/:id/time/:entryId", handlers.UpdateTimeEntry(db))
+		protectedTaskRoute.DELETE("/:id/time/:entryId", handlers.DeleteTimeEntry(db))
+		protectedTaskRoute.GET("/:id/reminders", handlers.GetReminders(db))
+		protectedTaskRoute.POST("/:id/reminders", handlers.CreateReminder(db))
+		protectedTaskRoute.DELETE("/:id/reminders/:reminderId", handlers.DeleteReminder(db))
+
+	}
+
+	router.GET("/api/board", middlewares.AuthMiddleware, handlers.GetBoard(db))
+	router.GET("/api/events", middlewares.AuthMiddleware, handlers.StreamEvents())
+	router.GET("/ws", middlewares.AuthMiddleware, handlers.BoardSocket(db, orgins))
+
+	protectedTimeRoute := router.Group("/api/time", middlewares.AuthMiddleware)
+	{
+		protectedTimeRoute.GET("/running", handlers.GetRunningTimer(db))
+		protectedTimeRoute.GET("/report", handlers.GetTimesheet(db))
+	}
+
+	protectedNotificationRoute := router.Group("/api/notifications", middlewares.AuthMiddleware)
+	{
+		protectedNotificationRoute.GET("/", handlers.GetNotifications(db))
+		protectedNotificationRoute.POST("/read-all", handlers.MarkAllNotificationsRead(db))
+		protectedNotificationRoute.POST("/:id/read", handlers.MarkNotificationRead(db))
+		protectedNotificationRoute.DELETE("/:id", handlers.DeleteNotification(db))
+		protectedNotificationRoute.GET("/preferences", handlers.GetNotificationPreferences(db))
+		protectedNotificationRoute.PUT("/preferences", handlers.UpdateNotificationPreferences(db))
+	}
 
+	protectedViewRoute := router.Group("/api/views", middlewares.AuthMiddleware)
+	{
+		protectedViewRoute.GET("/", handlers.GetViews(db))
+		protectedViewRoute.POST("/", handlers.CreateView(db))
+		protectedViewRoute.GET("/:id", handlers.GetView(db))
+		protectedViewRoute.PUT("/:id", handlers.UpdateView(db))
+		protectedViewRoute.DELETE("/:id", handlers.DeleteView(db))
+		protectedViewRoute.GET("/:id/tasks", handlers.GetViewTasks(db))
+	}
+
+	protectedTemplateRoute := router.Group("/api/templates", middlewares.AuthMiddleware)
+	{
+		protectedTemplateRoute.GET("/", handlers.GetTemplates(db))
+		protectedTemplateRoute.POST("/", handlers.CreateTemplate(db))
+		protectedTemplateRoute.GET("/:id", handlers.GetTemplate(db))
+		protectedTemplateRoute.PUT("/:id", handlers.UpdateTemplate(db))
+		protectedTemplateRoute.DELETE("/:id", handlers.DeleteTemplate(db))
+		protectedTemplateRoute.POST("/:id/instantiate", handlers.InstantiateTemplate(db))
+	}
+
+	protectedCustomFieldRoute := router.Group("/api/custom-fields", middlewares.AuthMiddleware)
+	{
+		protectedCustomFieldRoute.GET("/", handlers.GetCustomFields(db))
+		protectedCustomFieldRoute.POST("/", handlers.CreateCustomField(db))
+		protectedCustomFieldRoute.GET("/:id", handlers.GetCustomField(db))
+		protectedCustomFieldRoute.PUT("/:id", handlers.UpdateCustomField(db))
+		protectedCustomFieldRoute.DELETE("/:id", handlers.DeleteCustomField(db))
+	}
+
+	protectedWebhookRoute := router.Group("/api/webhooks", middlewares.AuthMiddleware)
+	{
+		protectedWebhookRoute.GET("/", handlers.GetWebhooks(db))
+		protectedWebhookRoute.POST("/", handlers.CreateWebhook(db))
+		protectedWebhookRoute.GET("/:id", handlers.GetWebhook(db))
+		protectedWebhookRoute.PUT("/:id", handlers.UpdateWebhook(db))
+		protectedWebhookRoute.DELETE("/:id", handlers.DeleteWebhook(db))
+		protectedWebhookRoute.GET("/:id/deliveries", handlers.GetWebhookDeliveries(db))
+		protectedWebhookRoute.POST("/:id/deliveries/:deliveryId/redeliver", handlers.RedeliverWebhook(db))
 	}
 
 	protectedUserRoute := router.Group("/api/user", middlewares.AuthMiddleware)
 	{
 		protectedUserRoute.GET("/profile", handlers.GetUserProfile(db))
 		protectedUserRoute.GET("/task", handlers.GetUserTasks(db))
+		protectedUserRoute.GET("/stats", handlers.GetTaskStats(db))
 		protectedUserRoute.PATCH("/update", handlers.UpdateUser(db))
+		protectedUserRoute.POST("/calendar-token", handlers.RotateCalendarToken(db))
+		protectedUserRoute.DELETE("/calendar-token", handlers.DisableCalendarToken(db))
 		// }
 
 		router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
@@ -0,0 +1,17 @@
+// swagger:model
+// @ignoreEmbedded
+package model
+
+import "gorm.io/gorm"
+
+// Attachment is the metadata of a file uploaded to a task. The bytes live in
+// storage under StorageKey.
+type Attachment struct {
+	gorm.Model
+	TaskID      uint   `gorm:"index;not null"`
+	UserID      uint   `gorm:"index;not null"`
+	FileName    string `gorm:"size:255;not null"`
+	ContentType string `gorm:"size:100;not null"`
+	Size        int64  `gorm:"not null"`
+	StorageKey  string `gorm:"size:255;uniqueIndex;not null"`
+}
@@ -0,0 +1,18 @@
+// swagger:model
+// @ignoreEmbedded
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Comment is a Markdown note left on a task. Deleting it only sets DeletedAt.
+type Comment struct {
+	gorm.Model
+	TaskID   uint   `gorm:"index;not null"`
+	UserID   uint   `gorm:"index;not null"`
+	Body     string `gorm:"type:text;not null"`
+	EditedAt *time.Time
+}
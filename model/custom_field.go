@@ -0,0 +1,64 @@
+// swagger:model
+// @ignoreEmbedded
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// CustomField defines an extra task field of a user, such as "customer" or
+// "story points". Tasks keep their values in Task.CustomFields under Key.
+// Options holds the choices of select and multi-select fields as JSON.
+type CustomField struct {
+	gorm.Model
+	UserID  uint   `gorm:"uniqueIndex:idx_custom_fields_user_key;not null"`
+	Key     string `gorm:"uniqueIndex:idx_custom_fields_user_key;size:50;not null"`
+	Name    string `gorm:"size:100;not null"`
+	Type    string `gorm:"size:20;not null"`
+	Options string `gorm:"type:text"`
+}
+
+// CustomFieldValues maps custom field keys to task values. It is stored as
+// JSONB on Postgres and JSON on SQLite, and as NULL when empty.
+type CustomFieldValues map[string]any
+
+func (v CustomFieldValues) Value() (driver.Value, error) {
+	if len(v) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	return string(data), err
+}
+
+func (v *CustomFieldValues) Scan(src any) error {
+
+	var data []byte
+	switch src := src.(type) {
+	case nil:
+		*v = nil
+		return nil
+	case []byte:
+		data = src
+	case string:
+		data = []byte(src)
+	default:
+		return fmt.Errorf("cannot scan %T into CustomFieldValues", src)
+	}
+	return json.Unmarshal(data, v)
+}
+
+func (CustomFieldValues) GormDataType() string {
+	return "json"
+}
+
+func (CustomFieldValues) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	if db.Dialector.Name() == "postgres" {
+		return "jsonb"
+	}
+	return "json"
+}
@@ -0,0 +1,14 @@
+// swagger:model
+// @ignoreEmbedded
+package model
+
+import "time"
+
+// TaskDependency says that TaskID is blocked by BlockedByID until the
+// blocking task is completed.
+type TaskDependency struct {
+	ID          uint `gorm:"primaryKey"`
+	CreatedAt   time.Time
+	TaskID      uint `gorm:"not null;uniqueIndex:idx_task_dependency"`
+	BlockedByID uint `gorm:"not null;uniqueIndex:idx_task_dependency;index"`
+}
@@ -0,0 +1,28 @@
+// swagger:model
+// @ignoreEmbedded
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ImportJob tracks a task import that runs in the background. Status is
+// one of "queued", "running", "completed" or "failed". Results holds the
+// per-row outcome as JSON once the job has finished. The server running a
+// job keeps UpdatedAt fresh as a heartbeat.
+type ImportJob struct {
+	gorm.Model
+	UserID     uint   `gorm:"index;not null"`
+	Status     string `gorm:"type:varchar(20);not null;default:'queued'"`
+	DryRun     bool
+	Atomic     bool
+	Total      int
+	Processed  int
+	Created    int
+	Failed     int
+	Results    string `gorm:"type:text"`
+	Error      string `gorm:"type:text"`
+	FinishedAt *time.Time
+}
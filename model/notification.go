@@ -0,0 +1,23 @@
+// swagger:model
+// @ignoreEmbedded
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Notification is an entry in a user's in-app inbox. ReadAt is nil while
+// it is unread.
+type Notification struct {
+	gorm.Model
+	UserID  uint   `gorm:"not null;index:idx_notifications_inbox,priority:1"`
+	Type    string `gorm:"size:30;not null"`
+	TaskID  *uint  `gorm:"index"`
+	ActorID *uint
+	Title   string     `gorm:"size:255;not null"`
+	Body    string     `gorm:"type:text"`
+	Link    string     `gorm:"size:500"`
+	ReadAt  *time.Time `gorm:"index:idx_notifications_inbox,priority:2"`
+}
@@ -0,0 +1,15 @@
+// swagger:model
+// @ignoreEmbedded
+package model
+
+import "gorm.io/gorm"
+
+// NotificationPreference decides through which channels a user receives
+// one type of event. Without a row the defaults of the event type apply.
+type NotificationPreference struct {
+	gorm.Model
+	UserID    uint   `gorm:"not null;uniqueIndex:idx_notification_preferences_user_type"`
+	EventType string `gorm:"size:30;not null;uniqueIndex:idx_notification_preferences_user_type"`
+	InApp     bool   `gorm:"not null"`
+	Email     bool   `gorm:"not null"`
+}
@@ -0,0 +1,20 @@
+// swagger:model
+// @ignoreEmbedded
+package model
+
+import "gorm.io/gorm"
+
+// SavedView is a named task query of a user: the GetTasks filters, a sort
+// and a page size, plus how a client should display the result. Display
+// holds the display options as JSON.
+type SavedView struct {
+	gorm.Model
+	UserID     uint   `gorm:"index;not null"`
+	Name       string `gorm:"size:100;not null"`
+	Status     string `gorm:"size:20"`
+	Blocked    *bool
+	Expression string `gorm:"type:text"`
+	Sort       string `gorm:"size:200"`
+	PageSize   int    `gorm:"not null;default:10"`
+	Display    string `gorm:"type:text"`
+}
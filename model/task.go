@@ -15,5 +15,16 @@ type Task struct {
 	Priority    int        `gorm:"default:0;check:priority >= 0;gte=0;lte=10"`
 	Status      string     `gorm:"type:varchar(20);default:'pending'"`
 	UserID      uint       `gorm:"index"`
+	AssigneeID  *uint      `gorm:"index"`
 	CompletedAt *time.Time `gorm:"index"`
+	DueAt       *time.Time `gorm:"index"`
+	Version     uint       `gorm:"not null;default:1"`
+	// BoardRank orders the task within its status column on the board.
+	BoardRank string `gorm:"size:32;not null;default:'';index"`
+	// EstimatedMinutes is the planned effort, compared against logged time.
+	EstimatedMinutes *int
+	// ParentID makes the task a subtask of another task.
+	ParentID *uint `gorm:"index"`
+	// CustomFields holds the values of the owner's custom fields by key.
+	CustomFields CustomFieldValues
 }
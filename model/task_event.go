@@ -0,0 +1,18 @@
+// swagger:model
+// @ignoreEmbedded
+package model
+
+import "time"
+
+// TaskEvent is one entry of a task's history. Field-level changes carry the
+// previous and new value as text so every kind of field fits the same column.
+type TaskEvent struct {
+	ID        uint      `gorm:"primaryKey"`
+	CreatedAt time.Time `gorm:"index"`
+	TaskID    uint      `gorm:"index;not null"`
+	ActorID   uint      `gorm:"index"`
+	Action    string    `gorm:"type:varchar(30);not null"`
+	Field     string    `gorm:"type:varchar(50)"`
+	OldValue  *string   `gorm:"type:text"`
+	NewValue  *string   `gorm:"type:text"`
+}
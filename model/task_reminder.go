@@ -0,0 +1,20 @@
+// swagger:model
+// @ignoreEmbedded
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TaskReminder emails a user MinutesBefore the task's due time. RemindAt
+// follows the due date; SentAt is set by whichever instance claims it.
+type TaskReminder struct {
+	gorm.Model
+	TaskID        uint      `gorm:"index;not null"`
+	UserID        uint      `gorm:"index;not null"`
+	MinutesBefore int       `gorm:"not null"`
+	RemindAt      time.Time `gorm:"index;not null"`
+	SentAt        *time.Time
+}
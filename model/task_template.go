@@ -0,0 +1,14 @@
+// swagger:model
+// @ignoreEmbedded
+package model
+
+import "gorm.io/gorm"
+
+// TaskTemplate is a reusable task tree, such as a checklist for onboarding
+// a new hire. Task holds the root task with its subtasks as JSON.
+type TaskTemplate struct {
+	gorm.Model
+	UserID uint   `gorm:"index;not null"`
+	Name   string `gorm:"size:100;not null"`
+	Task   string `gorm:"type:text;not null"`
+}
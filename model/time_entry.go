@@ -0,0 +1,20 @@
+// swagger:model
+// @ignoreEmbedded
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TimeEntry is time a user spent on a task. A running timer has no EndedAt;
+// the partial unique index allows one per user.
+type TimeEntry struct {
+	gorm.Model
+	TaskID    uint      `gorm:"index;not null"`
+	UserID    uint      `gorm:"not null;index;uniqueIndex:idx_time_entries_running,where:ended_at IS NULL AND deleted_at IS NULL"`
+	StartedAt time.Time `gorm:"not null;index"`
+	EndedAt   *time.Time
+	Note      string `gorm:"size:500"`
+}
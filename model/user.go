@@ -2,7 +2,11 @@
 // @ignoreEmbedded
 package model
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type User struct {
 	gorm.Model
@@ -12,4 +16,12 @@ type User struct {
 	Age      uint8
 	IsActive bool   `gorm:"default:true"`
 	Role     string `gorm:"varchar(20);default:'user'"`
+	// CalendarToken is the secret in the user's calendar feed URL; nil
+	// while the feed is off.
+	CalendarToken *string `gorm:"size:64;uniqueIndex"`
+	// TimeZone is an IANA zone name; digests go out at DigestHour there.
+	TimeZone        string `gorm:"size:64;not null;default:'UTC'"`
+	DigestFrequency string `gorm:"size:10;not null;default:'off'"`
+	DigestHour      int    `gorm:"not null;default:8"`
+	DigestSentAt    *time.Time
 }
@@ -0,0 +1,24 @@
+// swagger:model
+// @ignoreEmbedded
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Webhook posts the events listed in Events (comma separated) on the
+// user's tasks to URL, signed with Secret. It is switched off after too
+// many failed deliveries in a row.
+type Webhook struct {
+	gorm.Model
+	UserID         uint   `gorm:"index;not null"`
+	URL            string `gorm:"size:2000;not null"`
+	Events         string `gorm:"size:200;not null"`
+	Secret         string `gorm:"size:100;not null"`
+	Active         bool   `gorm:"not null;default:true"`
+	FailureCount   int    `gorm:"not null;default:0"`
+	DisabledAt     *time.Time
+	DisabledReason string `gorm:"size:200"`
+}
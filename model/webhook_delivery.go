@@ -0,0 +1,28 @@
+// swagger:model
+// @ignoreEmbedded
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WebhookDelivery is one event for one webhook. Rows are written in the
+// same transaction as the task change, so they double as the outbox the
+// delivery worker sends from, and are kept afterwards as the delivery log.
+type WebhookDelivery struct {
+	gorm.Model
+	WebhookID      uint      `gorm:"index;not null"`
+	EventID        string    `gorm:"size:36;not null;index"`
+	EventType      string    `gorm:"size:30;not null"`
+	Payload        string    `gorm:"type:text;not null"`
+	Status         string    `gorm:"size:20;not null;default:'pending';index:idx_webhook_deliveries_due,priority:1"`
+	Attempts       int       `gorm:"not null;default:0"`
+	NextAttemptAt  time.Time `gorm:"not null;index:idx_webhook_deliveries_due,priority:2"`
+	LastAttemptAt  *time.Time
+	ResponseStatus *int
+	ResponseBody   string `gorm:"size:1000"`
+	Error          string `gorm:"size:1000"`
+	DeliveredAt    *time.Time
+}
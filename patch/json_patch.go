@@ -0,0 +1,199 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// Operation is one step of a JSON Patch document.
+type Operation struct {
+	Op    string           `json:"op"`
+	Path  string           `json:"path"`
+	From  string           `json:"from"`
+	Value *json.RawMessage `json:"value"`
+}
+
+// ErrTestFailed is wrapped by the Error of a "test" operation whose value
+// did not match. Other errors mean the patch does not fit the document.
+var ErrTestFailed = errors.New("test failed")
+
+// Error reports which operation of a JSON Patch failed.
+type Error struct {
+	Index   int    `json:"operation"`
+	Message string `json:"message"`
+	err     error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("operation %d: %s", e.Index, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// MaxOperations caps the length of a JSON Patch document.
+const MaxOperations = 100
+
+// Apply applies a JSON Patch (RFC 6902) to a copy of doc. Either every
+// operation applies or the error is returned and doc is left alone.
+func Apply(doc any, patchJSON []byte) (any, error) {
+
+	var ops []Operation
+	if err := json.Unmarshal(patchJSON, &ops); err != nil {
+		return nil, fmt.Errorf("patch must be a JSON array of operations: %w", err)
+	}
+	if len(ops) > MaxOperations {
+		return nil, fmt.Errorf("patch has more than %d operations", MaxOperations)
+	}
+
+	result := deepCopy(doc)
+	for i, op := range ops {
+		var err error
+		result, err = applyOperation(result, op)
+		if err != nil {
+			return nil, &Error{Index: i, Message: err.Error(), err: err}
+		}
+	}
+	return result, nil
+}
+
+func applyOperation(doc any, op Operation) (any, error) {
+
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var value any
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, fmt.Errorf("%s needs a value", op.Op)
+		}
+		if err := json.Unmarshal(*op.Value, &value); err != nil {
+			return nil, err
+		}
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		if value, err = get(doc, from); err != nil {
+			return nil, fmt.Errorf("from: %w", err)
+		}
+		value = deepCopy(value)
+
+		if op.Op == "move" {
+			if len(path) > len(from) && slices.Equal(path[:len(from)], from) {
+				return nil, fmt.Errorf("cannot move %q into itself", op.From)
+			}
+			if doc, err = remove(doc, from); err != nil {
+				return nil, err
+			}
+		}
+	case "remove":
+	default:
+		return nil, fmt.Errorf("unknown op %q", op.Op)
+	}
+
+	switch op.Op {
+	case "remove":
+		return remove(doc, path)
+	case "replace":
+		if _, err := get(doc, path); err != nil {
+			return nil, err
+		}
+		if len(path) == 0 {
+			return value, nil
+		}
+		return update(doc, path, func(container any, last string) (any, error) {
+			return set(container, last, value, false)
+		})
+	case "test":
+		current, err := get(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(current, value) {
+			return nil, fmt.Errorf("%w: %s does not have the expected value", ErrTestFailed, op.Path)
+		}
+		return doc, nil
+	default: // add, move, copy
+		if len(path) == 0 {
+			return value, nil
+		}
+		return update(doc, path, func(container any, last string) (any, error) {
+			return set(container, last, value, true)
+		})
+	}
+}
+
+// set stores value in an object member or array element. With insert, an
+// array element is inserted instead of replaced.
+func set(container any, token string, value any, insert bool) (any, error) {
+
+	switch node := container.(type) {
+	case map[string]any:
+		node[token] = value
+		return node, nil
+	case []any:
+		index, err := arrayIndex(token, len(node), insert)
+		if err != nil {
+			return nil, err
+		}
+		if insert {
+			return slices.Insert(node, index, value), nil
+		}
+		node[index] = value
+		return node, nil
+	}
+	return nil, fmt.Errorf("cannot set %q on a scalar", token)
+}
+
+func remove(doc any, path []string) (any, error) {
+
+	if len(path) == 0 {
+		return nil, fmt.Errorf("cannot remove the whole document")
+	}
+	return update(doc, path, func(container any, last string) (any, error) {
+		switch node := container.(type) {
+		case map[string]any:
+			if _, ok := node[last]; !ok {
+				return nil, fmt.Errorf("member %q does not exist", last)
+			}
+			delete(node, last)
+			return node, nil
+		case []any:
+			index, err := arrayIndex(last, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			return slices.Delete(node, index, index+1), nil
+		}
+		return nil, fmt.Errorf("cannot remove %q from a scalar", last)
+	})
+}
+
+// deepCopy copies a decoded JSON value so that patches never alias the input.
+func deepCopy(value any) any {
+
+	switch node := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(node))
+		for k, v := range node {
+			out[k] = deepCopy(v)
+		}
+		return out
+	case []any:
+		out := make([]any, len(node))
+		for i, v := range node {
+			out[i] = deepCopy(v)
+		}
+		return out
+	}
+	return value
+}
@@ -0,0 +1,43 @@
+// Package patch applies JSON Merge Patch (RFC 7396) and JSON Patch
+// (RFC 6902) documents to decoded JSON values (map[string]any, []any and
+// scalars as produced by encoding/json).
+package patch
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Merge applies a JSON Merge Patch to a copy of doc: members of the patch
+// replace members of the document, null removes them and objects are
+// merged recursively.
+func Merge(doc any, patchJSON []byte) (any, error) {
+
+	var patch any
+	if err := json.Unmarshal(patchJSON, &patch); err != nil {
+		return nil, fmt.Errorf("merge patch is not valid JSON: %w", err)
+	}
+	return merge(deepCopy(doc), patch), nil
+}
+
+func merge(target, patch any) any {
+
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = map[string]any{}
+	}
+
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+			continue
+		}
+		targetObject[name] = merge(targetObject[name], value)
+	}
+	return targetObject
+}
@@ -0,0 +1,89 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decode(t *testing.T, s string) any {
+	t.Helper()
+	var v any
+	require.NoError(t, json.Unmarshal([]byte(s), &v))
+	return v
+}
+
+func TestMerge(t *testing.T) {
+	cases := []struct{ doc, patch, want string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+	}
+	for _, c := range cases {
+		doc := decode(t, c.doc)
+		got, err := Merge(doc, []byte(c.patch))
+		require.NoError(t, err)
+		assert.Equal(t, decode(t, c.want), got, c.patch)
+		assert.Equal(t, decode(t, c.doc), doc, "input is not modified")
+	}
+}
+
+func TestApply(t *testing.T) {
+	cases := []struct{ doc, patch, want string }{
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"foo":"bar","baz":"qux"}`},
+		{`{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{`{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":"qux"}]`, `{"foo":["bar","qux"]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{`{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{`{"baz":"qux"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo"}`},
+		{`{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{`{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{`{"a":{"b":1}}`, `[{"op":"copy","from":"/a","path":"/c"},{"op":"replace","path":"/c/b","value":2}]`, `{"a":{"b":1},"c":{"b":2}}`},
+		{`{"a/b":1,"m~n":2}`, `[{"op":"test","path":"/a~1b","value":1},{"op":"remove","path":"/m~0n"}]`, `{"a/b":1}`},
+	}
+	for _, c := range cases {
+		doc := decode(t, c.doc)
+		got, err := Apply(doc, []byte(c.patch))
+		require.NoError(t, err, c.patch)
+		assert.Equal(t, decode(t, c.want), got, c.patch)
+		assert.Equal(t, decode(t, c.doc), doc, "input is not modified")
+	}
+}
+
+func TestApply_Errors(t *testing.T) {
+	cases := []struct {
+		doc, patch string
+		index      int
+	}{
+		{`{"baz":"qux"}`, `[{"op":"test","path":"/baz","value":"bar"}]`, 0},
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`, 0},
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/a","value":1},{"op":"remove","path":"/missing"}]`, 1},
+		{`{"foo":["bar"]}`, `[{"op":"add","path":"/foo/5","value":1}]`, 0},
+		{`{"foo":["bar"]}`, `[{"op":"replace","path":"/foo/01","value":1}]`, 0},
+		{`{"foo":{"bar":1}}`, `[{"op":"move","from":"/foo","path":"/foo/bar/x"}]`, 0},
+		{`{}`, `[{"op":"frobnicate","path":"/a"}]`, 0},
+		{`{}`, `[{"op":"add","path":"a","value":1}]`, 0},
+		{`{}`, `[{"op":"add","path":"/a"}]`, 0},
+	}
+	for _, c := range cases {
+		doc := decode(t, c.doc)
+		_, err := Apply(doc, []byte(c.patch))
+		var patchErr *Error
+		if assert.ErrorAs(t, err, &patchErr, c.patch) {
+			assert.Equal(t, c.index, patchErr.Index, c.patch)
+			assert.Equal(t, c.patch == cases[0].patch, errors.Is(err, ErrTestFailed), c.patch)
+		}
+		assert.Equal(t, decode(t, c.doc), doc, "input is not modified")
+	}
+
+	_, err := Apply(map[string]any{}, []byte(`{"op":"add"}`))
+	assert.Error(t, err, "a patch must be an array")
+}
@@ -0,0 +1,103 @@
+package patch
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parsePointer splits a JSON Pointer (RFC 6901) into its unescaped tokens.
+// The empty pointer refers to the whole document.
+func parsePointer(pointer string) ([]string, error) {
+
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("path %q must start with /", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens, nil
+}
+
+// arrayIndex parses an array index token. "-" (the end of the array) is
+// only valid where allowEnd is set, i.e. when adding.
+func arrayIndex(token string, length int, allowEnd bool) (int, error) {
+
+	if token == "-" && allowEnd {
+		return length, nil
+	}
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+
+	max := length - 1
+	if allowEnd {
+		max = length
+	}
+	if index > max {
+		return 0, fmt.Errorf("array index %d out of range", index)
+	}
+	return index, nil
+}
+
+// get returns the value at the pointer tokens.
+func get(doc any, tokens []string) (any, error) {
+
+	for _, token := range tokens {
+		switch node := doc.(type) {
+		case map[string]any:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("member %q does not exist", token)
+			}
+			doc = value
+		case []any:
+			index, err := arrayIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[index]
+		default:
+			return nil, fmt.Errorf("cannot descend into %q", token)
+		}
+	}
+	return doc, nil
+}
+
+// update replaces the container holding the last token with the result of
+// change, which receives that container. Because arrays may grow or shrink,
+// the changed container is written back into its parent.
+func update(doc any, tokens []string, change func(container any, last string) (any, error)) (any, error) {
+
+	if len(tokens) == 1 {
+		return change(doc, tokens[0])
+	}
+
+	child, err := get(doc, tokens[:1])
+	if err != nil {
+		return nil, err
+	}
+	child, err = update(child, tokens[1:], change)
+	if err != nil {
+		return nil, err
+	}
+
+	switch node := doc.(type) {
+	case map[string]any:
+		node[tokens[0]] = child
+	case []any:
+		index, _ := arrayIndex(tokens[0], len(node), false)
+		node[index] = child
+	}
+	return doc, nil
+}
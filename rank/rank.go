@@ -0,0 +1,161 @@
+// Package rank generates lexicographic ranks: strings whose byte order is
+// the order of the items they label. A new rank can always be made between
+// two others, so moving an item only rewrites that item's rank.
+package rank
+
+import (
+	"errors"
+	"strings"
+)
+
+// digits are the rank characters, in ascending byte order.
+const digits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+const base = len(digits)
+
+// MaxLength is the longest rank Between produces. Ranks grow by about one
+// character for every five moves into the same gap; once they get this long
+// the list should be spread out again.
+const MaxLength = 24
+
+var (
+	// ErrOrder is returned when the lower rank is not below the upper one.
+	ErrOrder = errors.New("rank: lower bound must sort before upper bound")
+	// ErrInvalid is returned for ranks with characters outside 0-9a-z or
+	// ending in 0, which leave no room below them.
+	ErrInvalid = errors.New("rank: invalid rank")
+	// ErrTooLong is returned when the gap is exhausted and the ranks need to
+	// be spread out.
+	ErrTooLong = errors.New("rank: ranks too dense")
+)
+
+// Between returns a rank that sorts after lower and before upper. An empty
+// lower means the start of the list, an empty upper its end.
+//
+// Ranks at the end of the list count up instead of halving the gap, so
+// appending one item after another keeps them short.
+func Between(lower, upper string) (string, error) {
+
+	if !valid(lower) || !valid(upper) {
+		return "", ErrInvalid
+	}
+	if upper == "" {
+		return after(lower)
+	}
+	if lower >= upper {
+		return "", ErrOrder
+	}
+
+	var prefix strings.Builder
+	// open is set once the result is known to sort before upper whatever
+	// follows the prefix.
+	open := false
+	for i := 0; prefix.Len() < MaxLength; i++ {
+		lo := 0
+		if i < len(lower) {
+			lo = strings.IndexByte(digits, lower[i])
+		}
+		hi := base
+		if !open {
+			hi = strings.IndexByte(digits, upper[i])
+		}
+
+		if hi-lo > 1 {
+			prefix.WriteByte(digits[(lo+hi)/2])
+			return prefix.String(), nil
+		}
+		prefix.WriteByte(digits[lo])
+		if hi != lo {
+			open = true
+		}
+	}
+	return "", ErrTooLong
+}
+
+// minCountWidth is the width ranks are padded to when counting up.
+const minCountWidth = 3
+
+// after returns the rank following lower at the end of the list: lower,
+// padded with zeros, plus one. Only when every digit is already z does the
+// rank get longer.
+func after(lower string) (string, error) {
+
+	if lower == "" {
+		return digits[base/2 : base/2+1], nil
+	}
+
+	b := []byte(lower)
+	for len(b) < minCountWidth {
+		b = append(b, '0')
+	}
+	for {
+		i := len(b) - 1
+		for i >= 0 && b[i] == digits[base-1] {
+			b[i] = '0'
+			i--
+		}
+		if i < 0 {
+			b = []byte(lower + digits[base/2:base/2+1])
+			break
+		}
+		b[i] = digits[strings.IndexByte(digits, b[i])+1]
+		if b[len(b)-1] != '0' {
+			break
+		}
+	}
+
+	if len(b) > MaxLength {
+		return "", ErrTooLong
+	}
+	return string(b), nil
+}
+
+// Spread returns n evenly spaced ranks of equal length, in ascending order.
+func Spread(n int) []string {
+
+	if n <= 0 {
+		return nil
+	}
+
+	// leave a full digit of room between neighbours
+	width, capacity := 1, base
+	for capacity/base < n+1 {
+		width++
+		capacity *= base
+	}
+	step := capacity / (n + 1)
+
+	ranks := make([]string, n)
+	for k := range ranks {
+		ranks[k] = encode((k+1)*step, width)
+	}
+	return ranks
+}
+
+// encode writes v in base 36 with the given width. A trailing 0 is bumped
+// to 1, which keeps the order since neighbours are at least base apart.
+func encode(v, width int) string {
+
+	b := make([]byte, width)
+	for i := width - 1; i >= 0; i-- {
+		b[i] = digits[v%base]
+		v /= base
+	}
+	if b[width-1] == '0' {
+		b[width-1] = '1'
+	}
+	return string(b)
+}
+
+func valid(r string) bool {
+
+	if r == "" {
+		return true
+	}
+	for i := 0; i < len(r); i++ {
+		if strings.IndexByte(digits, r[i]) < 0 {
+			return false
+		}
+	}
+	return r[len(r)-1] != '0'
+}
@@ -0,0 +1,110 @@
+package rank
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBetween(t *testing.T) {
+	cases := []struct{ lower, upper string }{
+		{"", ""},
+		{"", "1"},
+		{"i", ""},
+		{"a", "b"},
+		{"a", "a1"},
+		{"az", "b"},
+		{"zz", ""},
+		{"", "001"},
+		{"01", "02"},
+	}
+	for _, c := range cases {
+		r, err := Between(c.lower, c.upper)
+		require.NoError(t, err, "%q..%q", c.lower, c.upper)
+		assert.Greater(t, r, c.lower, "%q..%q", c.lower, c.upper)
+		if c.upper != "" {
+			assert.Less(t, r, c.upper, "%q..%q", c.lower, c.upper)
+		}
+		assert.NotEqual(t, byte('0'), r[len(r)-1])
+	}
+}
+
+func TestBetween_Errors(t *testing.T) {
+	_, err := Between("b", "a")
+	assert.ErrorIs(t, err, ErrOrder)
+	_, err = Between("a", "a")
+	assert.ErrorIs(t, err, ErrOrder)
+	_, err = Between("A", "")
+	assert.ErrorIs(t, err, ErrInvalid)
+	_, err = Between("", "a0")
+	assert.ErrorIs(t, err, ErrInvalid)
+}
+
+func TestBetween_RepeatedInsertsRunOutOfRoom(t *testing.T) {
+	lower, upper := "a", "b"
+	var err error
+	moves := 0
+	for ; moves < 1000; moves++ {
+		var r string
+		if r, err = Between(lower, upper); err != nil {
+			break
+		}
+		upper = r
+	}
+	assert.ErrorIs(t, err, ErrTooLong)
+	assert.Greater(t, moves, 50, "a gap survives many inserts before it needs spreading")
+}
+
+func TestBetween_AppendingStaysShort(t *testing.T) {
+	last := ""
+	for i := 0; i < 20000; i++ {
+		r, err := Between(last, "")
+		require.NoError(t, err)
+		require.Greater(t, r, last)
+		last = r
+	}
+	assert.LessOrEqual(t, len(last), 4)
+}
+
+func TestSpread(t *testing.T) {
+	for _, n := range []int{1, 2, 35, 36, 1000, 50000} {
+		ranks := Spread(n)
+		require.Len(t, ranks, n)
+		assert.True(t, sort.StringsAreSorted(ranks), "n=%d", n)
+		for i, r := range ranks {
+			assert.Equal(t, len(ranks[0]), len(r))
+			if i > 0 {
+				_, err := Between(ranks[i-1], r)
+				assert.NoError(t, err, "room between neighbours, n=%d", n)
+			}
+		}
+		_, err := Between(ranks[n-1], "")
+		assert.NoError(t, err)
+	}
+}
+
+func TestBetween_RandomMovesKeepOrder(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	list := Spread(20)
+	for i := 0; i < 500; i++ {
+		at := rng.Intn(len(list) + 1)
+		lower, upper := "", ""
+		if at > 0 {
+			lower = list[at-1]
+		}
+		if at < len(list) {
+			upper = list[at]
+		}
+		r, err := Between(lower, upper)
+		if err != nil {
+			require.ErrorIs(t, err, ErrTooLong)
+			list = Spread(len(list))
+			continue
+		}
+		list = append(list[:at], append([]string{r}, list[at:]...)...)
+		require.True(t, sort.StringsAreSorted(list))
+	}
+}
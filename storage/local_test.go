@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocal_PutOpenDelete(t *testing.T) {
+	store, err := NewLocal(t.TempDir())
+	require.NoError(t, err)
+
+	n, err := store.Put("tasks/1/blob", strings.NewReader("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), n)
+
+	r, err := store.Open("tasks/1/blob")
+	require.NoError(t, err)
+	data, _ := io.ReadAll(r)
+	r.Close()
+	assert.Equal(t, "hello", string(data))
+
+	require.NoError(t, store.Delete("tasks/1/blob"))
+	require.NoError(t, store.Delete("tasks/1/blob"), "deleting twice is fine")
+
+	_, err = store.Open("tasks/1/blob")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestLocal_RejectsEscapingKeys(t *testing.T) {
+	store, err := NewLocal(t.TempDir())
+	require.NoError(t, err)
+
+	for _, key := range []string{"", "../secret", "/etc/passwd", "tasks/../../x"} {
+		_, err := store.Put(key, strings.NewReader("x"))
+		assert.Error(t, err, key)
+	}
+}
@@ -0,0 +1,21 @@
+package storage
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrNotFound is returned by Open when no blob is stored under the key.
+var ErrNotFound = errors.New("storage: blob not found")
+
+// Storage keeps the bytes of uploaded files. Keys are slash separated paths
+// chosen by the caller, e.g. "tasks/12/3f2a...". Implementations must be safe
+// for concurrent use.
+type Storage interface {
+	// Put stores everything read from r under key and returns the number of bytes written.
+	Put(key string, r io.Reader) (int64, error)
+	// Open returns a reader for the blob stored under key.
+	Open(key string) (io.ReadCloser, error)
+	// Delete removes the blob. Deleting a missing blob is not an error.
+	Delete(key string) error
+}
@@ -15,11 +15,19 @@ type SwaggerTask struct {
 	UpdatedAt time.Time `json:"updated_at"`
 	DeletedAt time.Time `json:"deleted_at,omitempty"`
 
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	Priority    int    `json:"priority"`
-	Status      string `json:"status"`
-	UserID      uint   `json:"user_id"`
+	Title            string `json:"title"`
+	Description      string `json:"description"`
+	Priority         int    `json:"priority"`
+	Status           string `json:"status"`
+	UserID           uint   `json:"user_id"`
+	AssigneeID       *uint  `json:"assignee_id,omitempty"`
+	Version          uint   `json:"version"`
+	DueAt            string `json:"due_at,omitempty"`
+	BoardRank        string `json:"board_rank"`
+	EstimatedMinutes *int   `json:"estimated_minutes,omitempty"`
+	ParentID         *uint  `json:"parent_id,omitempty"`
+
+	CustomFields map[string]interface{} `json:"custom_fields,omitempty"`
 }
 
 // @Schema
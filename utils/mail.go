@@ -0,0 +1,171 @@
+package utils
+
+import (
+	"bytes"
+	"html/template"
+	"os"
+	"path/filepath"
+
+	"github.com/resend/resend-go/v2"
+	"github.com/rs/zerolog/log"
+)
+
+func SendVerificationMail(userName, toEmail, verifyLink string) error {
+
+	// template data format
+	data := struct {
+		Name       string
+		VerifyLink string
+		Time       string
+	}{
+		Name:       userName,
+		VerifyLink: verifyLink,
+		Time:       "10 minutes",
+	}
+
+	return sendTemplateMail(toEmail, "Golang Task API user registration confirmation", "verifyEmail.html", data)
+}
+
+func SendAssignmentMail(assigneeName, toEmail, assignerName, taskTitle, taskLink string) error {
+
+	data := struct {
+		Name      string
+		Assigner  string
+		TaskTitle string
+		TaskLink  string
+	}{
+		Name:      assigneeName,
+		Assigner:  assignerName,
+		TaskTitle: taskTitle,
+		TaskLink:  taskLink,
+	}
+
+	return sendTemplateMail(toEmail, "A task has been assigned to you", "taskAssigned.html", data)
+}
+
+func SendMentionMail(mentionedName, toEmail, authorName, taskTitle, commentBody, taskLink string) error {
+
+	data := struct {
+		Name      string
+		Author    string
+		TaskTitle string
+		Comment   string
+		TaskLink  string
+	}{
+		Name:      mentionedName,
+		Author:    authorName,
+		TaskTitle: taskTitle,
+		Comment:   commentBody,
+		TaskLink:  taskLink,
+	}
+
+	return sendTemplateMail(toEmail, authorName+" mentioned you in a comment", "commentMention.html", data)
+}
+
+func SendCommentMail(userName, toEmail, authorName, taskTitle, commentBody, taskLink string) error {
+
+	data := struct {
+		Name      string
+		Author    string
+		TaskTitle string
+		Comment   string
+		TaskLink  string
+	}{
+		Name:      userName,
+		Author:    authorName,
+		TaskTitle: taskTitle,
+		Comment:   commentBody,
+		TaskLink:  taskLink,
+	}
+
+	return sendTemplateMail(toEmail, authorName+" commented on "+taskTitle, "commentAdded.html", data)
+}
+
+func SendReminderMail(userName, toEmail, taskTitle, dueTime, taskLink string) error {
+
+	data := struct {
+		Name      string
+		TaskTitle string
+		DueTime   string
+		TaskLink  string
+	}{
+		Name:      userName,
+		TaskTitle: taskTitle,
+		DueTime:   dueTime,
+		TaskLink:  taskLink,
+	}
+
+	return sendTemplateMail(toEmail, "Reminder: "+taskTitle+" is due "+dueTime, "taskReminder.html", data)
+}
+
+// DigestTask is one line of a digest email.
+type DigestTask struct {
+	Title   string
+	DueTime string
+	Link    string
+}
+
+func SendDigestMail(userName, toEmail, period string, overdue, upcoming []DigestTask) error {
+
+	data := struct {
+		Name     string
+		Period   string
+		Overdue  []DigestTask
+		Upcoming []DigestTask
+	}{
+		Name:     userName,
+		Period:   period,
+		Overdue:  overdue,
+		Upcoming: upcoming,
+	}
+
+	return sendTemplateMail(toEmail, "Your "+period+" task digest", "taskDigest.html", data)
+}
+
+// sendTemplateMail renders template/<tmplName> with data and sends it through
+// Resend. Missing mail config or an unparsable template only logs, so callers
+// running in the background never fail a request because of mail.
+func sendTemplateMail(toEmail, subject, tmplName string, data any) error {
+
+	fromAddr := os.Getenv("RESEND_FROM_ADDRESS")
+	resendApiKey := os.Getenv("RESEND_API_KEY")
+
+	if resendApiKey == "" || fromAddr == "" {
+		log.Warn().Msg("Resend mail 'from address or api key' config missing - skipping mail send")
+		return nil
+	}
+
+	// load html template
+	tmplPath := filepath.Join("template", tmplName)
+	tmpl, err := template.ParseFiles(tmplPath)
+	if err != nil {
+		log.Error().Err(err).Msgf("Failed to parse %s template", tmplName)
+		return nil
+	}
+
+	var htmlBody bytes.Buffer
+	err = tmpl.Execute(&htmlBody, data)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to render email template")
+		return err
+	}
+
+	// send mail using resend
+	client := resend.NewClient(resendApiKey)
+
+	params := &resend.SendEmailRequest{
+		From:    fromAddr,
+		To:      []string{toEmail},
+		Subject: subject,
+		Html:    htmlBody.String(),
+	}
+
+	_, err = client.Emails.Send(params)
+	if err != nil {
+		log.Error().Err(err).Str("to", toEmail).Str("template", tmplName).Msg("Failed to send mail")
+		return err
+	}
+
+	log.Info().Str("to", toEmail).Str("template", tmplName).Msg("Mail sent successfully")
+	return nil
+}
@@ -1,14 +1,10 @@
 package utils
 
 import (
-	"bytes"
-	"html/template"
 	"net/http"
 	"os"
-	"path/filepath"
 
 	"github.com/gin-gonic/gin"
-	"github.com/resend/resend-go/v2"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"golang.org/x/crypto/bcrypt"
@@ -47,62 +43,6 @@ func UserIDFromContext(ctx *gin.Context) (uint, bool) {
 	return uid, true
 }
 
-func SendVerificationMail(userName, toEmail, verifyLink string) error {
-
-	fromAddr := os.Getenv("RESEND_FROM_ADDRESS")
-	resendApiKey := os.Getenv("RESEND_API_KEY")
-
-	if resendApiKey == "" || fromAddr == "" {
-		log.Warn().Msg("Resend mail 'from address or api key' config missing - skipping mail send")
-		return nil
-	}
-
-	// load html template
-	tmplPath := filepath.Join("template", "verifyEmail.html")
-	tmpl, err := template.ParseFiles(tmplPath)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to parse verifyEmail.html template")
-		return nil
-	}
-
-	// template data format
-	data := struct {
-		Name       string
-		VerifyLink string
-		Time       string
-	}{
-		Name:       userName,
-		VerifyLink: verifyLink,
-		Time:       "10 minutes",
-	}
-
-	var htmlBody bytes.Buffer
-	err = tmpl.Execute(&htmlBody, data)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to render email template")
-		return err
-	}
-
-	// send mail using resend
-	client := resend.NewClient(resendApiKey)
-
-	params := &resend.SendEmailRequest{
-		From:    fromAddr,
-		To:      []string{toEmail},
-		Subject: "Golang Task API user registration confirmation",
-		Html:    htmlBody.String(),
-	}
-
-	_, err = client.Emails.Send(params)
-	if err != nil {
-		log.Error().Err(err).Str("to", toEmail).Msg("Failed to send verification mail")
-		return err
-	}
-
-	log.Info().Str("to", toEmail).Msg("Verification mail sent successfully")
-	return nil
-}
-
 func InitLogger() {
 
 	if os.Getenv("ENV") == "development" || os.Getenv("ENV") == "" {